@@ -0,0 +1,21 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+// Package catalog defines the classes of ships and items in Far Horizons.
+//
+// It is the single source of truth for class abbreviations, costs and
+// capacities. The order parser uses it to recognize abbreviations, the
+// engine uses it to build and operate ships and items, and the reports
+// use it for names and capacities.
+package catalog
+
+// Errors used by the package.
+const (
+	ErrInvalidClass = constError("invalid class")
+)
+
+// declarations to support constant errors
+type constError string
+
+func (ce constError) Error() string {
+	return string(ce)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package catalog_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/catalog"
+	"testing"
+)
+
+func TestLookupShip(t *testing.T) {
+	for _, tc := range []struct {
+		code     string
+		class    string
+		kind     catalog.Kind
+		subLight bool
+		tonnage  int
+		cost     int
+		capacity int
+		minMA    int
+	}{
+		{"CL", "CL", catalog.Warship, false, 200_000, 2000, 20, 40},
+		{"ffs", "FF", catalog.Warship, true, 100_000, 750, 10, 20},
+		{"TR7", "TR7", catalog.Transport, false, 70_000, 700, 91, 14},
+		{"TR15S", "TR15", catalog.Transport, true, 150_000, 1125, 255, 30},
+		{"BAS", "BAS", catalog.Starbase, false, 0, 0, 0, 0},
+	} {
+		sc, subLight, err := catalog.LookupShip(tc.code)
+		if err != nil {
+			t.Errorf("%s: err: expected nil: got %v\n", tc.code, err)
+			continue
+		}
+		if sc.Code != tc.class || sc.Kind != tc.kind || subLight != tc.subLight || sc.Tonnage != tc.tonnage {
+			t.Errorf("%s: expected %s %d %v %d: got %s %d %v %d\n", tc.code, tc.class, tc.kind, tc.subLight, tc.tonnage, sc.Code, sc.Kind, subLight, sc.Tonnage)
+		}
+		if got := catalog.ShipCost(sc.Tonnage, subLight); got != tc.cost {
+			t.Errorf("%s: cost: expected %d: got %d\n", tc.code, tc.cost, got)
+		}
+		if got := catalog.CarryingCapacity(sc.Kind, sc.Tonnage); got != tc.capacity {
+			t.Errorf("%s: capacity: expected %d: got %d\n", tc.code, tc.capacity, got)
+		}
+		if got := catalog.MinMA(sc.Tonnage); got != tc.minMA {
+			t.Errorf("%s: min MA: expected %d: got %d\n", tc.code, tc.minMA, got)
+		}
+	}
+
+	for _, code := range []string{"BASS", "TR", "TR0", "TR07", "TRX", "XX", "PL"} {
+		if _, _, err := catalog.LookupShip(code); !errors.Is(err, catalog.ErrInvalidClass) {
+			t.Errorf("%s: err: expected ErrInvalidClass: got %v\n", code, err)
+		}
+	}
+
+	if got := catalog.CarryingCapacity(catalog.Starbase, 150_000); got != 150 {
+		t.Errorf("BAS: capacity: expected 150: got %d\n", got)
+	}
+	if got := catalog.CombatTonnage(catalog.Transport, 150_000); got != 15_000 {
+		t.Errorf("TR15: combat tonnage: expected 15000: got %d\n", got)
+	}
+}
+
+func TestLookupItem(t *testing.T) {
+	for _, tc := range []struct {
+		code     string
+		tech     string
+		minLevel int
+		carry    int
+		cost     int // at tech level 50
+	}{
+		{"pd", "", 0, 3, 1},
+		{"SU", "MA", 20, 20, 110},
+		{"TP", "BI", 40, 100, 1000},
+		{"GU3", "ML", 30, 15, 750},
+		{"SG9", "LS", 90, 45, 2250},
+	} {
+		item, ok := catalog.LookupItem(tc.code)
+		if !ok {
+			t.Errorf("%s: expected item: got none\n", tc.code)
+			continue
+		}
+		if item.Tech != tc.tech || item.MinLevel != tc.minLevel || item.Carry != tc.carry || item.UnitCost(50) != tc.cost {
+			t.Errorf("%s: expected %s %d %d %d: got %s %d %d %d\n", tc.code, tc.tech, tc.minLevel, tc.carry, tc.cost, item.Tech, item.MinLevel, item.Carry, item.UnitCost(50))
+		}
+	}
+	if item, _ := catalog.LookupItem("RM"); item.Cost != 0 {
+		t.Errorf("RM: cost: expected 0: got %d\n", item.Cost)
+	}
+	if _, ok := catalog.LookupItem("GU0"); ok {
+		t.Errorf("GU0: expected no item\n")
+	}
+}
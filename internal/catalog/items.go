@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Item is a class of item that can be built, stored and carried.
+type Item struct {
+	Code     string
+	Name     string
+	Cost     int    // cost of one unit, 0 if the item can't be built
+	Carry    int    // carrying capacity needed for one unit
+	Tech     string // technology needed to build the item, if any
+	MinLevel int    // minimum level of Tech
+	Tonnage  int    // equivalent tonnage of gun units and shield generators
+
+	// costDivided is true if the cost is divided by the builder's tech level
+	costDivided bool
+}
+
+// UnitCost returns the cost of one unit for a builder with the given level
+// in the item's technology.
+func (it *Item) UnitCost(level int) int {
+	if it.costDivided {
+		if level < 1 {
+			return 0
+		}
+		return it.Cost / level
+	}
+	return it.Cost
+}
+
+// Items is the list of items, in the order they are listed on reports.
+var Items []*Item
+
+var itemsByCode = make(map[string]*Item)
+
+func init() {
+	Items = []*Item{
+		{Code: "RM", Name: "Raw Material Units", Carry: 1},
+		{Code: "PD", Name: "Planetary Defense Units", Cost: 1, Carry: 3},
+		{Code: "SU", Name: "Starbase Units", Cost: 110, Carry: 20, Tech: "MA", MinLevel: 20},
+		{Code: "DR", Name: "Damage Repair Units", Cost: 50, Carry: 1, Tech: "MA", MinLevel: 30},
+		{Code: "CU", Name: "Colonist Units", Cost: 1, Carry: 1},
+		{Code: "IU", Name: "Colonial Mining Units", Cost: 1, Carry: 1},
+		{Code: "AU", Name: "Colonial Manufacturing Units", Cost: 1, Carry: 1},
+		{Code: "FS", Name: "Fail-Safe Jump Units", Cost: 25, Carry: 1, Tech: "GV", MinLevel: 20},
+		{Code: "JP", Name: "Jump Portal Units", Cost: 100, Carry: 10, Tech: "GV", MinLevel: 25},
+		{Code: "FM", Name: "Forced Mis-jump Units", Cost: 100, Carry: 5, Tech: "GV", MinLevel: 30},
+		{Code: "FJ", Name: "Forced Jump Units", Cost: 125, Carry: 5, Tech: "GV", MinLevel: 40},
+		{Code: "GT", Name: "Gravitic Telescope Units", Cost: 500, Carry: 20, Tech: "GV", MinLevel: 50},
+		{Code: "FD", Name: "Field Distortion Units", Cost: 50, Carry: 1, Tech: "LS", MinLevel: 20},
+		{Code: "TP", Name: "Terraforming Plants", Cost: 50_000, Carry: 100, Tech: "BI", MinLevel: 40, costDivided: true},
+		{Code: "GW", Name: "Germ Warfare Bombs", Cost: 1000, Carry: 100, Tech: "BI", MinLevel: 50},
+	}
+	for n := 1; n <= 9; n++ {
+		Items = append(Items, &Item{Code: fmt.Sprintf("GU%d", n), Name: fmt.Sprintf("Mark-%d Gun Units", n), Cost: 250 * n, Carry: 5 * n, Tech: "ML", MinLevel: 10 * n, Tonnage: 50_000 * n})
+	}
+	for n := 1; n <= 9; n++ {
+		Items = append(Items, &Item{Code: fmt.Sprintf("SG%d", n), Name: fmt.Sprintf("Mark-%d Shield Generators", n), Cost: 250 * n, Carry: 5 * n, Tech: "LS", MinLevel: 10 * n, Tonnage: 50_000 * n})
+	}
+	for _, item := range Items {
+		itemsByCode[item.Code] = item
+	}
+}
+
+// LookupItem returns the item for a class abbreviation. Case is not significant.
+func LookupItem(code string) (*Item, bool) {
+	item, ok := itemsByCode[strings.ToUpper(code)]
+	return item, ok
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of ship.
+type Kind int
+
+const (
+	Warship Kind = iota + 1
+	Transport
+	Starbase
+)
+
+// ShipClass is a class of ship or starbase.
+type ShipClass struct {
+	Code    string // abbreviation without the sub-light suffix, e.g. "CL", "TR10" or "BAS"
+	Name    string
+	Kind    Kind
+	Tonnage int // 0 for starbases, which grow as they are built
+}
+
+// TonnagePerMA is the tonnage that each level of manufacturing tech allows.
+// The largest ship or starbase a species can build is 5000 times its MA.
+const TonnagePerMA = 5000
+
+// StarbaseIncrement is the tonnage that starbases are built in.
+const StarbaseIncrement = 10_000
+
+// ShipClasses is the table of warship classes from the manual.
+var ShipClasses = []ShipClass{
+	{"PB", "Picketboat", Warship, 10_000},
+	{"CT", "Corvette", Warship, 20_000},
+	{"ES", "Escort", Warship, 50_000},
+	{"FF", "Frigate", Warship, 100_000},
+	{"DD", "Destroyer", Warship, 150_000},
+	{"CL", "Light Cruiser", Warship, 200_000},
+	{"CS", "Strike Cruiser", Warship, 250_000},
+	{"CA", "Heavy Cruiser", Warship, 300_000},
+	{"CC", "Command Cruiser", Warship, 350_000},
+	{"BC", "Battlecruiser", Warship, 400_000},
+	{"BS", "Battleship", Warship, 450_000},
+	{"DN", "Dreadnought", Warship, 500_000},
+	{"SD", "Super Dreadnought", Warship, 550_000},
+	{"BM", "Battlemoon", Warship, 600_000},
+	{"BW", "Battleworld", Warship, 650_000},
+	{"BR", "Battlestar", Warship, 700_000},
+}
+
+var starbase = ShipClass{Code: "BAS", Name: "Starbase", Kind: Starbase}
+
+// LookupShip returns the class for an abbreviation like "CL", "FFS",
+// "TR10S" or "BAS". Ships with an "S" suffix are sub-light; starbases
+// don't have a sub-light version. Case is not significant.
+func LookupShip(code string) (ShipClass, bool, error) {
+	code = strings.ToUpper(code)
+	if code == starbase.Code {
+		return starbase, false, nil
+	}
+	for _, sc := range ShipClasses {
+		if code == sc.Code || code == sc.Code+"S" {
+			return sc, code != sc.Code, nil
+		}
+	}
+	if size, ok := strings.CutPrefix(code, "TR"); ok {
+		size, subLight := strings.CutSuffix(size, "S")
+		// no leading zeros, so that each transport has just one name
+		if n, err := strconv.Atoi(size); err == nil && n > 0 && !strings.HasPrefix(size, "0") {
+			return ShipClass{Code: fmt.Sprintf("TR%d", n), Name: "Transport", Kind: Transport, Tonnage: n * 10_000}, subLight, nil
+		}
+	}
+	return ShipClass{}, false, fmt.Errorf("%q: %w", code, ErrInvalidClass)
+}
+
+// MinMA returns the manufacturing tech level needed to build a ship of the tonnage.
+func MinMA(tonnage int) int {
+	return (tonnage + TonnagePerMA - 1) / TonnagePerMA
+}
+
+// ShipCost returns the cost of building a ship of the given tonnage.
+// The FTL cost is the tonnage divided by 100, and sub-light ships cost
+// 25% less. Starbases cost the same as FTL ships.
+func ShipCost(tonnage int, subLight bool) int {
+	if subLight {
+		return tonnage * 3 / 400
+	}
+	return tonnage / 100
+}
+
+// CarryingCapacity returns the number of cargo units that a ship can carry.
+func CarryingCapacity(kind Kind, tonnage int) int {
+	switch kind {
+	case Transport:
+		n := tonnage / 10_000
+		return (10 + n/2) * n
+	case Starbase:
+		return tonnage / 1000
+	}
+	return tonnage / 10_000
+}
+
+// CombatTonnage returns the tonnage that a ship fights as.
+// Transports have about one-tenth the offensive and defensive capability
+// of warships, and starbases fight as warships of the same tonnage.
+func CombatTonnage(kind Kind, tonnage int) int {
+	if kind == Transport {
+		return tonnage / 10
+	}
+	return tonnage
+}
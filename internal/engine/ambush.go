@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+	"sort"
+)
+
+const (
+	// interceptWarship and interceptTransport are the largest ships that
+	// can be intercepted, unless they came through a natural wormhole.
+	interceptWarship   = 50_000
+	interceptTransport = 200_000
+)
+
+// Ambush is an amount spent in production to prepare for enemies arriving
+// in a system. It is used in the first battle there that the species
+// attacks in, which can be in the strike phase of the same turn or the
+// combat phase of the next. After that it is wasted.
+type Ambush struct {
+	Species int    `json:"species"`
+	Coords  Coords `json:"coords"`
+	Amount  int    `json:"amount"`
+}
+
+// ambush spends production to prepare an ambush in the producing planet's
+// system. Amounts from every planet in the system add up. Besieged planets
+// can't prepare ambushes.
+func ambush(t *Turn, sp *Species, cmd *orders.Command) error {
+	c, n, err := t.preparation(sp, cmd)
+	if err != nil {
+		return err
+	}
+	for _, a := range t.Game.Ambushes {
+		if a.Species == sp.Id && a.Coords == c {
+			a.Amount += n
+			return nil
+		}
+	}
+	t.Game.Ambushes = append(t.Game.Ambushes, &Ambush{Species: sp.Id, Coords: c, Amount: n})
+	return nil
+}
+
+// intercept spends production to intercept enemy ships that jumped into
+// the producing planet's system this turn. Amounts from every planet in
+// the system add up, and are used once production is over. Besieged
+// planets can't intercept.
+func intercept(t *Turn, sp *Species, cmd *orders.Command) error {
+	c, n, err := t.preparation(sp, cmd)
+	if err != nil {
+		return err
+	}
+	if t.intercepts[sp.Id] == nil {
+		t.intercepts[sp.Id] = make(map[Coords]int)
+	}
+	t.intercepts[sp.Id][c] += n
+	return nil
+}
+
+// preparation spends the amount for an AMBUSH or INTERCEPT order and
+// returns the producing planet's sector.
+func (t *Turn) preparation(sp *Species, cmd *orders.Command) (Coords, int, error) {
+	colony, _, err := t.producer(sp)
+	if err != nil {
+		return Coords{}, 0, err
+	} else if len(t.sieges(colony)) != 0 {
+		return Coords{}, 0, fmt.Errorf("PL %s: under siege: %w", colony.Name, ErrNotAllowed)
+	}
+	n := cmd.Args[0].Number
+	if n <= 0 {
+		return Coords{}, 0, fmt.Errorf("%d: %w", n, ErrInvalidAmount)
+	} else if err := t.spend(sp, cmd, n); err != nil {
+		return Coords{}, 0, err
+	}
+	return t.Game.Galaxy.StarOf(colony.Planet).Coords, n, nil
+}
+
+// interceptions uses the amounts spent on interception against the enemy
+// ships that jumped into each system this turn. Ships are taken in random
+// order and destroyed until one costs more than is left or is too big to
+// intercept. A warship costs 1 for each 100 tons and a transport a tenth
+// of that. Field-distorted ships are always treated as enemies. Each
+// species intercepts on its own. The owners of the ships are
+// only told that they disappeared.
+func interceptions(t *Turn) {
+	for _, id := range t.SpeciesIds() {
+		var locations []Coords
+		for c := range t.intercepts[id] {
+			locations = append(locations, c)
+		}
+		sort.Slice(locations, func(i, j int) bool { return locations[i].less(locations[j]) })
+		for _, c := range locations {
+			var targets []*Ship
+			for _, ship := range t.Game.Ships {
+				if ship.Coords == c && ship.InTransit && ship.Species != id && (t.Game.declaredEnemy(id, ship.Species) || ship.IsDistorted()) {
+					targets = append(targets, ship)
+				}
+			}
+			for i := len(targets) - 1; i > 0; i-- {
+				j := t.RNG.Intn(i + 1)
+				targets[i], targets[j] = targets[j], targets[i]
+			}
+			funds := t.intercepts[id][c]
+			for _, ship := range targets {
+				cost, limit := ship.Tonnage/100, interceptWarship
+				if ship.Kind() == catalog.Transport {
+					cost, limit = cost/10, interceptTransport
+				}
+				if (ship.Tonnage > limit && !ship.Wormhole) || cost > funds {
+					break
+				}
+				funds -= cost
+				t.Game.removeShip(ship)
+				t.Logf(id, 0, "%s %s: intercepted and destroyed at %s", t.Game.Identify(t.Game.SpeciesById(id), ship), ship, c)
+				t.Logf(ship.Species, 0, "%s: disappeared without a trace at %s", ship, c)
+			}
+		}
+	}
+}
+
+// ambush springs the ambushes prepared here by species that are attacking.
+// Every ship of the species they attack is aged by
+//
+//	10,000 x amount / (warship tonnage + transport tonnage / 10)
+//
+// using the total of all the ambushes and the tonnage of the victims. The
+// aging is scaled by the ratio of the ambushers' warship tonnage to the
+// victims', up to double, and ships that came through a natural wormhole
+// get twice as much.
+func (e *conflict) ambush(aggressors []*battlePlan, c Coords) {
+	amount, ambushers := 0, make(map[int]*battlePlan)
+	for _, plan := range aggressors {
+		for i := 0; i < len(e.t.Game.Ambushes); i++ {
+			if a := e.t.Game.Ambushes[i]; a.Species == plan.species && a.Coords == c {
+				amount, ambushers[plan.species] = amount+a.Amount, plan
+				e.t.Game.Ambushes = append(e.t.Game.Ambushes[:i], e.t.Game.Ambushes[i+1:]...)
+				i--
+			}
+		}
+	}
+	if amount == 0 {
+		return
+	}
+	var victims []*unit
+	friendly, warships, transports := 0, 0, 0
+	for _, u := range e.units {
+		if u.ship == nil || u.out {
+			continue
+		} else if ambushers[u.species.Id] != nil {
+			if u.ship.Kind() != catalog.Transport {
+				friendly += u.ship.Tonnage
+			}
+			continue
+		}
+		for _, plan := range ambushers {
+			if plan.attacks(e.t, u.species.Id) {
+				victims = append(victims, u)
+				if u.ship.Kind() == catalog.Transport {
+					transports += u.ship.Tonnage
+				} else {
+					warships += u.ship.Tonnage
+				}
+				break
+			}
+		}
+	}
+	if warships+transports/10 == 0 {
+		return
+	}
+	aging := 10_000 * amount / (warships + transports/10)
+	if warships > 0 {
+		aging = aging * min(friendly, 2*warships) / warships
+	}
+	e.logf(false, "Ambush")
+	for _, u := range victims {
+		n := aging
+		if u.ship.Wormhole {
+			n *= 2
+		}
+		if u.ship.Age += n; u.ship.Age >= 50 {
+			u.out = true
+			e.logf(false, "%s is destroyed in the ambush", u)
+			e.t.Game.removeShip(u.ship)
+			continue
+		}
+		e.logf(true, "%s is aged %d turns by the ambush", u, n)
+	}
+}
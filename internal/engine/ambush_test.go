@@ -0,0 +1,176 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+// neighbor returns a sector next to c.
+func neighbor(g *engine.Game, c engine.Coords) engine.Coords {
+	if c.X < g.Galaxy.Radius {
+		c.X++
+	} else {
+		c.X--
+	}
+	return c
+}
+
+func TestAmbush(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "FF", Name: "Gorby", Tonnage: 100_000},
+		&engine.Ship{Species: 1, Class: "DD", Name: "Dagger", Tonnage: 150_000},
+		&engine.Ship{Species: 2, Class: "FF", Name: "Raider", Tonnage: 100_000},
+		&engine.Ship{Species: 2, Class: "DD", Name: "Ravager", Tonnage: 150_000},
+		&engine.Ship{Species: 2, Class: "TR7", Name: "Hauler", Tonnage: 70_000},
+	)
+	g.SpeciesById(1).Treasury = 1_000
+	steps := engine.DefaultSteps()
+	strikes := fmt.Sprintf("START STRIKES\nBATTLE %d %d %d\nATTACK SP Klingon\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z)
+
+	// the example from the manual, with the ambushers' warships on par
+	// with the Klingons'
+	runSteps(t, g, []engine.Step{steps[3], steps[5]}, map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nAMBUSH 710\nEND\n" + strikes})
+	if len(g.Battles) != 1 {
+		t.Fatalf("ambush: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	log := strings.Join(g.Battles[0].LogFor(1), "\n")
+	if n := strings.Count(log, "is aged 27 turns by the ambush"); n != 3 {
+		t.Errorf("ambush: expected 3 ships aged 27 turns: got %d: %q\n", n, log)
+	}
+	if len(g.Ambushes) != 0 {
+		t.Errorf("ambush: expected the ambush to be used: got %d\n", len(g.Ambushes))
+	}
+
+	// an ambush that isn't sprung lasts until the next combat phase
+	runStep(t, g, steps[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nAMBUSH 10\nEND\n"})
+	if len(g.Ambushes) != 1 || g.Ambushes[0].Amount != 10 {
+		t.Fatalf("ambush: expected an ambush of 10: got %v\n", g.Ambushes)
+	}
+	runStep(t, g, steps[0], nil)
+	if len(g.Ambushes) != 0 {
+		t.Errorf("ambush: expected the ambush to be wasted: got %d\n", len(g.Ambushes))
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	g, coords := newBattleGame(t)
+	earth := g.ColonyNamed(1, "Earth")
+	next := neighbor(g, coords)
+	exit := g.Galaxy.StarOf(earth.Planet)
+	var entrance *engine.Star
+	for _, star := range g.Galaxy.Stars {
+		if star != exit {
+			entrance = star
+			break
+		}
+	}
+	entrance.Wormhole, exit.Wormhole = exit.Id, entrance.Id
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: 2, Class: "ES", Name: "Escort", Tonnage: 50_000, Coords: next, Status: engine.DeepSpace},
+		{Id: 2, Species: 2, Class: "TR5", Name: "Freighter", Tonnage: 50_000, Coords: next, Status: engine.DeepSpace},
+		{Id: 3, Species: 2, Class: "DD", Name: "Destroyer", Tonnage: 150_000, Coords: next, Status: engine.DeepSpace},
+		{Id: 4, Species: 2, Class: "PB", Name: "Mover", Tonnage: 10_000, Coords: next, Status: engine.DeepSpace},
+		{Id: 5, Species: 2, Class: "BS", Name: "Tunneler", Tonnage: 450_000, Coords: entrance.Coords, Status: engine.DeepSpace},
+	}
+	g.SpeciesById(1).Treasury = 10_000
+	g.SpeciesById(2).Tech[engine.GV] = 200
+	steps := engine.DefaultSteps()
+	jump := fmt.Sprintf("JUMP %%s, %d %d %d\n", coords.X, coords.Y, coords.Z)
+
+	// interception is paid for in production, after the ships arrive, and
+	// ships that moved in or are too big can't be intercepted
+	runSteps(t, g, []engine.Step{steps[1], steps[2], steps[3]}, map[int]string{
+		1: "START PRE-DEPARTURE\nENEMY SP Klingon\nEND\nSTART PRODUCTION\nPRODUCTION PL Earth\nINTERCEPT 450\nINTERCEPT 100\nEND\n",
+		2: "START JUMPS\n" + fmt.Sprintf(jump, "ES Escort") + fmt.Sprintf(jump, "TR5 Freighter") + fmt.Sprintf(jump, "DD Destroyer") +
+			fmt.Sprintf("MOVE PB Mover, %d %d %d\n", coords.X, coords.Y, coords.Z) + "END\n",
+	})
+	for _, name := range []string{"Destroyer", "Mover"} {
+		if ship := g.ShipNamed(2, name); ship == nil || ship.Coords != coords {
+			t.Errorf("intercept: expected %s to arrive: got %+v\n", name, ship)
+		}
+	}
+	destroyed := 0
+	for _, name := range []string{"Escort", "Freighter"} {
+		if g.ShipNamed(2, name) == nil {
+			destroyed++
+		}
+	}
+	var disappeared int
+	for _, e := range g.Log {
+		if e.Species == 2 && strings.Contains(e.Text, "disappeared without a trace") {
+			disappeared++
+		} else if e.Species == 2 && strings.Contains(e.Text, "intercept") {
+			t.Errorf("intercept: expected the Klingons not to know: got %q\n", e.Text)
+		}
+	}
+	if destroyed != disappeared {
+		t.Errorf("intercept: expected %d ships to disappear: got %d\n", destroyed, disappeared)
+	}
+	if spent := g.LedgerFor(earth.Id).Spent; spent != 550 {
+		t.Errorf("intercept: expected to spend 550: got %d\n", spent)
+	}
+
+	// there is no size limit for ships coming through a natural wormhole
+	runSteps(t, g, []engine.Step{steps[2], steps[3]}, map[int]string{
+		1: "START PRODUCTION\nPRODUCTION PL Earth\nINTERCEPT 4500\nEND\n",
+		2: "START JUMPS\nWORMHOLE BS Tunneler\nEND\n",
+	})
+	if ship := g.ShipNamed(2, "Tunneler"); ship != nil {
+		t.Errorf("intercept: wormhole: expected the battleship to be destroyed: got %+v\n", ship)
+	}
+}
+
+func TestIntercept_Distorted(t *testing.T) {
+	g, coords := newBattleGame(t)
+	next := neighbor(g, coords)
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: 2, Class: "ES", Name: "Ghost", Tonnage: 50_000, Coords: next, Status: engine.DeepSpace, Cargo: map[string]int{"FD": 5}},
+		{Id: 2, Species: 2, Class: "ES", Name: "Escort", Tonnage: 50_000, Coords: next, Status: engine.DeepSpace},
+	}
+	g.SpeciesById(1).Treasury = 10_000
+	steps := engine.DefaultSteps()
+	jump := fmt.Sprintf("JUMP %%s, %d %d %d\n", coords.X, coords.Y, coords.Z)
+
+	// the Klingons aren't enemies, but a field-distorted ship always is
+	runSteps(t, g, []engine.Step{steps[2], steps[3]}, map[int]string{
+		1: "START PRODUCTION\nPRODUCTION PL Earth\nINTERCEPT 1000\nEND\n",
+		2: "START JUMPS\n" + fmt.Sprintf(jump, "ES Ghost") + fmt.Sprintf(jump, "ES Escort") + "END\n",
+	})
+	if ship := g.ShipNamed(2, "Ghost"); ship != nil {
+		t.Errorf("intercept: distorted: expected the escort to be destroyed: got %+v\n", ship)
+	}
+	if ship := g.ShipNamed(2, "Escort"); ship == nil || ship.Coords != coords {
+		t.Errorf("intercept: distorted: expected the undistorted escort to arrive: got %+v\n", ship)
+	}
+}
+
+func TestPicketDuty(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "DD", Name: "Picket", Tonnage: 150_000},
+		&engine.Ship{Species: 2, Class: "CT", Name: "Scout", Tonnage: 20_000},
+	)
+	earth := g.ColonyNamed(1, "Earth")
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 99, Species: 2, Planet: otherPlanet(g, earth).Id, Name: "Outpost"})
+	next := neighbor(g, coords)
+	g.ShipNamed(2, "Scout").Coords = next
+	steps := engine.DefaultSteps()
+
+	// ships that move in can't hide in orbit, and are surprised by pickets
+	runSteps(t, g, []engine.Step{steps[2], steps[4], steps[5]}, map[int]string{
+		1: fmt.Sprintf("START STRIKES\nBATTLE %d %d %d\nATTACK SP Klingon\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z),
+		2: fmt.Sprintf("START JUMPS\nMOVE CT Scout, %d %d %d\nEND\nSTART POST-ARRIVAL\nORBIT CT Scout, PL Outpost\nEND\n", coords.X, coords.Y, coords.Z),
+	})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "moved into the sector this turn") {
+		t.Errorf("picket: log: expected the orbit to be refused: got %q\n", errs)
+	}
+	if len(g.Battles) != 1 {
+		t.Fatalf("picket: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	if log := strings.Join(g.Battles[0].LogFor(1), "\n"); !strings.Contains(log, "Surprise attack") {
+		t.Errorf("picket: expected a surprise attack: got %q\n", log)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Gas is one of the atmospheric gases used in the game.
+type Gas int
+
+const (
+	H2 Gas = iota + 1
+	CH4
+	He
+	NH3
+	N2
+	CO2
+	O2
+	HCl
+	Cl2
+	F2
+	H2O
+	SO2
+	H2S
+)
+
+// Gases is the list of all gases, in the order they are listed in the manual.
+var Gases = []Gas{H2, CH4, He, NH3, N2, CO2, O2, HCl, Cl2, F2, H2O, SO2, H2S}
+
+var gasSymbols = []string{"", "H2", "CH4", "He", "NH3", "N2", "CO2", "O2", "HCl", "Cl2", "F2", "H2O", "SO2", "H2S"}
+
+var gasNames = []string{"", "Hydrogen", "Methane", "Helium", "Ammonia", "Nitrogen", "Carbon Dioxide", "Oxygen", "Hydrogen Chloride", "Chlorine", "Fluorine", "Water Vapor", "Sulfur Dioxide", "Hydrogen Sulfide"}
+
+// Name returns the full name of the gas.
+func (g Gas) Name() string {
+	if g < H2 || g > H2S {
+		return "?"
+	}
+	return gasNames[g]
+}
+
+// String implements the Stringer interface by returning the gas's symbol.
+func (g Gas) String() string {
+	if g < H2 || g > H2S {
+		return "?"
+	}
+	return gasSymbols[g]
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The zero value, meaning no gas, is an empty string.
+func (g Gas) MarshalText() ([]byte, error) {
+	if g == 0 {
+		return []byte{}, nil
+	} else if g < H2 || g > H2S {
+		return nil, fmt.Errorf("gas: invalid value %d", int(g))
+	}
+	return []byte(gasSymbols[g]), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (g *Gas) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*g = 0
+		return nil
+	}
+	gas, ok := ParseGas(string(text))
+	if !ok {
+		return fmt.Errorf("gas: invalid symbol %q", string(text))
+	}
+	*g = gas
+	return nil
+}
+
+// ParseGas returns the gas for a symbol.
+// Case is not significant, and "Fl2" is accepted for Fluorine.
+func ParseGas(symbol string) (Gas, bool) {
+	if strings.EqualFold(symbol, "Fl2") {
+		return F2, true
+	}
+	for _, gas := range Gases {
+		if strings.EqualFold(symbol, gasSymbols[gas]) {
+			return gas, true
+		}
+	}
+	return 0, false
+}
+
+// Constituent is a gas and its percentage of the atmosphere.
+type Constituent struct {
+	Gas     Gas `json:"gas"`
+	Percent int `json:"pct"`
+}
+
+// Atmosphere is the list of gases that are the major components of a
+// planet's atmosphere. The percentages add up to 100.
+type Atmosphere []Constituent
+
+// Percent returns the percentage of the gas in the atmosphere.
+func (a Atmosphere) Percent(g Gas) int {
+	for _, c := range a {
+		if c.Gas == g {
+			return c.Percent
+		}
+	}
+	return 0
+}
+
+// String implements the Stringer interface.
+// The format is the one used in scans, e.g. "N2(47%),CO2(23%),O2(30%)".
+func (a Atmosphere) String() string {
+	var parts []string
+	for _, c := range a {
+		parts = append(parts, fmt.Sprintf("%s(%d%%)", c.Gas, c.Percent))
+	}
+	return strings.Join(parts, ",")
+}
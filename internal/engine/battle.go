@@ -0,0 +1,857 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"math"
+	"sort"
+)
+
+const (
+	// maxRounds is the most rounds that a battle can last at one place.
+	maxRounds = 20
+
+	// pdTonnage is the tonnage of the FTL warship that a planetary defense
+	// unit is worth in combat.
+	pdTonnage = 50
+
+	// pdSiegeTonnage is the tonnage that a planetary defense unit is worth
+	// when it helps to besiege a planet.
+	pdSiegeTonnage = 2000
+)
+
+// power returns the combat power of a unit of the given tonnage.
+// Power grows faster than tonnage, so a single large ship is much
+// stronger than several small ships of the same total tonnage.
+func power(tonnage int) float64 {
+	n := float64(tonnage) / 10_000
+	return n * math.Sqrt(n)
+}
+
+// unit is a ship, a starbase, or a colony's planetary defenses in a battle.
+type unit struct {
+	species *Species
+	plan    *battlePlan
+	ship    *Ship   // nil for planetary defenses
+	colony  *Colony // owner of the planetary defenses
+	planet  int     // number of the planet the unit is at, 0 for deep space
+	offense float64
+	defense float64 // full strength of the shields, which is also how much damage the unit can take
+	shield  float64
+	out     bool // destroyed or withdrew
+	hidden  bool
+	fought  bool // took part in at least one round
+	hijack  bool // fighting at reduced strength to capture ships
+	alias   int  // species number shown while the ship is field distorted, 0 if it isn't
+}
+
+// present reports whether the unit can take part in bombardment, germ
+// warfare, and sieges. A hidden ship only does if it was drawn into the fight.
+func (u *unit) present() bool {
+	return !u.out && (!u.hidden || u.fought)
+}
+
+// measure sets the offensive and defensive strength of the unit.
+// Military tech makes weapons more effective and life support tech makes
+// shields stronger. Auxiliary guns and shields only work on warships.
+// Ships trying to hijack have a quarter of their normal strength.
+func (u *unit) measure() {
+	var tonnage int
+	var guns, shields float64
+	if u.ship == nil {
+		tonnage = u.colony.Inventory["PD"] * pdTonnage
+	} else {
+		kind := u.ship.Kind()
+		tonnage = catalog.CombatTonnage(kind, u.ship.Tonnage)
+		for _, item := range catalog.Items {
+			n := u.ship.Cargo[item.Code]
+			if n == 0 || item.Tonnage == 0 || kind != catalog.Warship {
+				continue
+			} else if item.Tech == "ML" {
+				guns += float64(n) * power(item.Tonnage)
+			} else {
+				shields += float64(n) * power(item.Tonnage)
+			}
+		}
+	}
+	u.offense = (power(tonnage) + guns) * (1 + float64(u.species.Tech[ML])/50)
+	u.defense = (power(tonnage) + shields) * (1 + float64(u.species.Tech[LS])/50)
+	if u.hijack {
+		u.offense, u.defense = u.offense/4, u.defense/4
+	}
+}
+
+// Firepower returns the offensive and defensive strength of a ship in
+// combat, including any auxiliary gun units and shield generators it uses.
+func (g *Game) Firepower(ship *Ship) (offense, defense float64) {
+	u := &unit{species: g.SpeciesById(ship.Species), ship: ship}
+	u.measure()
+	return u.offense, u.defense
+}
+
+// kind returns the unit's target type.
+func (u *unit) kind() int {
+	if u.ship == nil {
+		return TargetPlanetaryDU
+	}
+	switch u.ship.Kind() {
+	case catalog.Transport:
+		return TargetTransports
+	case catalog.Starbase:
+		return TargetStarbases
+	}
+	return TargetWarships
+}
+
+// String implements the Stringer interface.
+func (u *unit) String() string {
+	if u.ship == nil {
+		return fmt.Sprintf("%s planetary defenses on planet %d", u.species, u.planet)
+	} else if u.alias != 0 {
+		return fmt.Sprintf("SP %d %s ???", u.alias, u.ship.Class)
+	}
+	return fmt.Sprintf("%s %s", u.species, u.ship)
+}
+
+// conflict is the state of a battle at one location.
+type conflict struct {
+	t       *Turn
+	star    *Star
+	battle  *Battle
+	plans   map[int]*battlePlan
+	hostile map[[2]int]bool
+	units   []*unit
+}
+
+// fight resolves the battle at a location.
+//
+// A battle only happens if a species has given orders to attack another
+// species that is present. Species that are attacked fight back. The
+// fighting starts in deep space if the attackers want it there or if the
+// defenders want to keep it away from their planets, and then moves to
+// each planet that is attacked. Planets whose defenders are all gone can
+// then be bombarded, attacked with germ warfare, or besieged.
+func fight(t *Turn, c Coords) {
+	e := &conflict{t: t, star: t.Game.Galaxy.StarAt(c), plans: make(map[int]*battlePlan), hostile: make(map[[2]int]bool)}
+	for _, plan := range t.battlePlans {
+		if plan.coords == c {
+			e.plans[plan.species] = plan
+		}
+	}
+	e.collectUnits(c)
+
+	var aggressors []*battlePlan
+	for _, id := range e.speciesPresent() {
+		if plan := e.planFor(id); plan.isAggressor() {
+			aggressors = append(aggressors, plan)
+			for _, other := range e.speciesPresent() {
+				if plan.attacks(t, other) {
+					e.hostile[[2]int{plan.species, other}] = true
+					e.hostile[[2]int{other, plan.species}] = true
+				}
+			}
+		}
+	}
+	// species that aren't attacking anyone fight on the side of any ally
+	// that is attacked, as if they and the attackers had ordered attacks
+	// on each other.
+	for _, plan := range aggressors {
+		for _, victim := range e.speciesPresent() {
+			if !plan.attacks(t, victim) {
+				continue
+			}
+			for _, id := range e.speciesPresent() {
+				if id != plan.species && id != victim && !e.planFor(id).isAggressor() && t.Game.declaredAlly(id, victim) && !t.Game.declaredAlly(id, plan.species) {
+					e.hostile[[2]int{id, plan.species}] = true
+					e.hostile[[2]int{plan.species, id}] = true
+				}
+			}
+		}
+	}
+	if !e.hostilities() {
+		return
+	}
+
+	e.battle = &Battle{Coords: c, Phase: t.phase}
+	for _, id := range e.speciesPresent() {
+		for _, other := range e.speciesPresent() {
+			if e.hostile[[2]int{id, other}] {
+				e.battle.Species = append(e.battle.Species, id)
+				if e.planFor(id).summary {
+					e.battle.Summary = append(e.battle.Summary, id)
+				}
+				break
+			}
+		}
+	}
+	t.Game.Battles = append(t.Game.Battles, e.battle)
+	for _, plan := range aggressors {
+		for _, other := range e.speciesPresent() {
+			if plan.attacks(t, other) {
+				e.logf(false, "%s attacks %s", e.name(plan.species), e.name(other))
+				if t.Game.declaredAlly(other, plan.species) {
+					t.betrayals = append(t.betrayals, [2]int{plan.species, other})
+				}
+			}
+		}
+	}
+	e.ambush(aggressors, c)
+	e.surprise(aggressors)
+
+	// defenders that want to keep the fight away from their planets hold
+	// the attackers in deep space for a round, or for one round for each
+	// level of military tech they have over the attackers.
+	deepRounds := 0
+	var planets []int
+	for _, plan := range aggressors {
+		sp := t.Game.SpeciesById(plan.species)
+		for _, eng := range plan.engage {
+			if eng.option == DeepSpaceFight {
+				deepRounds = maxRounds
+			} else if eng.option >= PlanetAttack {
+				planets = appendUnique(planets, eng.planet)
+				for _, other := range e.speciesPresent() {
+					if e.hostile[[2]int{plan.species, other}] && e.planFor(other).has(DeepSpaceDefend, DeepSpaceFight) {
+						deepRounds = max(deepRounds, 1, t.Game.SpeciesById(other).Tech[ML]-sp.Tech[ML])
+					}
+				}
+			}
+		}
+	}
+	if deepRounds > 0 {
+		e.logf(false, "Fighting in deep space")
+		e.fightAt(0, deepRounds)
+	}
+	for _, planet := range planets {
+		e.logf(false, "Fighting at planet %d", planet)
+		e.fightAt(planet, maxRounds)
+	}
+
+	// hijackers are back at full strength for what follows the fighting
+	for _, u := range e.units {
+		if u.hijack {
+			u.hijack = false
+			u.measure()
+		}
+	}
+	besieged := make(map[int][]*Colony)
+	for _, plan := range aggressors {
+		for _, eng := range plan.engage {
+			if eng.option < Bombardment || !e.defeated(plan.species, eng.planet) {
+				continue
+			}
+			for _, colony := range e.coloniesOn(eng.planet) {
+				if colony.Species == plan.species || !e.hostile[[2]int{plan.species, colony.Species}] {
+					continue
+				}
+				switch eng.option {
+				case Bombardment:
+					e.bombard(plan.species, eng.planet, colony)
+				case GermWarfare:
+					e.germWarfare(plan.species, eng.planet, colony)
+				case Besiege:
+					besieged[plan.species] = append(besieged[plan.species], colony)
+				}
+			}
+		}
+	}
+	for _, plan := range aggressors {
+		e.besiege(plan.species, besieged[plan.species])
+	}
+}
+
+// collectUnits finds the ships, starbases and planetary defenses at the location.
+// Ships under construction and ships that already withdrew don't fight.
+func (e *conflict) collectUnits(c Coords) {
+	planets := make(map[int]int) // planet id to planet number
+	if e.star != nil {
+		for _, planet := range e.star.Planets {
+			planets[planet.Id] = planet.Orbit
+		}
+	}
+	for _, ship := range e.t.Game.Ships {
+		if ship.Coords != c || ship.IsUnderConstruction() || ship.Withdrawn || ship.Forced != "" {
+			continue
+		}
+		u := &unit{species: e.t.Game.SpeciesById(ship.Species), ship: ship, planet: planets[ship.Planet]}
+		u.plan = e.planFor(ship.Species)
+		u.hidden = u.plan.hidden[ship.Id]
+		u.hijack = u.plan.isHijacking()
+		e.units = append(e.units, u)
+	}
+	for _, colony := range e.t.Game.Colonies {
+		if orbit, ok := planets[colony.Planet]; ok && colony.Inventory["PD"] > 0 {
+			u := &unit{species: e.t.Game.SpeciesById(colony.Species), colony: colony, planet: orbit}
+			u.plan = e.planFor(colony.Species)
+			e.units = append(e.units, u)
+		}
+	}
+	for _, u := range e.units {
+		u.measure()
+		u.shield = u.defense
+	}
+	e.distort()
+}
+
+// distort hides the identity of species whose ships are all field
+// distorted. The units only work if every ship and starbase of the species
+// at the battle uses them and the species has no colonies there, since
+// ships and planets working together would give the species away.
+func (e *conflict) distort() {
+	for _, id := range e.speciesPresent() {
+		distorted := false
+		for _, u := range e.units {
+			if u.species.Id != id {
+				continue
+			} else if u.ship == nil || !u.ship.IsDistorted() {
+				distorted = false
+				break
+			}
+			distorted = true
+		}
+		for planet := 1; distorted && e.star != nil && planet <= len(e.star.Planets); planet++ {
+			for _, colony := range e.coloniesOn(planet) {
+				if colony.Species == id && colony.Population() > 0 {
+					distorted = false
+				}
+			}
+		}
+		if !distorted {
+			continue
+		}
+		alias := e.t.Game.Pseudonym(e.t.Game.SpeciesById(id))
+		for _, u := range e.units {
+			if u.species.Id == id {
+				u.alias = alias
+			}
+		}
+	}
+}
+
+// name returns the name of a species as the others at the battle see it.
+func (e *conflict) name(species int) string {
+	for _, u := range e.units {
+		if u.species.Id == species && u.alias != 0 {
+			return fmt.Sprintf("SP %d", u.alias)
+		}
+	}
+	return e.t.Game.SpeciesById(species).String()
+}
+
+// planFor returns the species' plan, creating the default plan for
+// species that didn't give orders for the battle.
+func (e *conflict) planFor(species int) *battlePlan {
+	if e.plans[species] == nil {
+		e.plans[species] = &battlePlan{species: species, withdraw: defaultWithdraw}
+	}
+	return e.plans[species]
+}
+
+// speciesPresent returns the ids of the species with units or colonies
+// at the battle, in order.
+func (e *conflict) speciesPresent() []int {
+	var ids []int
+	for _, u := range e.units {
+		ids = appendUnique(ids, u.species.Id)
+	}
+	for planet := 1; e.star != nil && planet <= len(e.star.Planets); planet++ {
+		for _, colony := range e.coloniesOn(planet) {
+			ids = appendUnique(ids, colony.Species)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// hostilities returns true if a species with a warship, starbase or
+// planetary defenses is hostile to anyone at the battle.
+func (e *conflict) hostilities() bool {
+	for _, u := range e.units {
+		if u.kind() == TargetTransports {
+			continue
+		}
+		for _, id := range e.speciesPresent() {
+			if e.hostile[[2]int{u.species.Id, id}] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// engaged returns true if any two of the units are hostile and at least
+// one of them is not a transport. Transports alone can't have a battle.
+func (e *conflict) engaged(units []*unit) bool {
+	for _, a := range units {
+		for _, b := range units {
+			if !a.out && !b.out && e.hostile[[2]int{a.species.Id, b.species.Id}] && (a.kind() != TargetTransports || b.kind() != TargetTransports) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inFront returns true if the unit takes part in the fighting at the planet,
+// or in deep space if the planet is 0.
+func (e *conflict) inFront(u *unit, planet int) bool {
+	if u.ship == nil || u.ship.IsStarbase() {
+		return u.planet == planet
+	} else if u.plan.isAggressor() {
+		if planet == 0 {
+			return true
+		}
+		for _, eng := range u.plan.engage {
+			if eng.option >= PlanetAttack && eng.planet == planet {
+				return true
+			}
+		}
+		return false
+	} else if planet == 0 {
+		return u.planet == 0 || u.plan.has(DeepSpaceDefend, DeepSpaceFight)
+	}
+	for _, eng := range u.plan.engage {
+		if eng.option == PlanetDefense && eng.planet == planet {
+			return true
+		}
+	}
+	return u.planet == planet
+}
+
+// fightAt fights rounds at the planet, or in deep space if the planet is 0,
+// until one side is gone or the rounds run out.
+func (e *conflict) fightAt(planet, rounds int) {
+	for round := 1; round <= rounds; round++ {
+		var active []*unit
+		for _, u := range e.units {
+			if !u.out && e.inFront(u, planet) && (!u.hidden || e.losses(u.species.Id) > 0) {
+				active = append(active, u)
+			}
+		}
+		if !e.engaged(active) {
+			return
+		}
+		for _, u := range active {
+			u.fought = true
+		}
+		e.logf(true, "Round %d", round)
+
+		// everyone fires once a round, in random order
+		order := append([]*unit{}, active...)
+		for i := len(order) - 1; i > 0; i-- {
+			j := e.t.RNG.Intn(i + 1)
+			order[i], order[j] = order[j], order[i]
+		}
+		for _, u := range order {
+			if u.out {
+				continue
+			} else if target := e.pickTarget(u, active); target != nil {
+				e.shoot(u, target)
+			}
+		}
+		for _, u := range order {
+			if !u.out && u.ship != nil && u.ship.IsStarbase() {
+				e.forceJump(u, active, "FM")
+				e.forceJump(u, active, "FJ")
+			}
+		}
+		e.withdrawals()
+	}
+}
+
+// forceJump tries to use a starbase's forced jump or forced mis-jump units
+// on the largest hostile ship that they can move, which is 10,000 tons for
+// each unit carried. Getting a lock on the target is a coin toss each round.
+// The chance of success is 2% for each level of gravitics tech the
+// starbase's owner has over the target's owner, plus 2% for each unit
+// more than the target needs. Starbases can't be forced to jump.
+func (e *conflict) forceJump(u *unit, active []*unit, code string) {
+	units := u.ship.Cargo[code]
+	if units == 0 {
+		return
+	}
+	var target *unit
+	for _, v := range active {
+		if v.out || v.ship == nil || v.ship.IsStarbase() || !e.hostile[[2]int{u.species.Id, v.species.Id}] || v.ship.Tonnage > units*10_000 {
+			continue
+		} else if target == nil || v.ship.Tonnage > target.ship.Tonnage {
+			target = v
+		}
+	}
+	if target == nil || !e.t.RNG.Percent(50) {
+		return
+	}
+	needed := (target.ship.Tonnage + 9_999) / 10_000
+	chance := 2*(u.species.Tech[GV]-target.species.Tech[GV]) + 2*(units-needed)
+	if chance <= 0 || !e.t.RNG.Percent(min(chance, 100)) {
+		e.logf(true, "%s fails to force %s to jump", u, target)
+		return
+	}
+	target.out, target.ship.Forced = true, code
+	if code == "FM" {
+		e.logf(false, "%s forces %s to mis-jump", u, target)
+	} else {
+		e.logf(false, "%s forces %s to jump", u, target)
+	}
+}
+
+// surprise gives attackers a free round against species that aren't on
+// alert, which they would be if they had given a BATTLE order for the
+// location. Species that declared the attackers an ally are surprised, and
+// so are ships that arrived this turn and run into pickets in the strike
+// phase. The victims' shields are down for the surprise round.
+func (e *conflict) surprise(aggressors []*battlePlan) {
+	var attackers, victims []*unit
+	for _, plan := range aggressors {
+		for _, other := range e.speciesPresent() {
+			if !plan.attacks(e.t, other) || e.planFor(other).alert {
+				continue
+			}
+			betrayed, surprised := e.t.Game.declaredAlly(other, plan.species), false
+			for _, u := range e.units {
+				if u.species.Id == other && (betrayed || e.arrived(u)) {
+					victims, surprised = appendUnique(victims, u), true
+				}
+			}
+			for _, u := range e.units {
+				if surprised && u.species.Id == plan.species && !u.hidden {
+					attackers = appendUnique(attackers, u)
+				}
+			}
+		}
+	}
+	if len(attackers) == 0 || len(victims) == 0 {
+		return
+	}
+	e.logf(false, "Surprise attack")
+	for _, u := range victims {
+		u.shield = 0
+	}
+	for _, u := range attackers {
+		if target := e.pickTarget(u, victims); target != nil {
+			e.shoot(u, target)
+		}
+	}
+	for _, u := range victims {
+		u.shield = u.defense
+	}
+	e.withdrawals()
+}
+
+// arrived returns true if the unit is a ship that jumped or moved into the
+// sector this turn and is fighting in the strike phase.
+func (e *conflict) arrived(u *unit) bool {
+	return e.t.phase == "strikes" && u.ship != nil && e.t.moved[u.ship.Id]
+}
+
+// pickTarget returns the unit that u fires on. Fire is concentrated on the
+// most powerful enemy, preferring the type named in a TARGET order.
+func (e *conflict) pickTarget(u *unit, active []*unit) *unit {
+	var candidates []*unit
+	preferred := false
+	for _, v := range active {
+		if v.out || v == u || !e.hostile[[2]int{u.species.Id, v.species.Id}] {
+			continue
+		}
+		if isPreferred := v.kind() == u.plan.target; isPreferred && !preferred {
+			candidates, preferred = nil, true
+		} else if preferred && !isPreferred {
+			continue
+		}
+		candidates = append(candidates, v)
+	}
+	var target *unit
+	for _, v := range candidates {
+		if target == nil || v.offense > target.offense {
+			target = v
+		}
+	}
+	return target
+}
+
+// shoot resolves a single shot.
+//
+// The chance to hit starts at 50% and moves 2% for each level of difference
+// in military tech. Like every other chance, it drops by 2% of itself for
+// each turn of the shooter's age. Damage is absorbed by the target's
+// shields first. Damage that gets through ages a ship, destroying it at age
+// 50, and destroys planetary defense units in proportion.
+func (e *conflict) shoot(u, v *unit) {
+	chance := clamp(5000+200*(u.species.Tech[ML]-v.species.Tech[ML]), 200, 9800)
+	if u.ship != nil {
+		chance -= 2 * u.ship.Age * chance / 100
+	}
+	if v.alias != 0 {
+		chance -= chance / 4
+	}
+	if e.t.RNG.Intn(10_000) >= chance {
+		e.logf(true, "%s fires on %s and misses", u, v)
+		return
+	}
+	absorbed := math.Min(v.shield, u.offense)
+	v.shield -= absorbed
+	damage := u.offense - absorbed
+	if damage <= 0 {
+		e.logf(true, "%s hits %s, but the shields hold", u, v)
+		return
+	}
+
+	// damage that gets through the shields stops the field distortion
+	// units and reveals the ship's real name and owner
+	if v.alias != 0 {
+		name := v.String()
+		v.alias = 0
+		e.logf(false, "%s is revealed to be %s", name, v)
+	}
+	if v.ship != nil {
+		aging := int(math.Ceil(50 * damage / v.defense))
+		if v.ship.Age+aging >= 50 && u.plan.hijacks(e.t, v.species.Id) {
+			v.out = true
+			e.hijack(u, v)
+			return
+		}
+		v.ship.Age += aging
+		if v.ship.Age >= 50 {
+			v.out = true
+			e.logf(false, "%s is destroyed by %s", v, u)
+			e.t.Game.removeShip(v.ship)
+			return
+		}
+		e.logf(true, "%s hits %s for %d turns of damage", u, v, aging)
+		// cargo can be lost when damage gets through the shields,
+		// and that is never reported.
+		pct := min(100, 2*aging)
+		for _, item := range catalog.Items {
+			if n := v.ship.Cargo[item.Code]; n > 0 {
+				addItems(&v.ship.Cargo, item.Code, -n*pct/100)
+			}
+		}
+		return
+	}
+
+	n := v.colony.Inventory["PD"]
+	lost := min(n, int(math.Ceil(float64(n)*damage/v.defense)))
+	addItems(&v.colony.Inventory, "PD", -lost)
+	if lost == n {
+		v.out = true
+		e.logf(false, "%s are destroyed by %s", v, u)
+		return
+	}
+	e.logf(true, "%s hits %s, destroying %d units", u, v, lost)
+	v.measure()
+}
+
+// hijack captures a ship that would have been destroyed. The ship and its
+// cargo are sold for their recycle value, at the age the ship was before
+// the last hit, and the economic units go to the hijackers' treasury at
+// the end of the turn.
+func (e *conflict) hijack(u, v *unit) {
+	value := v.ship.recycleValue() + cargoValue(v.species, v.ship.Cargo)
+	e.logf(false, "%s is hijacked by %s", v, u)
+	e.t.Game.Transactions = append(e.t.Game.Transactions, &Transaction{Kind: "HIJACK", From: v.species.Id, To: u.species.Id, Amount: value})
+	e.t.Game.removeShip(v.ship)
+}
+
+// withdrawals checks each species' conditions for leaving the battle.
+// Starbases and sub-light ships can't jump, so they can't withdraw.
+// Ships that withdraw jump away during the next jump phase.
+func (e *conflict) withdrawals() {
+	for _, id := range e.battle.Species {
+		plan := e.planFor(id)
+		total, gone := 0, 0
+		for _, u := range e.units {
+			if u.ship != nil && u.species.Id == id {
+				total++
+				if u.out {
+					gone++
+				}
+			}
+		}
+		fleet := plan.withdraw[2] == 0 || (total > 0 && 100*gone/total >= plan.withdraw[2])
+		for _, u := range e.units {
+			if u.out || u.ship == nil || u.species.Id != id || u.ship.IsStarbase() || u.ship.SubLight {
+				continue
+			}
+			leave := fleet
+			if u.kind() == TargetTransports {
+				leave = leave || (plan.withdraw[0] > 0 && u.ship.Age > plan.withdraw[0])
+			} else {
+				leave = leave || u.ship.Age > plan.withdraw[1]
+			}
+			if leave {
+				u.out, u.ship.Withdrawn, u.ship.Haven = true, true, plan.haven
+				e.logf(false, "%s withdraws", u)
+			}
+		}
+	}
+}
+
+// losses returns the number of the species' units that are out of the battle.
+func (e *conflict) losses(species int) int {
+	n := 0
+	for _, u := range e.units {
+		if u.out && u.species.Id == species {
+			n++
+		}
+	}
+	return n
+}
+
+// defeated returns true if nothing hostile to the species is left at the
+// planet and the species still has a warship or starbase there.
+func (e *conflict) defeated(species, planet int) bool {
+	support := false
+	for _, u := range e.units {
+		if u.out || !e.inFront(u, planet) {
+			continue
+		} else if e.hostile[[2]int{species, u.species.Id}] {
+			return false
+		} else if u.species.Id == species && u.ship != nil && u.kind() != TargetTransports {
+			support = true
+		}
+	}
+	return support
+}
+
+// coloniesOn returns the colonies on the planet with the given number.
+func (e *conflict) coloniesOn(planet int) []*Colony {
+	var colonies []*Colony
+	if e.star == nil || planet < 1 || planet > len(e.star.Planets) {
+		return nil
+	}
+	for _, colony := range e.t.Game.Colonies {
+		if colony.Planet == e.star.Planets[planet-1].Id {
+			colonies = append(colonies, colony)
+		}
+	}
+	return colonies
+}
+
+// bombard destroys a share of a colony's population and infrastructure
+// that depends on the attackers' firepower and the size of the colony.
+func (e *conflict) bombard(species, planet int, colony *Colony) {
+	var firepower float64
+	for _, u := range e.units {
+		if u.present() && u.species.Id == species && u.ship != nil && e.inFront(u, planet) {
+			firepower += u.offense
+		}
+	}
+	pct := 100
+	if bases := colony.MiningBase + colony.ManufacturingBase; bases > 0 {
+		pct = min(100, int(100*firepower/float64(bases)))
+	}
+	colony.MiningBase -= colony.MiningBase * pct / 100
+	colony.ManufacturingBase -= colony.ManufacturingBase * pct / 100
+	colony.AvailablePopulation -= colony.AvailablePopulation * pct / 100
+	for _, item := range catalog.Items {
+		if n := colony.Inventory[item.Code]; n > 0 {
+			addItems(&colony.Inventory, item.Code, -n*pct/100)
+		}
+	}
+	e.logf(false, "%s bombards %s on planet %d: %d%% destroyed", e.t.Game.SpeciesById(species), e.t.Game.SpeciesById(colony.Species), planet, pct)
+}
+
+// germWarfare uses all of the attackers' germ warfare bombs at the planet.
+// Each bomb has a 50% chance of wiping out the colony, moved 2% for each
+// level of difference in biology tech. The attackers loot the colony.
+func (e *conflict) germWarfare(species, planet int, colony *Colony) {
+	attacker, defender := e.t.Game.SpeciesById(species), e.t.Game.SpeciesById(colony.Species)
+	bombs := 0
+	for _, u := range e.units {
+		if u.present() && u.species.Id == species && u.ship != nil && e.inFront(u, planet) {
+			bombs += u.ship.Cargo["GW"]
+			addItems(&u.ship.Cargo, "GW", -u.ship.Cargo["GW"])
+		}
+	}
+	if bombs == 0 {
+		e.logf(false, "%s has no germ warfare bombs at planet %d", attacker, planet)
+		return
+	}
+	chance := clamp(50+2*(attacker.Tech[BI]-defender.Tech[BI]), 0, 100)
+	for i := 0; i < bombs; i++ {
+		if e.t.RNG.Percent(chance) {
+			loot := (defender.Tech[MI]*colony.MiningBase + defender.Tech[MA]*colony.ManufacturingBase) / 10
+			colony.MiningBase, colony.ManufacturingBase, colony.AvailablePopulation, colony.Inventory = 0, 0, 0, nil
+			e.t.Game.Transactions = append(e.t.Game.Transactions, &Transaction{Kind: "LOOT", From: defender.Id, To: attacker.Id, Amount: loot})
+			e.logf(false, "%s wipes out %s on planet %d with germ warfare", attacker, defender, planet)
+			return
+		}
+	}
+	e.logf(false, "%s's germ warfare against %s on planet %d fails", attacker, defender, planet)
+}
+
+// besiege records the sieges of a species' target colonies.
+// Effectiveness depends on the besiegers' tonnage and military tech and on
+// the colony's economy and military tech. Starbases count a quarter of
+// their tonnage, transports don't count, and the besiegers' planetary
+// defenses on the same planet count 2000 tons a unit. A ship besieging
+// several planets has its effectiveness divided among them. A siege needs
+// a warship or starbase, and field distorted ships can't besiege at all:
+// trying to gives away who they are.
+func (e *conflict) besiege(species int, colonies []*Colony) {
+	if len(colonies) == 0 {
+		return
+	}
+	attacker := e.t.Game.SpeciesById(species)
+	for _, u := range e.units {
+		if u.species.Id == species && u.alias != 0 {
+			e.logf(false, "SP %d can't besiege with field distorted ships and is revealed to be %s", u.alias, attacker)
+			for _, v := range e.units {
+				if v.species.Id == species {
+					v.alias = 0
+				}
+			}
+			return
+		}
+	}
+	var planets []int
+	for _, colony := range colonies {
+		planets = appendUnique(planets, colony.Planet)
+	}
+	for _, colony := range colonies {
+		defender := e.t.Game.SpeciesById(colony.Species)
+		planet := e.t.Game.Galaxy.Planet(colony.Planet).Orbit
+		tonnage, ships := 0, false
+		for _, u := range e.units {
+			if !u.present() || u.species.Id != species || !e.inFront(u, planet) {
+				continue
+			} else if u.ship == nil {
+				tonnage += u.colony.Inventory["PD"] * pdSiegeTonnage
+			} else if u.kind() == TargetWarships {
+				tonnage, ships = tonnage+u.ship.Tonnage, true
+			} else if u.kind() == TargetStarbases {
+				tonnage, ships = tonnage+u.ship.Tonnage/4, true
+			}
+		}
+		if !ships {
+			e.logf(false, "%s can't besiege %s on planet %d without warships or starbases", attacker, defender, planet)
+			continue
+		}
+		effectiveness := maxSiegeEffectiveness
+		// the bases are in tenths, so the tonnage is scaled to match
+		if base := defender.Tech[MI]*colony.MiningBase + defender.Tech[MA]*colony.ManufacturingBase; base > 0 {
+			effectiveness = min(maxSiegeEffectiveness, 10*tonnage*attacker.Tech[ML]/(base*(defender.Tech[ML]+1)))
+		}
+		effectiveness /= len(planets)
+		occupation := e.t.Game.colonyOn(species, colony.Planet) != nil
+		e.t.Game.Sieges = append(e.t.Game.Sieges, &Siege{Colony: colony.Id, Besieger: species, Effectiveness: effectiveness, Occupation: occupation})
+		e.logf(false, "%s besieges %s on planet %d: %d%% effective", attacker, defender, planet, effectiveness)
+	}
+}
+
+// logf adds a line to the combat log.
+func (e *conflict) logf(detail bool, format string, args ...any) {
+	e.battle.Lines = append(e.battle.Lines, &BattleLine{Text: fmt.Sprintf(format, args...), Detail: detail})
+}
+
+// appendUnique appends n to the list if it isn't already there.
+func appendUnique[T comparable](list []T, n T) []T {
+	for _, v := range list {
+		if v == n {
+			return list
+		}
+	}
+	return append(list, n)
+}
@@ -0,0 +1,372 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// installation is colonial units installed during the pre-departure phase.
+// Installation takes the whole turn, so the bases don't grow until
+// housekeeping.
+type installation struct {
+	colony        *Colony
+	mining        int // tenths added to the mining base
+	manufacturing int // tenths added to the manufacturing base
+}
+
+// name names a planet in a star system that the species has visited.
+// Naming a planet creates an empty colony that can receive colonists.
+func name(t *Turn, sp *Species, cmd *orders.Command) error {
+	c := Coords{X: cmd.Args[0].Number, Y: cmd.Args[1].Number, Z: cmd.Args[2].Number}
+	star := t.Game.Galaxy.StarAt(c)
+	if star == nil {
+		return fmt.Errorf("%s: %w", c, ErrNoSuchStar)
+	} else if orbit := cmd.Args[3].Number; orbit < 1 || orbit > len(star.Planets) {
+		return fmt.Errorf("%s %d: %w", c, orbit, ErrNoSuchPlanet)
+	} else if !sp.HasVisited(star.Id) {
+		return fmt.Errorf("%s: %w", c, ErrNotVisited)
+	}
+	planet := star.Planets[cmd.Args[3].Number-1]
+	if err := ValidateName(cmd.Args[4].Name); err != nil {
+		return err
+	} else if t.Game.ColonyNamed(sp.Id, cmd.Args[4].Name) != nil {
+		return fmt.Errorf("PL %s: %w", cmd.Args[4].Name, ErrDuplicateName)
+	} else if colony := t.Game.colonyOn(sp.Id, planet.Id); colony != nil {
+		return fmt.Errorf("PL %s: %w", colony.Name, ErrAlreadyNamed)
+	}
+	t.Game.Colonies = append(t.Game.Colonies, &Colony{
+		Id:      t.Game.nextColonyId(),
+		Species: sp.Id,
+		Planet:  planet.Id,
+		Name:    cmd.Args[4].Name,
+	})
+	return nil
+}
+
+// hold is a ship or colony that items can be transferred to or from.
+type hold struct {
+	ship   *Ship
+	colony *Colony
+}
+
+// holdArg returns the species' ship or colony for an argument.
+func (t *Turn) holdArg(sp *Species, arg orders.Arg) (hold, error) {
+	if arg.Kind == orders.Ship {
+		ship, err := t.shipArg(sp, arg)
+		if err != nil {
+			return hold{}, err
+		} else if ship.IsUnderConstruction() {
+			return hold{}, fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+		}
+		return hold{ship: ship}, nil
+	}
+	colony := t.Game.ColonyNamed(sp.Id, arg.Name)
+	if colony == nil {
+		return hold{}, fmt.Errorf("PL %s: %w", arg.Name, ErrNoSuchPlanet)
+	}
+	return hold{colony: colony}, nil
+}
+
+// inventory returns the items in the hold.
+func (h hold) inventory() *map[string]int {
+	if h.ship != nil {
+		return &h.ship.Cargo
+	}
+	return &h.colony.Inventory
+}
+
+// coords returns the sector the hold is in.
+func (h hold) coords(g *Game) Coords {
+	if h.ship != nil {
+		return h.ship.Coords
+	}
+	return g.Galaxy.StarOf(h.colony.Planet).Coords
+}
+
+// room returns the number of units of an item that fit in the hold.
+// Planets have no limit.
+func (h hold) room(item *catalog.Item) int {
+	if h.ship == nil || item.Carry == 0 {
+		return -1
+	}
+	return (h.ship.CarryingCapacity() - cargoUsed(h.ship.Cargo)) / item.Carry
+}
+
+// String implements the Stringer interface.
+func (h hold) String() string {
+	if h.ship != nil {
+		return h.ship.String()
+	}
+	return "PL " + h.colony.Name
+}
+
+// cargoUsed returns the carrying capacity used by the items in a cargo hold.
+func cargoUsed(cargo map[string]int) int {
+	used := 0
+	for code, n := range cargo {
+		if item, ok := catalog.LookupItem(code); ok {
+			used += n * item.Carry
+		}
+	}
+	return used
+}
+
+// transfer moves items between ships and planets in the same sector.
+// A count of zero moves all of them, or as many as will fit.
+//
+// In the post-arrival phase, items can only be moved to planets that the
+// species already lives on. Transfers between planets use shuttles, and
+// besiegers may detect and destroy them.
+func transfer(t *Turn, sp *Species, cmd *orders.Command) error {
+	n, code := cmd.Args[0].Number, cmd.Args[1].Class
+	item, ok := catalog.LookupItem(code)
+	if !ok {
+		return fmt.Errorf("%s: %w", code, ErrInvalidClass)
+	}
+	src, err := t.holdArg(sp, cmd.Args[2])
+	if err != nil {
+		return err
+	}
+	dst, err := t.holdArg(sp, cmd.Args[3])
+	if err != nil {
+		return err
+	}
+	if src.coords(t.Game) != dst.coords(t.Game) {
+		return fmt.Errorf("%s: %w", dst, ErrNotHere)
+	} else if dst.colony != nil && t.phase == "post-arrival" && dst.colony.Population() == 0 {
+		return fmt.Errorf("%s: %w", dst, ErrNotColonized)
+	}
+
+	have := (*src.inventory())[item.Code]
+	if n == 0 {
+		n = have
+		if room := dst.room(item); room >= 0 {
+			n = min(n, room)
+		}
+	} else if have < n {
+		return fmt.Errorf("%s: have %d %s: %w", src, have, item.Code, ErrInsufficientItems)
+	} else if room := dst.room(item); room >= 0 && room < n {
+		return fmt.Errorf("%s: room for %d %s: %w", dst, room, item.Code, ErrInsufficientCapacity)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	addItems(src.inventory(), item.Code, -n)
+	if src.colony != nil && dst.colony != nil {
+		for _, colony := range []*Colony{src.colony, dst.colony} {
+			if besieger := t.detected(colony, false); besieger != nil {
+				t.Logf(besieger.Id, 0, "detected a shipment of %d %s on %s and destroyed it", n, item.Code, t.Game.location(colony))
+				return fmt.Errorf("%d %s: %w", n, item.Code, ErrDetected)
+			}
+		}
+	}
+	addItems(dst.inventory(), item.Code, n)
+	return nil
+}
+
+// install combines colonist units with colonial mining or manufacturing
+// units. Each pair adds a tenth to the mining or manufacturing base at the
+// end of the turn. A count of zero installs as many as possible.
+func install(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony := t.Game.ColonyNamed(sp.Id, cmd.Args[len(cmd.Args)-1].Name)
+	if colony == nil {
+		return fmt.Errorf("PL %s: %w", cmd.Args[len(cmd.Args)-1].Name, ErrNoSuchPlanet)
+	}
+	if cmd.Pattern() == "p" {
+		return t.installAll(sp, colony)
+	}
+	n, code := cmd.Args[0].Number, cmd.Args[1].Class
+	if code != "IU" && code != "AU" {
+		return fmt.Errorf("%s: %w", code, ErrInvalidClass)
+	}
+	if n == 0 {
+		n = min(colony.Inventory["CU"], colony.Inventory[code])
+	}
+	return t.install(sp, colony, code, n)
+}
+
+// unload moves the colonists and colonial units from a ship to the
+// colony on the planet it is at, and installs as many as possible.
+func unload(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+	} else if ship.Planet == 0 {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	}
+	colony := t.Game.colonyOn(sp.Id, ship.Planet)
+	if colony == nil {
+		return fmt.Errorf("%s: planet must be named first: %w", ship, ErrNoSuchPlanet)
+	}
+	for _, code := range []string{"CU", "IU", "AU"} {
+		addItems(&colony.Inventory, code, ship.Cargo[code])
+		addItems(&ship.Cargo, code, -ship.Cargo[code])
+	}
+	return t.installAll(sp, colony)
+}
+
+// installAll installs as many colonial units as possible, mining units first.
+func (t *Turn) installAll(sp *Species, colony *Colony) error {
+	if err := t.Game.canInstall(sp, colony); err != nil {
+		return err
+	}
+	for _, code := range []string{"IU", "AU"} {
+		if n := min(colony.Inventory["CU"], colony.Inventory[code]); n > 0 {
+			if err := t.install(sp, colony, code, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// install uses up n colonist units and n colonial units to grow a colony.
+func (t *Turn) install(sp *Species, colony *Colony, code string, n int) error {
+	if err := t.Game.canInstall(sp, colony); err != nil {
+		return err
+	} else if colony.Inventory["CU"] < n || colony.Inventory[code] < n {
+		return fmt.Errorf("need %d CU and %d %s: %w", n, n, code, ErrInsufficientItems)
+	}
+	addItems(&colony.Inventory, "CU", -n)
+	addItems(&colony.Inventory, code, -n)
+	inst := &installation{colony: colony}
+	if code == "IU" {
+		inst.mining = n
+	} else {
+		inst.manufacturing = n
+	}
+	t.installations = append(t.installations, inst)
+	return nil
+}
+
+// canInstall returns an error if the species can't install colonial units
+// on the colony's planet. Units can't be installed on home planets, and the
+// species needs enough life support to live on the planet.
+func (g *Game) canInstall(sp *Species, colony *Colony) error {
+	for _, other := range g.Colonies {
+		if other.IsHome && other.Planet == colony.Planet {
+			return fmt.Errorf("PL %s: home planet: %w", colony.Name, ErrNotAllowed)
+		}
+	}
+	if lsn := LifeSupportNeeded(sp, g.Galaxy.Planet(colony.Planet)); sp.Tech[LS] < lsn {
+		return fmt.Errorf("PL %s needs LS %d: %w", colony.Name, lsn, ErrTechTooLow)
+	}
+	return nil
+}
+
+// finishInstallations adds the units installed this turn to the colonies'
+// mining and manufacturing bases.
+func finishInstallations(t *Turn) {
+	for _, inst := range t.installations {
+		if t.Game.ColonyById(inst.colony.Id) != inst.colony {
+			continue // lost during the turn
+		}
+		inst.colony.MiningBase += inst.mining
+		inst.colony.ManufacturingBase += inst.manufacturing
+	}
+	t.installations = nil
+}
+
+// develop builds colonist units along with a balance of colonial mining
+// and manufacturing units for a colony. With no colony, the units are for
+// the producing planet. With a ship, the units fill its empty cargo space
+// and are loaded if the ship is in the producing planet's sector;
+// otherwise they are sent to the colony if it is in the same sector, or
+// left on the producing planet.
+//
+// The number of units is limited by the producing planet's available
+// population, its funds, and the optional spending limit.
+func develop(t *Turn, sp *Species, cmd *orders.Command) error {
+	producer, _, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	args, limit := cmd.Args, -1
+	if len(args) > 0 && args[0].Kind == orders.Number {
+		args, limit = args[1:], args[0].Number
+	}
+	colony := producer
+	if len(args) > 0 {
+		if colony = t.Game.ColonyNamed(sp.Id, args[0].Name); colony == nil {
+			return fmt.Errorf("PL %s: %w", args[0].Name, ErrNoSuchPlanet)
+		}
+	}
+	var ship *Ship
+	if len(args) > 1 {
+		if ship, err = t.shipArg(sp, args[1]); err != nil {
+			return err
+		}
+	}
+	here := t.Game.Galaxy.StarOf(producer.Planet).Coords
+	if colony.IsHome {
+		return fmt.Errorf("PL %s: home planet: %w", colony.Name, ErrNotAllowed)
+	} else if colony == producer && (colony.IsMiningColony() || t.Game.IsResortColony(colony)) {
+		return fmt.Errorf("PL %s: no production: %w", colony.Name, ErrNotAllowed)
+	} else if ship == nil && t.Game.Galaxy.StarOf(colony.Planet).Coords != here {
+		return fmt.Errorf("PL %s: %w", colony.Name, ErrNotHere)
+	}
+
+	// each colonist unit comes with one colonial unit unless the colony
+	// can build its own
+	funds := t.funds(sp)
+	if limit >= 0 {
+		funds = min(funds, limit)
+	}
+	selfSufficient := ship != nil && colony.ManufacturingBase > 0 && !colony.IsMiningColony() && !t.Game.IsResortColony(colony)
+	perUnit := 2
+	if selfSufficient {
+		perUnit = 1
+	}
+	n := min(producer.AvailablePopulation, funds/perUnit)
+	if ship != nil && ship.Coords == here {
+		n = min(n, (ship.CarryingCapacity()-cargoUsed(ship.Cargo))/perUnit)
+	}
+	if n <= 0 {
+		return fmt.Errorf("have %d available population and %d funds: %w", producer.AvailablePopulation, funds, ErrInsufficientPopulation)
+	}
+	iu, au := 0, 0
+	if !selfSufficient {
+		iu, au = t.Game.balance(sp, colony, n)
+	}
+	if err := t.spend(sp, cmd, n+iu+au); err != nil {
+		return err
+	}
+	producer.AvailablePopulation -= n
+
+	dest := &producer.Inventory
+	if ship != nil && ship.Coords == here {
+		dest = &ship.Cargo
+	} else if t.Game.Galaxy.StarOf(colony.Planet).Coords == here {
+		dest = &colony.Inventory
+	}
+	addItems(dest, "CU", n)
+	addItems(dest, "IU", iu)
+	addItems(dest, "AU", au)
+	return nil
+}
+
+// balance splits n colonial units between mining and manufacturing so
+// that the raw material a colony mines matches what it can manufacture.
+// Mining colonies only get mining units and resort colonies only get
+// manufacturing units.
+//
+// Raw material is 10×MI×MB/MD and capacity is MA×MAB/10 (with bases in
+// tenths and MD in hundredths), so they balance when MB/MAB = MA×MD/(100×MI).
+func (g *Game) balance(sp *Species, colony *Colony, n int) (iu, au int) {
+	if colony.IsMiningColony() {
+		return n, 0
+	} else if g.IsResortColony(colony) {
+		return 0, n
+	}
+	md := g.Galaxy.Planet(colony.Planet).MiningDifficulty
+	total := colony.MiningBase + colony.ManufacturingBase + n
+	mining := total * sp.Tech[MA] * md / (sp.Tech[MA]*md + 100*sp.Tech[MI])
+	iu = clamp(mining-colony.MiningBase, 0, n)
+	return iu, n - iu
+}
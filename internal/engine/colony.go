@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+const (
+	// economicBaseLimit is the total economic base, in tenths, above which
+	// a planet's colonies lose economic efficiency.
+	economicBaseLimit = 2000
+
+	// smallColony is the population below which a colony loses a unit
+	// of population every turn.
+	smallColony = 50
+
+	// resortLifeSupport is the life support needed at which a planet is
+	// too hostile to be a resort.
+	resortLifeSupport = 6
+)
+
+// LifeSupportNeeded returns the life support tech level that a species
+// needs to live on a planet. Each of these needs 3 levels: the required
+// gas missing or out of range, each poisonous gas in the atmosphere, and
+// each point of difference from the home planet's temperature and
+// pressure classes.
+func LifeSupportNeeded(sp *Species, planet *Planet) int {
+	lsn := 3*abs(planet.TemperatureClass-sp.TemperatureClass) + 3*abs(planet.PressureClass-sp.PressureClass)
+	if pct := planet.Atmosphere.Percent(sp.RequiredGas); pct < sp.RequiredMin || pct > sp.RequiredMax {
+		lsn += 3
+	}
+	for _, c := range planet.Atmosphere {
+		if sp.IsPoison(c.Gas) {
+			lsn += 3
+		}
+	}
+	return lsn
+}
+
+// ProductionPenalty returns the percent of a planet's output that a
+// species needs just to survive there: 100 times the life support needed
+// divided by the species' life support tech level.
+func ProductionPenalty(sp *Species, planet *Planet) int {
+	lsn := LifeSupportNeeded(sp, planet)
+	if lsn == 0 {
+		return 0
+	} else if sp.Tech[LS] <= lsn {
+		return 100
+	}
+	return 100 * lsn / sp.Tech[LS]
+}
+
+// Habitability returns how habitable a planet is for a species and the life
+// support tech level the species needs to live there. The score is the
+// percent of the planet's output that the species keeps after the
+// production penalty at its current life support tech level: 100 for a
+// planet just like home and 0 for one where it can't survive.
+func Habitability(sp *Species, planet *Planet) (score, lsn int) {
+	lsn = LifeSupportNeeded(sp, planet)
+	if sp.Tech[LS] < lsn {
+		return 0, lsn
+	}
+	return 100 - ProductionPenalty(sp, planet), lsn
+}
+
+// IsMiningColony returns true if the colony mines but has no manufacturing.
+func (c *Colony) IsMiningColony() bool {
+	return !c.IsHome && c.MiningBase > 0 && c.ManufacturingBase == 0
+}
+
+// IsResortColony returns true if the colony manufactures without mining
+// on a planet that is pleasant for the species: gravity no higher than
+// the home planet's and less than 6 levels of life support needed.
+func (g *Game) IsResortColony(c *Colony) bool {
+	if c.IsHome || c.MiningBase != 0 || c.ManufacturingBase == 0 {
+		return false
+	}
+	sp, planet := g.SpeciesById(c.Species), g.Galaxy.Planet(c.Planet)
+	return planet.Gravity <= g.Galaxy.Planet(sp.HomePlanet).Gravity && LifeSupportNeeded(sp, planet) < resortLifeSupport
+}
+
+// Population returns the colony's population units: its installed base,
+// in tenths, plus colonist units, planetary defense units and available
+// population.
+func (c *Colony) Population() int {
+	return c.MiningBase + c.ManufacturingBase + c.Inventory["CU"] + c.Inventory["PD"] + c.AvailablePopulation
+}
+
+// EconomicEfficiency returns the percent of a colony's production that
+// it can use. Every colony on a planet shares the planet's efficiency,
+// which drops once the total base of all of them passes 200.0 because
+// anything over that counts for only 5%. Home planets are always 100%.
+func (g *Game) EconomicEfficiency(c *Colony) int {
+	if c.IsHome {
+		return 100
+	}
+	total := 0
+	for _, colony := range g.Colonies {
+		if colony.Planet == c.Planet {
+			total += colony.MiningBase + colony.ManufacturingBase
+		}
+	}
+	if total <= economicBaseLimit {
+		return 100
+	}
+	return 100 * (economicBaseLimit + (total-economicBaseLimit)/20) / total
+}
+
+// availablePopulation returns the population a colony has available to
+// hire for the next turn. Growth is 10% of the installed population when
+// no life support is needed, dropping to nothing when the species' life
+// support tech is just enough to survive, with a small random fluctuation.
+// Colonies other than the home planet grow another percent for every 20
+// levels of biology. Mining and resort colonies never have available
+// population.
+func (g *Game) availablePopulation(r *RNG, c *Colony) int {
+	if c.IsMiningColony() || g.IsResortColony(c) {
+		return 0
+	}
+	sp, planet := g.SpeciesById(c.Species), g.Galaxy.Planet(c.Planet)
+	rate := 100 - ProductionPenalty(sp, planet) // tenths of a percent
+	rate = rate * (90 + r.Intn(21)) / 100
+	if !c.IsHome {
+		rate += sp.Tech[BI] / 20 * 10
+	}
+	return (c.MiningBase + c.ManufacturingBase) * rate / 1000
+}
+
+// attrition takes one unit of population from a small colony: available
+// population first, then colonist units, planetary defense units,
+// manufacturing base and mining base. It returns what was lost, or an
+// empty string if the colony didn't lose anything that is reported.
+func (c *Colony) attrition() string {
+	if pop := c.Population(); pop == 0 || pop >= smallColony {
+		return ""
+	}
+	switch {
+	case c.AvailablePopulation > 0:
+		c.AvailablePopulation--
+		return ""
+	case c.Inventory["CU"] > 0:
+		addItems(&c.Inventory, "CU", -1)
+		return "1 CU"
+	case c.Inventory["PD"] > 0:
+		addItems(&c.Inventory, "PD", -1)
+		return "1 PD"
+	case c.ManufacturingBase > 0:
+		c.ManufacturingBase--
+		return "0.1 manufacturing base"
+	}
+	c.MiningBase--
+	return "0.1 mining base"
+}
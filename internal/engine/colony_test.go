@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestColonyMath(t *testing.T) {
+	sp := &engine.Species{TemperatureClass: 12, PressureClass: 10, RequiredGas: engine.O2, RequiredMin: 10, RequiredMax: 30, PoisonGases: []engine.Gas{engine.Cl2, engine.SO2}}
+	sp.Tech[engine.LS] = 36
+	for _, tc := range []struct {
+		name    string
+		planet  *engine.Planet
+		lsn     int
+		penalty int
+	}{
+		{"home", &engine.Planet{TemperatureClass: 12, PressureClass: 10, Atmosphere: engine.Atmosphere{{Gas: engine.O2, Percent: 20}}}, 0, 0},
+		{"cold", &engine.Planet{TemperatureClass: 10, PressureClass: 11, Atmosphere: engine.Atmosphere{{Gas: engine.O2, Percent: 20}}}, 9, 25},
+		{"toxic", &engine.Planet{TemperatureClass: 13, PressureClass: 10, Atmosphere: engine.Atmosphere{{Gas: engine.Cl2, Percent: 60}, {Gas: engine.SO2, Percent: 40}}}, 12, 33},
+		{"hostile", &engine.Planet{TemperatureClass: 30, PressureClass: 0}, 87, 100},
+	} {
+		if got := engine.LifeSupportNeeded(sp, tc.planet); got != tc.lsn {
+			t.Errorf("%s: LifeSupportNeeded: expected %d: got %d\n", tc.name, tc.lsn, got)
+		}
+		if got := engine.ProductionPenalty(sp, tc.planet); got != tc.penalty {
+			t.Errorf("%s: ProductionPenalty: expected %d: got %d\n", tc.name, tc.penalty, got)
+		}
+		if score, lsn := engine.Habitability(sp, tc.planet); score != 100-tc.penalty || lsn != tc.lsn {
+			t.Errorf("%s: Habitability: expected %d and %d: got %d and %d\n", tc.name, 100-tc.penalty, tc.lsn, score, lsn)
+		}
+	}
+
+	// every colony on a planet shares the planet's economic efficiency
+	g, _, earth := newTestGame(t)
+	planet := otherPlanet(g, earth)
+	mars := &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Mars", MiningBase: 1000, ManufacturingBase: 800}
+	g.Colonies = append(g.Colonies, mars)
+	if got := g.EconomicEfficiency(mars); got != 100 {
+		t.Errorf("EconomicEfficiency: 180.0: expected 100: got %d\n", got)
+	}
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 101, Species: 2, Planet: planet.Id, Name: "Barsoom", MiningBase: 1000, ManufacturingBase: 1200})
+	if got := g.EconomicEfficiency(mars); got != 52 {
+		t.Errorf("EconomicEfficiency: 400.0: expected 52: got %d\n", got)
+	}
+	if got := g.EconomicEfficiency(earth); got != 100 {
+		t.Errorf("EconomicEfficiency: home: expected 100: got %d\n", got)
+	}
+}
+
+// otherPlanet returns a planet in the colony's star system other than the
+// colony's planet.
+func otherPlanet(g *engine.Game, colony *engine.Colony) *engine.Planet {
+	for _, p := range g.Galaxy.StarOf(colony.Planet).Planets {
+		if p.Id != colony.Planet {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestColonization(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	sp.Tech[engine.LS] = 99
+	planet := otherPlanet(g, earth)
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	earth.Inventory = map[string]int{"CU": 100, "IU": 30, "AU": 30}
+	g.Ships = []*engine.Ship{{Id: 1, Species: 1, Class: "TR10", Name: "Hauler", Tonnage: 100_000, Coords: coords, Planet: planet.Id, Status: engine.Landed}}
+	log := runOrders(t, g, fmt.Sprintf(`START PRE-DEPARTURE
+	NAME %d %d %d %d PL Mars
+	NAME %d %d %d %d PL Earth
+	TRANSFER 60 CU PL Earth, TR10 Hauler
+	TRANSFER 30 IU PL Earth, TR10 Hauler
+	TRANSFER 30 AU PL Earth, TR10 Hauler
+	TRANSFER 40 CU PL Earth, TR10 Hauler
+	UNLOAD TR10 Hauler
+	INSTALL PL Earth
+END
+`, coords.X, coords.Y, coords.Z, planet.Orbit, coords.X, coords.Y, coords.Z, planet.Orbit))
+	for line, expect := range map[int]string{
+		3: "duplicate name",
+		7: "insufficient cargo capacity",
+		9: "not allowed here",
+	} {
+		if !strings.Contains(log[line], expect) {
+			t.Errorf("colonization: line %d: expected %q: got %q\n", line, expect, log[line])
+		}
+	}
+	if len(log) != 3 {
+		t.Errorf("colonization: log: expected 3 errors: got %v\n", log)
+	}
+
+	mars := g.ColonyNamed(1, "Mars")
+	if mars == nil {
+		t.Fatalf("colonization: expected a colony on Mars\n")
+	} else if mars.Planet != planet.Id {
+		t.Errorf("colonization: expected planet %d: got %d\n", planet.Id, mars.Planet)
+	}
+	// the units are installed by the end of the turn
+	if mars.MiningBase != 30 || mars.ManufacturingBase != 30 || len(mars.Inventory) != 0 {
+		t.Errorf("colonization: expected 3.0 and 3.0 with nothing left: got %d, %d and %v\n", mars.MiningBase, mars.ManufacturingBase, mars.Inventory)
+	}
+	if ship := g.ShipNamed(1, "Hauler"); len(ship.Cargo) != 0 {
+		t.Errorf("colonization: expected an empty transport: got %v\n", ship.Cargo)
+	}
+	if earth.Inventory["CU"] != 40 {
+		t.Errorf("colonization: expected 40 CU left on Earth: got %d\n", earth.Inventory["CU"])
+	}
+}
+
+func TestColonization_NotVisited(t *testing.T) {
+	g, _, earth := newTestGame(t)
+	var c engine.Coords
+	for _, star := range g.Galaxy.Stars {
+		if star.Id != g.Galaxy.StarOf(earth.Planet).Id {
+			c = star.Coords
+			break
+		}
+	}
+	log := runOrders(t, g, fmt.Sprintf("START PRE-DEPARTURE\nNAME %d %d %d 1 PL Vulcan\nEND\n", c.X, c.Y, c.Z))
+	if !strings.Contains(log[2], "star system not visited") {
+		t.Errorf("name: expected %q: got %q\n", "star system not visited", log[2])
+	}
+}
+
+func TestDevelop(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	planet := otherPlanet(g, earth)
+	mars := &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Mars"}
+	g.Colonies = append(g.Colonies, mars)
+	earth.AvailablePopulation = 40
+
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nDEVELOP PL Mars\nDEVELOP PL Earth\nEND\n"})
+	if len(g.Log) != 1 || !strings.Contains(g.Log[0].Text, "not allowed here") {
+		t.Errorf("develop: log: expected the home planet to be refused: got %v\n", g.Log)
+	}
+	// the units are balanced by the mining difficulty
+	ma, md := sp.Tech[engine.MA], planet.MiningDifficulty
+	iu := 40 * ma * md / (ma*md + 100*sp.Tech[engine.MI])
+	if mars.Inventory["CU"] != 40 || mars.Inventory["IU"] != iu || mars.Inventory["AU"] != 40-iu {
+		t.Errorf("develop: expected 40 CU, %d IU and %d AU: got %v\n", iu, 40-iu, mars.Inventory)
+	}
+	if earth.AvailablePopulation != 0 {
+		t.Errorf("develop: expected no available population: got %d\n", earth.AvailablePopulation)
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Spent != 80 {
+		t.Errorf("develop: expected 80 spent: got %d\n", ledger.Spent)
+	}
+}
+
+func TestPopulationGrowth(t *testing.T) {
+	g, _, earth := newTestGame(t)
+	outpost := &engine.Colony{Id: 100, Species: 1, Planet: otherPlanet(g, earth).Id, Name: "Outpost", MiningBase: 5, Inventory: map[string]int{"CU": 1}}
+	g.Colonies = append(g.Colonies, outpost)
+	mb, mab := earth.MiningBase, earth.ManufacturingBase
+
+	if err := engine.RunTurn(g, nil, engine.WithSteps(engine.DefaultSteps()[6])); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+	// home planets grow about 2% a turn
+	if earth.MiningBase < mb*1018/1000 || earth.MiningBase > mb*1022/1000 || earth.ManufacturingBase < mab*1018/1000 || earth.ManufacturingBase > mab*1022/1000 {
+		t.Errorf("growth: expected about 2%%: got %d to %d and %d to %d\n", mb, earth.MiningBase, mab, earth.ManufacturingBase)
+	}
+	if earth.AvailablePopulation == 0 {
+		t.Errorf("growth: expected available population on Earth\n")
+	}
+	// small colonies lose population, colonists first
+	if outpost.MiningBase != 5 || outpost.Inventory["CU"] != 0 {
+		t.Errorf("attrition: expected the colonist unit to be lost: got %d and %v\n", outpost.MiningBase, outpost.Inventory)
+	}
+}
+
+func TestPopulationGrowth_Biology(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	planet := otherPlanet(g, earth)
+	lsn := engine.LifeSupportNeeded(sp, planet)
+	if lsn == 0 {
+		t.Fatalf("biology: expected the colony to need life support\n")
+	}
+	// with just enough life support, colonies only grow from biology
+	sp.Tech[engine.LS] = lsn
+	colony := &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Colony", MiningBase: 1000, ManufacturingBase: 1000}
+	g.Colonies = append(g.Colonies, colony)
+	for _, tc := range []struct {
+		bi, expect int
+	}{
+		{19, 0},
+		{20, 20},
+		{40, 40},
+	} {
+		sp.Tech[engine.BI] = tc.bi
+		colony.MiningBase, colony.ManufacturingBase = 1000, 1000
+		mb, mab := earth.MiningBase, earth.ManufacturingBase
+		if err := engine.RunTurn(g, nil, engine.WithSteps(engine.DefaultSteps()[6])); err != nil {
+			t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+		}
+		if colony.AvailablePopulation != tc.expect {
+			t.Errorf("biology: BI %d: expected %d: got %d\n", tc.bi, tc.expect, colony.AvailablePopulation)
+		}
+		// the home planet doesn't get the bonus
+		if limit := (mb + mab) * 110 / 1000; earth.AvailablePopulation > limit {
+			t.Errorf("biology: BI %d: Earth: expected at most %d: got %d\n", tc.bi, limit, earth.AvailablePopulation)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+	"sort"
+	"strconv"
+)
+
+// Engagement options from the ENGAGE order.
+const (
+	DefenseInPlace  = 0
+	DeepSpaceDefend = 1
+	PlanetDefense   = 2
+	DeepSpaceFight  = 3
+	PlanetAttack    = 4
+	Bombardment     = 5
+	GermWarfare     = 6
+	Besiege         = 7
+)
+
+// Target types from the TARGET order.
+const (
+	TargetWarships    = 1
+	TargetTransports  = 2
+	TargetStarbases   = 3
+	TargetPlanetaryDU = 4
+)
+
+// Battle is the record of a battle, kept for the combat logs in the reports.
+type Battle struct {
+	Coords  Coords        `json:"coords"`
+	Phase   string        `json:"phase"`
+	Species []int         `json:"species"`           // everyone who took part
+	Summary []int         `json:"summary,omitempty"` // species that asked for a summary
+	Lines   []*BattleLine `json:"lines"`
+}
+
+// BattleLine is a line in a combat log.
+// Detail lines, like misses and hits that don't destroy anything, are
+// left out of the log for species that gave a SUMMARY order.
+type BattleLine struct {
+	Text   string `json:"text"`
+	Detail bool   `json:"detail,omitempty"`
+}
+
+// LogFor returns the combat log for a species that took part in the battle.
+func (b *Battle) LogFor(species int) []string {
+	summary := false
+	for _, id := range b.Summary {
+		summary = summary || id == species
+	}
+	var lines []string
+	for _, line := range b.Lines {
+		if !line.Detail || !summary {
+			lines = append(lines, line.Text)
+		}
+	}
+	return lines
+}
+
+// battlePlan is a species' combat orders for one location.
+// Species that are at a battle without giving orders get the defaults:
+// defense in place, and withdraw only when half the fleet is lost.
+type battlePlan struct {
+	species  int
+	coords   Coords
+	alert    bool // the species gave a BATTLE order for the location
+	engage   []engagement
+	attack   []int // ids of the species to attack
+	enemies  bool  // attack all declared enemies
+	target   int
+	haven    *Coords
+	withdraw [3]int // transport age, warship age, fleet loss percentage
+	summary  bool
+	hidden   map[int]bool // ids of ships kept out of the fighting
+
+	hijack        []int // ids of the species to hijack
+	hijackEnemies bool  // hijack all declared enemies
+}
+
+// engagement is an ENGAGE option and the planet number it applies to.
+type engagement struct {
+	option int
+	planet int
+}
+
+// defaultWithdraw is the WITHDRAW order used when none is given.
+var defaultWithdraw = [3]int{0, 50, 50}
+
+// has returns true if the plan includes the engagement option.
+func (p *battlePlan) has(options ...int) bool {
+	for _, e := range p.engage {
+		for _, option := range options {
+			if e.option == option {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAggressor returns true if the plan attacks or hijacks someone.
+func (p *battlePlan) isAggressor() bool {
+	return (len(p.attack) != 0 || p.enemies || p.isHijacking()) && p.has(DeepSpaceFight, PlanetAttack, Bombardment, GermWarfare, Besiege)
+}
+
+// attacks returns true if the plan attacks or hijacks the species.
+func (p *battlePlan) attacks(t *Turn, species int) bool {
+	for _, id := range p.attack {
+		if id == species {
+			return true
+		}
+	}
+	return (p.enemies && t.Game.declaredEnemy(p.species, species)) || p.hijacks(t, species)
+}
+
+// isHijacking returns true if the plan hijacks anyone.
+func (p *battlePlan) isHijacking() bool {
+	return len(p.hijack) != 0 || p.hijackEnemies
+}
+
+// hijacks returns true if the plan hijacks the species. A species named
+// in an ATTACK order is attacked even if all enemies are hijacked.
+func (p *battlePlan) hijacks(t *Turn, species int) bool {
+	for _, id := range p.hijack {
+		if id == species {
+			return true
+		}
+	}
+	for _, id := range p.attack {
+		if id == species {
+			return false
+		}
+	}
+	return p.hijackEnemies && t.Game.declaredEnemy(p.species, species)
+}
+
+// plan returns the species' plan for the battle started by its last BATTLE order.
+func (t *Turn) plan(sp *Species) (*battlePlan, error) {
+	if plan := t.battlePlan[sp.Id]; plan != nil {
+		return plan, nil
+	}
+	return nil, ErrNoBattle
+}
+
+// battle starts the combat orders for a location.
+// The species must have ships or colonies there.
+func battle(t *Turn, sp *Species, cmd *orders.Command) error {
+	delete(t.battlePlan, sp.Id)
+	c := Coords{X: cmd.Args[0].Number, Y: cmd.Args[1].Number, Z: cmd.Args[2].Number}
+	if !t.Game.Galaxy.Contains(c) {
+		return fmt.Errorf("%s: %w", c, ErrInvalidCoords)
+	} else if !t.Game.hasForcesAt(sp.Id, c) {
+		return fmt.Errorf("%s: %w", c, ErrNoForces)
+	}
+	for _, plan := range t.battlePlans {
+		if plan.species == sp.Id && plan.coords == c {
+			t.battlePlan[sp.Id] = plan
+			return nil
+		}
+	}
+	plan := &battlePlan{species: sp.Id, coords: c, alert: true, withdraw: defaultWithdraw}
+	t.battlePlans = append(t.battlePlans, plan)
+	t.battlePlan[sp.Id] = plan
+	return nil
+}
+
+// engage adds an engagement option to the battle.
+// Bombardment, germ warfare and siege take too long for the strike phase.
+func engage(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	}
+	e := engagement{option: cmd.Args[0].Number}
+	if len(cmd.Args) == 2 {
+		e.planet = cmd.Args[1].Number
+	}
+	switch e.option {
+	case DefenseInPlace, DeepSpaceDefend, DeepSpaceFight:
+		if e.planet != 0 {
+			return fmt.Errorf("option %d does not take a planet: %w", e.option, ErrInvalidOption)
+		}
+	case PlanetDefense, PlanetAttack, Bombardment, GermWarfare, Besiege:
+		star := t.Game.Galaxy.StarAt(plan.coords)
+		if star == nil || e.planet < 1 || e.planet > len(star.Planets) {
+			return fmt.Errorf("planet %d: %w", e.planet, ErrNoSuchPlanet)
+		}
+		if e.option >= Bombardment && t.phase == "strikes" {
+			return fmt.Errorf("option %d: %w", e.option, ErrNotAllowed)
+		}
+	default:
+		return fmt.Errorf("option %d: %w", e.option, ErrInvalidOption)
+	}
+	plan.engage = append(plan.engage, e)
+	return nil
+}
+
+// attack names a species to attack, or, with a zero argument, all of the
+// species' declared enemies. Field-distorted species are given by number.
+// HIJACK works the same way, but tries to capture the ships instead.
+func attack(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	}
+	hijack := cmd.Verb == orders.Hijack
+	arg := cmd.Args[0]
+	if arg.Kind == orders.Number {
+		if arg.Number != 0 {
+			return fmt.Errorf("%d: %w", arg.Number, ErrInvalidOption)
+		} else if hijack {
+			plan.hijackEnemies = true
+		} else {
+			plan.enemies = true
+		}
+		return nil
+	}
+	// a number is the species shown for field distorted ships, or a species id
+	other := t.Game.SpeciesNamed(arg.Name)
+	if id, err := strconv.Atoi(arg.Name); err == nil {
+		other = t.Game.SpeciesById(id)
+		for _, o := range t.Game.Species {
+			if o != sp && t.Game.Pseudonym(o) == id {
+				other = o
+				break
+			}
+		}
+	}
+	if other == nil {
+		return fmt.Errorf("SP %s: %w", arg.Name, ErrNoSuchSpecies)
+	} else if hijack {
+		plan.hijack = append(plan.hijack, other.Id)
+	} else {
+		plan.attack = append(plan.attack, other.Id)
+	}
+	return nil
+}
+
+// target sets the kind of unit to concentrate fire on.
+func target(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	} else if n := cmd.Args[0].Number; n < TargetWarships || n > TargetPlanetaryDU {
+		return fmt.Errorf("%d: %w", n, ErrInvalidOption)
+	}
+	plan.target = cmd.Args[0].Number
+	return nil
+}
+
+// haven sets where ships that withdraw from the battle will jump to.
+func haven(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	}
+	c := Coords{X: cmd.Args[0].Number, Y: cmd.Args[1].Number, Z: cmd.Args[2].Number}
+	if !t.Game.Galaxy.Contains(c) {
+		return fmt.Errorf("%s: %w", c, ErrInvalidCoords)
+	}
+	plan.haven = &c
+	return nil
+}
+
+// withdraw sets the conditions for leaving the battle.
+func withdraw(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	} else if n := cmd.Args[2].Number; n > 100 {
+		return fmt.Errorf("fleet loss %d%%: %w", n, ErrInvalidOption)
+	}
+	plan.withdraw = [3]int{cmd.Args[0].Number, cmd.Args[1].Number, cmd.Args[2].Number}
+	return nil
+}
+
+// summary asks for a brief combat log.
+func summary(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	}
+	plan.summary = true
+	return nil
+}
+
+// hide keeps a landed ship out of the battle until the species starts to
+// lose. HIDE orders for ships that aren't landed are ignored. Hiding a
+// colony is a production order.
+func hide(t *Turn, sp *Species, cmd *orders.Command) error {
+	plan, err := t.plan(sp)
+	if err != nil {
+		return err
+	} else if len(cmd.Args) == 0 {
+		return fmt.Errorf("colonies are hidden in the production section: %w", ErrNotAllowed)
+	}
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.Coords != plan.coords {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	} else if ship.Status != Landed {
+		return nil
+	}
+	if plan.hidden == nil {
+		plan.hidden = make(map[int]bool)
+	}
+	plan.hidden[ship.Id] = true
+	return nil
+}
+
+// combatStep returns a step that collects the combat orders from one
+// section of the order files and then fights the battles. Allies that
+// attacked become enemies once the battles are over. Ambushes that weren't
+// sprung by the end of the combat phase are wasted.
+func combatStep(name string, section orders.Section, handlers map[orders.Verb]handler) Step {
+	phase := phaseStep(name, section, handlers)
+	return Step{Name: phase.Name, Run: func(t *Turn) error {
+		t.battlePlans, t.battlePlan = nil, make(map[int]*battlePlan)
+		// sieges are forgotten at the start of every combat phase and
+		// must be renewed with combat orders each turn.
+		if section == orders.CombatSection {
+			t.Game.Sieges = nil
+		}
+		if err := phase.Run(t); err != nil {
+			return err
+		}
+		fightBattles(t)
+		applyBetrayals(t)
+		if section == orders.CombatSection {
+			t.Game.Ambushes = nil
+		}
+		return nil
+	}}
+}
+
+// fightBattles fights a battle at each location that has combat orders.
+// Locations are taken in coordinate order so that turns are repeatable.
+func fightBattles(t *Turn) {
+	var locations []Coords
+	seen := make(map[Coords]bool)
+	for _, plan := range t.battlePlans {
+		if !seen[plan.coords] {
+			seen[plan.coords] = true
+			locations = append(locations, plan.coords)
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].less(locations[j]) })
+	for _, c := range locations {
+		fight(t, c)
+	}
+}
+
+// hasForcesAt returns true if the species has ships or colonies at the location.
+func (g *Game) hasForcesAt(species int, c Coords) bool {
+	for _, ship := range g.Ships {
+		if ship.Species == species && ship.Coords == c {
+			return true
+		}
+	}
+	for _, colony := range g.Colonies {
+		if colony.Species == species && g.Galaxy.StarOf(colony.Planet).Coords == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+	"testing"
+)
+
+// newBattleGame returns a game with the Humanoids on Earth and the
+// Klingons, and the ships of both species in deep space in Earth's system.
+func newBattleGame(t *testing.T, ships ...*engine.Ship) (*engine.Game, engine.Coords) {
+	t.Helper()
+	g, _, earth := newTestGame(t)
+	if _, err := g.AddSpecies(engine.SpeciesSetup{Name: "Klingon", HomePlanet: "Kronos", Government: "High Council", GovernmentType: "Empire", ML: 10, GV: 1, LS: 4, BI: 0}); err != nil {
+		t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+	}
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	for i, ship := range ships {
+		ship.Id, ship.Coords, ship.Status = i+1, coords, engine.DeepSpace
+	}
+	g.Ships = ships
+	return g, coords
+}
+
+// fightTurn runs the combat phase with orders for each species.
+func fightTurn(t *testing.T, g *engine.Game, text map[int]string) {
+	t.Helper()
+	runStep(t, g, engine.DefaultSteps()[0], text)
+}
+
+// runStep runs a single step of a turn with orders for each species.
+func runStep(t *testing.T, g *engine.Game, step engine.Step, text map[int]string) {
+	t.Helper()
+	runSteps(t, g, []engine.Step{step}, text)
+}
+
+// runSteps runs some of the steps of a turn with orders for each species.
+func runSteps(t *testing.T, g *engine.Game, steps []engine.Step, text map[int]string) {
+	t.Helper()
+	o := make(map[int]*orders.Orders)
+	for id, s := range text {
+		var err error
+		if o[id], err = orders.Parse(strings.NewReader(s)); err != nil {
+			t.Fatalf("Parse: err: expected nil: got %v\n", err)
+		}
+	}
+	if err := engine.RunTurn(g, o, engine.WithSteps(steps...)); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+}
+
+// orderErrors returns the log entries for orders, which are the errors.
+func orderErrors(g *engine.Game) []string {
+	var errs []string
+	for _, e := range g.Log {
+		if e.Line != 0 {
+			errs = append(errs, e.Text)
+		}
+	}
+	return errs
+}
+
+func TestCombat_Orders(t *testing.T) {
+	g, coords := newBattleGame(t, &engine.Ship{Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000})
+	log := runOrders(t, g, fmt.Sprintf(`START COMBAT
+	ENGAGE 1
+	BATTLE 1 1 1
+	BATTLE %d %d %d
+	ENGAGE 9
+	ENGAGE 2 99
+	ATTACK SP Nobody
+	TARGET 7
+	WITHDRAW 0 50 150
+	HIDE
+	HIDE CT Picket
+END
+`, coords.X, coords.Y, coords.Z))
+	for line, expect := range map[int]string{
+		2:  "missing BATTLE order",
+		3:  "no ships or colonies at location",
+		5:  "invalid option",
+		6:  "no such planet",
+		7:  "no such species",
+		8:  "invalid option",
+		9:  "invalid option",
+		10: "not allowed here",
+	} {
+		if !strings.Contains(log[line], expect) {
+			t.Errorf("combat: line %d: expected %q: got %q\n", line, expect, log[line])
+		}
+	}
+	if len(log) != 8 {
+		t.Errorf("combat: log: expected 8 errors: got %v\n", log)
+	}
+	if len(g.Battles) != 0 {
+		t.Errorf("combat: battles: expected none without an attack: got %d\n", len(g.Battles))
+	}
+}
+
+func TestCombat_Battle(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000},
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000},
+	)
+	battle := fmt.Sprintf("BATTLE %d %d %d\n", coords.X, coords.Y, coords.Z)
+	fightTurn(t, g, map[int]string{
+		1: "START COMBAT\n" + battle + "SUMMARY\nHAVEN 1 1 1\nWITHDRAW 10 20 50\nEND\n",
+		2: "START COMBAT\n" + battle + "ATTACK SP Humanoid\nENGAGE 3\nTARGET 1\nEND\n",
+	})
+
+	if len(g.Battles) != 1 {
+		t.Fatalf("battle: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	b := g.Battles[0]
+	if b.Coords != coords || len(b.Species) != 2 {
+		t.Errorf("battle: expected both species at %s: got %v at %s\n", coords, b.Species, b.Coords)
+	}
+	// the heavy cruiser fires on the corvette first and the Humanoids
+	// withdraw whatever is left once half of their fleet is gone
+	if g.ShipNamed(1, "Picket") != nil {
+		t.Errorf("battle: expected the corvette to be destroyed\n")
+	}
+	if ship := g.ShipNamed(1, "Hauler"); ship != nil && (!ship.Withdrawn || ship.Haven == nil || *ship.Haven != (engine.Coords{X: 1, Y: 1, Z: 1})) {
+		t.Errorf("battle: expected the transport to withdraw to the haven: got %+v\n", ship)
+	}
+	if ship := g.ShipNamed(2, "Bird of Prey"); ship == nil || ship.Withdrawn {
+		t.Errorf("battle: expected the heavy cruiser to hold the field\n")
+	}
+
+	// the Humanoids asked for a summary, so they don't see the misses
+	full, brief := b.LogFor(2), b.LogFor(1)
+	if len(brief) >= len(full) {
+		t.Errorf("battle: summary: expected a shorter log: got %d lines, full log has %d\n", len(brief), len(full))
+	}
+	for _, line := range brief {
+		if strings.Contains(line, "misses") || strings.HasPrefix(line, "Round") {
+			t.Errorf("battle: summary: unexpected detail %q\n", line)
+		}
+	}
+	if !strings.Contains(strings.Join(full, "\n"), "SP Klingon attacks SP Humanoid") {
+		t.Errorf("battle: log: expected the attack to be announced: got %q\n", full)
+	}
+}
+
+func TestCombat_TransportsOnly(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	battle := fmt.Sprintf("BATTLE %d %d %d\n", coords.X, coords.Y, coords.Z)
+	fightTurn(t, g, map[int]string{
+		2: "START COMBAT\n" + battle + "ATTACK SP Humanoid\nENGAGE 3\nEND\n",
+	})
+	if len(g.Battles) != 0 {
+		t.Errorf("transports: expected no battle: got %d\n", len(g.Battles))
+	}
+}
+
+func TestCombat_HideInOrbit(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000},
+	)
+	planet := otherPlanet(g, g.ColonyNamed(1, "Earth"))
+	g.Ships[0].Planet, g.Ships[0].Status = planet.Id, engine.InOrbit
+	battle := fmt.Sprintf("BATTLE %d %d %d\n", coords.X, coords.Y, coords.Z)
+	fightTurn(t, g, map[int]string{
+		1: "START COMBAT\n" + battle + "HIDE CT Picket\nEND\n",
+		2: "START COMBAT\n" + battle + fmt.Sprintf("ATTACK SP Humanoid\nENGAGE 4 %d\nEND\n", planet.Orbit),
+	})
+	// only landed ships can hide, so the corvette fights and is destroyed
+	if len(g.Battles) != 1 {
+		t.Fatalf("hide: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	if g.ShipNamed(1, "Picket") != nil {
+		t.Errorf("hide: expected the orbiting corvette to fight and be destroyed\n")
+	}
+}
+
+func TestCombat_Hijack(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "BS", Name: "Pirate", Tonnage: 450_000},
+		&engine.Ship{Species: 2, Class: "CT", Name: "Prize", Tonnage: 20_000, Cargo: map[string]int{"CU": 10}},
+	)
+	human := g.SpeciesById(1)
+	human.Treasury = 0
+	steps := engine.DefaultSteps()
+	runSteps(t, g, []engine.Step{steps[0], steps[6]}, map[int]string{
+		1: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nHIJACK SP Klingon\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z),
+	})
+	if len(g.Battles) != 1 {
+		t.Fatalf("hijack: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	if log := strings.Join(g.Battles[0].LogFor(2), "\n"); !strings.Contains(log, "SP Klingon CT Prize is hijacked by SP Humanoid BS Pirate") {
+		t.Errorf("hijack: expected the corvette to be hijacked: got %q\n", log)
+	}
+	if g.ShipNamed(2, "Prize") != nil {
+		t.Errorf("hijack: expected the corvette to be taken\n")
+	}
+	// the corvette is sold for 3 * 200 * 60 / 200 = 180 and its cargo for 5
+	if human.Treasury != 185 {
+		t.Errorf("hijack: expected 185 in the treasury: got %d\n", human.Treasury)
+	}
+	for _, e := range g.Log {
+		if e.Species == 2 && e.Text == "HIJACK 185 to SP Humanoid" {
+			return
+		}
+	}
+	t.Errorf("hijack: expected the Klingons to be told of the loss\n")
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"math"
+)
+
+// Coords are the X, Y, and Z coordinates of a sector, in parsecs.
+// All coordinates in the galaxy are zero or greater.
+type Coords struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// DistanceTo returns the distance, in parsecs, between two sectors.
+func (c Coords) DistanceTo(o Coords) float64 {
+	dx, dy, dz := float64(o.X-c.X), float64(o.Y-c.Y), float64(o.Z-c.Z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// less returns true if c comes before o, sorting by X, then Y, then Z.
+func (c Coords) less(o Coords) bool {
+	if c.X != o.X {
+		return c.X < o.X
+	} else if c.Y != o.Y {
+		return c.Y < o.Y
+	}
+	return c.Z < o.Z
+}
+
+// String implements the Stringer interface.
+// Coordinates are formatted the way they are entered on an order form.
+func (c Coords) String() string {
+	return fmt.Sprintf("%d %d %d", c.X, c.Y, c.Z)
+}
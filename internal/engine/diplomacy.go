@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+	"sort"
+)
+
+// Status is the stance that one species declares toward another.
+type Status int
+
+const (
+	Neutral Status = iota
+	Ally
+	Enemy
+)
+
+var statusNames = []string{"neutral", "ally", "enemy"}
+
+// String implements the Stringer interface.
+func (s Status) String() string {
+	if s < Neutral || s > Enemy {
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+	return statusNames[s]
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (s *Status) UnmarshalText(text []byte) error {
+	for i, name := range statusNames {
+		if name == string(text) {
+			*s = Status(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("status %q: %w", text, ErrInvalidOption)
+}
+
+// Diplomacy is the directed matrix of declared stances, keyed by the
+// declaring species and then by the species the stance is toward. A
+// species is neutral toward anyone it hasn't declared otherwise, and
+// declarations stay in effect from turn to turn until they are changed.
+type Diplomacy map[int]map[int]Status
+
+// Status returns the stance that a species has declared toward another.
+func (d Diplomacy) Status(species, other int) Status {
+	return d[species][other]
+}
+
+// declare records a species' stance toward another.
+func (g *Game) declare(species, other int, s Status) {
+	if g.Diplomacy == nil {
+		g.Diplomacy = make(Diplomacy)
+	}
+	if g.Diplomacy[species] == nil {
+		g.Diplomacy[species] = make(map[int]Status)
+	}
+	if s == Neutral {
+		delete(g.Diplomacy[species], other)
+		return
+	}
+	g.Diplomacy[species][other] = s
+}
+
+// Stance is a species' declared status toward another species.
+type Stance struct {
+	Species int
+	Status  Status
+}
+
+// Stances returns the status that a species has declared toward every
+// species it has met, in species order.
+func (g *Game) Stances(sp *Species) []Stance {
+	var stances []Stance
+	for _, id := range sp.Met {
+		stances = append(stances, Stance{Species: id, Status: g.Diplomacy.Status(sp.Id, id)})
+	}
+	sort.Slice(stances, func(i, j int) bool { return stances[i].Species < stances[j].Species })
+	return stances
+}
+
+// declaredEnemy returns true if a species has declared another to be an enemy.
+func (g *Game) declaredEnemy(species, other int) bool {
+	return g.Diplomacy.Status(species, other) == Enemy
+}
+
+// declaredAlly returns true if a species has declared another to be an ally.
+func (g *Game) declaredAlly(species, other int) bool {
+	return g.Diplomacy.Status(species, other) == Ally
+}
+
+// ally declares a species to be an ally. Only species that have been
+// met can be allies.
+func ally(t *Turn, sp *Species, cmd *orders.Command) error {
+	other := t.Game.SpeciesNamed(cmd.Args[0].Name)
+	if other == nil || other == sp {
+		return fmt.Errorf("SP %s: %w", cmd.Args[0].Name, ErrNoSuchSpecies)
+	} else if !sp.HasMet(other.Id) {
+		return fmt.Errorf("%s: %w", other, ErrNotMet)
+	}
+	t.Game.declare(sp.Id, other.Id, Ally)
+	return nil
+}
+
+// enemy declares a species to be an enemy. A number instead of a species
+// declares every other species in the game an enemy.
+func enemy(t *Turn, sp *Species, cmd *orders.Command) error {
+	return t.declareAll(sp, cmd, Enemy)
+}
+
+// neutral declares neutrality toward a species. A number instead of a
+// species declares neutrality toward every other species in the game.
+func neutral(t *Turn, sp *Species, cmd *orders.Command) error {
+	return t.declareAll(sp, cmd, Neutral)
+}
+
+// declareAll records a stance toward the species named in the order, or
+// toward every other species if the argument is a number.
+func (t *Turn) declareAll(sp *Species, cmd *orders.Command, s Status) error {
+	if cmd.Args[0].Kind == orders.Number {
+		for _, other := range t.Game.Species {
+			if other != sp {
+				t.Game.declare(sp.Id, other.Id, s)
+			}
+		}
+		return nil
+	}
+	other := t.Game.SpeciesNamed(cmd.Args[0].Name)
+	if other == nil || other == sp {
+		return fmt.Errorf("SP %s: %w", cmd.Args[0].Name, ErrNoSuchSpecies)
+	}
+	t.Game.declare(sp.Id, other.Id, s)
+	return nil
+}
+
+// applyBetrayals turns allies who attacked into enemies once a combat
+// phase is over. The victim declares the attacker an enemy, and so does
+// every species that counted both of them as allies.
+func applyBetrayals(t *Turn) {
+	for _, b := range t.betrayals {
+		attacker, victim := b[0], b[1]
+		for _, id := range t.SpeciesIds() {
+			if id == attacker || !t.Game.declaredAlly(id, attacker) {
+				continue
+			} else if id == victim || t.Game.declaredAlly(id, victim) {
+				t.Game.declare(id, attacker, Enemy)
+				t.Logf(id, 0, "%s betrayed %s and is now an enemy", t.Game.SpeciesById(attacker), t.Game.SpeciesById(victim))
+			}
+		}
+	}
+	t.betrayals = nil
+}
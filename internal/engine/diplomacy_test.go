@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestDiplomacy_Orders(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	sp := g.SpeciesById(1)
+	preDeparture := map[int]string{1: "START PRE-DEPARTURE\nALLY SP Klingon\nENEMY SP Nobody\nNEUTRAL SP Humanoid\nEND\n"}
+
+	// allies have to be met first
+	runStep(t, g, engine.DefaultSteps()[1], preDeparture)
+	if errs := orderErrors(g); len(errs) != 3 || !strings.Contains(errs[0], "species not met") || !strings.Contains(errs[1], "no such species") || !strings.Contains(errs[2], "no such species") {
+		t.Errorf("diplomacy: log: expected 3 errors: got %q\n", errs)
+	}
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{1: "START PRE-DEPARTURE\nALLY SP Klingon\nEND\n"})
+	if got := g.Diplomacy.Status(1, 2); got != engine.Ally {
+		t.Errorf("ally: expected ally: got %s\n", got)
+	} else if got = g.Diplomacy.Status(2, 1); got != engine.Neutral {
+		t.Errorf("ally: expected the Klingons to stay neutral: got %s\n", got)
+	}
+
+	// declarations last until they are changed
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nEND\n"})
+	if got := g.Diplomacy.Status(1, 2); got != engine.Ally {
+		t.Errorf("ally: next turn: expected ally: got %s\n", got)
+	}
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nENEMY 0\nEND\n"})
+	if stances := g.Stances(sp); len(stances) != 1 || stances[0] != (engine.Stance{Species: 2, Status: engine.Enemy}) {
+		t.Errorf("enemy: expected the Klingons to be enemies: got %v\n", stances)
+	}
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nNEUTRAL SP Klingon\nEND\n"})
+	if got := g.Diplomacy.Status(1, 2); got != engine.Neutral {
+		t.Errorf("neutral: expected neutral: got %s\n", got)
+	}
+}
+
+func TestDiplomacy_Enemies(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000},
+	)
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	battle := fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK 0\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z)
+
+	// ATTACK 0 only attacks declared enemies
+	fightTurn(t, g, map[int]string{2: battle})
+	if len(g.Battles) != 0 {
+		t.Fatalf("enemies: expected no battle: got %d\n", len(g.Battles))
+	}
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{2: "START PRE-DEPARTURE\nENEMY SP Humanoid\nEND\n"})
+	fightTurn(t, g, map[int]string{2: battle})
+	if len(g.Battles) != 1 {
+		t.Fatalf("enemies: expected 1 battle: got %d\n", len(g.Battles))
+	}
+}
+
+func TestDiplomacy_Allies(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000},
+		&engine.Ship{Species: 3, Class: "BS", Name: "Enterprise", Tonnage: 500_000},
+	)
+	if _, err := g.AddSpecies(engine.SpeciesSetup{Name: "Vulcanian", HomePlanet: "ShiKahr", Government: "Science Academy", GovernmentType: "Republic", ML: 10, GV: 1, LS: 4, BI: 0}); err != nil {
+		t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+	}
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{
+		1: "START PRE-DEPARTURE\nALLY SP Vulcanian\nEND\n",
+		3: "START PRE-DEPARTURE\nALLY SP Humanoid\nEND\n",
+	})
+
+	// the Vulcans fight on the side of their ally
+	fightTurn(t, g, map[int]string{2: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP Humanoid\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z)})
+	if len(g.Battles) != 1 {
+		t.Fatalf("allies: expected 1 battle: got %d\n", len(g.Battles))
+	} else if b := g.Battles[0]; len(b.Species) != 3 {
+		t.Errorf("allies: expected 3 species in the battle: got %v\n", b.Species)
+	}
+
+	// an ally that attacks takes its victim by surprise and is
+	// then an enemy of the victim and of the victim's allies
+	fightTurn(t, g, map[int]string{3: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP Humanoid\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z)})
+	if len(g.Battles) != 1 {
+		t.Fatalf("betrayal: expected 1 battle: got %d\n", len(g.Battles))
+	} else if log := strings.Join(g.Battles[0].LogFor(3), "\n"); !strings.Contains(log, "Surprise attack") {
+		t.Errorf("betrayal: expected a surprise attack: got %q\n", log)
+	}
+	if got := g.Diplomacy.Status(1, 3); got != engine.Enemy {
+		t.Errorf("betrayal: expected the Vulcans to be enemies: got %s\n", got)
+	}
+}
+
+func TestDiplomacy_Teach(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	sp, klingon := g.SpeciesById(1), g.SpeciesById(2)
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	sp.Tech[engine.GV], sp.Treasury = 20, 100
+
+	// allies can be taught, enemies can't be sent or taught anything
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nALLY SP Klingon\nTEACH GV SP Klingon\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("teach: log: expected no errors: got %q\n", errs)
+	}
+	if klingon.Knowledge[engine.GV] != 20 {
+		t.Errorf("teach: expected knowledge of 20: got %d\n", klingon.Knowledge[engine.GV])
+	}
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nENEMY SP Klingon\nSEND 10 SP Klingon\nTEACH GV SP Klingon\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "not allowed here") || !strings.Contains(errs[1], "declared enemy") {
+		t.Errorf("enemy: log: expected 2 errors: got %q\n", errs)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+// Errors used by the package.
+const (
+	ErrAlreadyMoved           = constError("already moved this turn")
+	ErrAlreadyUsed            = constError("already used this turn")
+	ErrAlreadyNamed           = constError("planet already named")
+	ErrDeclaredEnemy          = constError("declared enemy")
+	ErrDetected               = constError("detected and destroyed by besiegers")
+	ErrDuplicateName          = constError("duplicate name")
+	ErrDuplicateProduction    = constError("duplicate production order for planet")
+	ErrDuplicateShipyard      = constError("duplicate shipyard order for planet")
+	ErrInsufficientCapacity   = constError("insufficient cargo capacity")
+	ErrInsufficientFunds      = constError("insufficient funds")
+	ErrInsufficientItems      = constError("insufficient items")
+	ErrInsufficientPopulation = constError("insufficient available population")
+	ErrInvalidAmount          = constError("invalid amount")
+	ErrInvalidClass           = constError("invalid class")
+	ErrInvalidCoords          = constError("invalid coordinates")
+	ErrInvalidName            = constError("invalid name")
+	ErrInvalidOption          = constError("invalid option")
+	ErrInvalidPlayerCount     = constError("invalid player count")
+	ErrInvalidTech            = constError("invalid tech")
+	ErrInvalidTechPoints      = constError("invalid tech points")
+	ErrNoBattle               = constError("missing BATTLE order")
+	ErrNoForces               = constError("no ships or colonies at location")
+	ErrNoHomeSystem           = constError("no home system available")
+	ErrNoJumpDrive            = constError("no jump drive")
+	ErrNoProduction           = constError("missing PRODUCTION order")
+	ErrNoSuchPlanet           = constError("no such planet")
+	ErrNoSuchShip             = constError("no such ship")
+	ErrNoSuchSpecies          = constError("no such species")
+	ErrNoSuchStar             = constError("no star at location")
+	ErrNoWormhole             = constError("no natural wormhole here")
+	ErrNotAdjacent            = constError("not an adjacent sector")
+	ErrNotAllowed             = constError("not allowed here")
+	ErrNotBuildable           = constError("item can not be built")
+	ErrNotColonized           = constError("planet not colonized")
+	ErrNotHere                = constError("not at this location")
+	ErrNotMet                 = constError("species not met")
+	ErrNotUnderConstruction   = constError("not under construction")
+	ErrNotVisited             = constError("star system not visited")
+	ErrPortalTooSmall         = constError("jump portal too small for ship")
+	ErrShipyardCapacity       = constError("shipyard capacity exceeded")
+	ErrSubLightOnly           = constError("only sub-light ships can be built without gravitics")
+	ErrTechTooLow             = constError("tech level too low")
+	ErrTonnageLimit           = constError("tonnage exceeds manufacturing limit")
+	ErrTooManyStars           = constError("too many stars")
+	ErrUnderConstruction      = constError("under construction")
+)
+
+// declarations to support constant errors
+type constError string
+
+func (ce constError) Error() string {
+	return string(ce)
+}
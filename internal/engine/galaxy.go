@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"math"
+	"sort"
+)
+
+// The size of the galaxy is scaled from the "standard" game described in
+// the manual: about 80 usable star systems in a cluster with a radius of
+// about 18 parsecs for 15 players.
+const (
+	standardNumberOfSpecies = 15
+	standardNumberOfStars   = 80
+	standardRadius          = 18
+
+	// starsPerWormhole is roughly how many stars there are for each
+	// natural wormhole.
+	starsPerWormhole = 20
+)
+
+// Galaxy is a small, open star cluster.
+// It is approximately spherical, and it is projected onto a box whose
+// lower, left, rear corner is at 0,0,0.
+type Galaxy struct {
+	Radius int     `json:"radius"`
+	Stars  []*Star `json:"stars"`
+}
+
+// NewGalaxy returns a new galaxy sized for the number of players.
+// The same player count and seed will always produce the same galaxy.
+func NewGalaxy(players int, seed int64) (*Galaxy, error) {
+	if players < 1 {
+		return nil, ErrInvalidPlayerCount
+	}
+	r := NewRNG(uint64(seed))
+
+	// the number of stars scales with the number of players and the
+	// volume of the cluster scales with the number of stars.
+	numStars := (players*standardNumberOfStars + standardNumberOfSpecies/2) / standardNumberOfSpecies
+	if numStars < players {
+		numStars = players
+	}
+	radius := int(math.Round(standardRadius * math.Cbrt(float64(numStars)/standardNumberOfStars)))
+	if radius < 3 {
+		radius = 3
+	}
+
+	// the star map shows one star per X,Y location, so the projection of
+	// the cluster onto the X,Y plane limits the number of stars it can hold.
+	if float64(numStars) > math.Pi*float64(radius*radius)/2 {
+		return nil, ErrTooManyStars
+	}
+
+	g := &Galaxy{Radius: radius}
+
+	// place stars randomly inside the sphere, centered at radius,radius,radius
+	// so that all the coordinates are zero or greater.
+	var locations []Coords
+	used := make(map[[2]int]bool)
+	for len(locations) < numStars {
+		c := Coords{X: r.Intn(2*radius + 1), Y: r.Intn(2*radius + 1), Z: r.Intn(2*radius + 1)}
+		dx, dy, dz := c.X-radius, c.Y-radius, c.Z-radius
+		if dx*dx+dy*dy+dz*dz > radius*radius {
+			continue
+		} else if used[[2]int{c.X, c.Y}] {
+			continue
+		}
+		used[[2]int{c.X, c.Y}] = true
+		locations = append(locations, c)
+	}
+
+	// number the stars and planets in map order
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.X != b.X {
+			return a.X < b.X
+		} else if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+	planetId := 0
+	for i, c := range locations {
+		star := generateStar(r, c)
+		star.Id = i + 1
+		for _, planet := range star.Planets {
+			planetId++
+			planet.Id = planetId
+		}
+		g.Stars = append(g.Stars, star)
+	}
+
+	// natural wormholes connect pairs of stars. each star is the terminus
+	// of at most one wormhole.
+	for i := 0; i < numStars/starsPerWormhole; i++ {
+		a, b := g.Stars[r.Intn(numStars)], g.Stars[r.Intn(numStars)]
+		if a == b || a.Wormhole != 0 || b.Wormhole != 0 {
+			continue
+		}
+		a.Wormhole, b.Wormhole = b.Id, a.Id
+	}
+
+	return g, nil
+}
+
+// Contains returns true if the coordinates are inside the box that the
+// galaxy is projected onto.
+func (g *Galaxy) Contains(c Coords) bool {
+	size := 2 * g.Radius
+	return 0 <= c.X && c.X <= size && 0 <= c.Y && c.Y <= size && 0 <= c.Z && c.Z <= size
+}
+
+// Planet returns the planet with the given id or nil if there is no such planet.
+func (g *Galaxy) Planet(id int) *Planet {
+	for _, star := range g.Stars {
+		for _, planet := range star.Planets {
+			if planet.Id == id {
+				return planet
+			}
+		}
+	}
+	return nil
+}
+
+// Star returns the star with the given id or nil if there is no such star.
+func (g *Galaxy) Star(id int) *Star {
+	for _, star := range g.Stars {
+		if star.Id == id {
+			return star
+		}
+	}
+	return nil
+}
+
+// StarAt returns the star at the given coordinates or nil if the sector is empty.
+func (g *Galaxy) StarAt(c Coords) *Star {
+	for _, star := range g.Stars {
+		if star.Coords == c {
+			return star
+		}
+	}
+	return nil
+}
+
+// StarOf returns the star system that the planet is in.
+func (g *Galaxy) StarOf(planetId int) *Star {
+	for _, star := range g.Stars {
+		for _, planet := range star.Planets {
+			if planet.Id == planetId {
+				return star
+			}
+		}
+	}
+	return nil
+}
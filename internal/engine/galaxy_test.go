@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/engine"
+	"reflect"
+	"testing"
+)
+
+func TestNewGalaxy(t *testing.T) {
+	g, err := engine.NewGalaxy(15, 1)
+	if err != nil {
+		t.Fatalf("NewGalaxy: err: expected nil: got %v\n", err)
+	} else if g.Radius != 18 {
+		t.Errorf("NewGalaxy: radius: expected 18: got %d\n", g.Radius)
+	} else if len(g.Stars) != 80 {
+		t.Errorf("NewGalaxy: stars: expected 80: got %d\n", len(g.Stars))
+	}
+
+	seen := make(map[[2]int]bool)
+	for _, star := range g.Stars {
+		c := star.Coords
+		if c.X < 0 || c.Y < 0 || c.Z < 0 {
+			t.Errorf("star %d: coords: expected non-negative: got %v\n", star.Id, c)
+		}
+		dx, dy, dz := c.X-g.Radius, c.Y-g.Radius, c.Z-g.Radius
+		if dx*dx+dy*dy+dz*dz > g.Radius*g.Radius {
+			t.Errorf("star %d: coords: expected inside cluster: got %v\n", star.Id, c)
+		}
+		if seen[[2]int{c.X, c.Y}] {
+			t.Errorf("star %d: coords: expected unique x,y: got %v\n", star.Id, c)
+		}
+		seen[[2]int{c.X, c.Y}] = true
+	}
+
+	if _, err := engine.NewGalaxy(0, 1); !errors.Is(err, engine.ErrInvalidPlayerCount) {
+		t.Errorf("NewGalaxy: err: expected ErrInvalidPlayerCount: got %v\n", err)
+	}
+}
+
+func TestNewGalaxy_Seed(t *testing.T) {
+	a, _ := engine.NewGalaxy(7, 42)
+	b, _ := engine.NewGalaxy(7, 42)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("NewGalaxy: same seed: expected identical galaxies\n")
+	}
+	c, _ := engine.NewGalaxy(7, 43)
+	if reflect.DeepEqual(a, c) {
+		t.Errorf("NewGalaxy: different seed: expected different galaxies\n")
+	}
+}
+
+func TestNewGalaxy_Planets(t *testing.T) {
+	g, err := engine.NewGalaxy(15, 1)
+	if err != nil {
+		t.Fatalf("NewGalaxy: err: expected nil: got %v\n", err)
+	}
+
+	var inner, outer, innerCount, outerCount int
+	for _, star := range g.Stars {
+		if len(star.Planets) < 1 || len(star.Planets) > 9 {
+			t.Errorf("star %d: planets: expected 1..9: got %d\n", star.Id, len(star.Planets))
+		}
+		for i, planet := range star.Planets {
+			if planet.Orbit != i+1 {
+				t.Errorf("star %d: planet %d: orbit: expected %d: got %d\n", star.Id, planet.Id, i+1, planet.Orbit)
+			}
+			if planet.IsGasGiant() && planet.PressureClass < 16 {
+				t.Errorf("star %d: planet %d: gas giant: pressure: expected >= 16: got %d\n", star.Id, planet.Id, planet.PressureClass)
+			}
+			if planet.TemperatureClass < engine.MinTemperatureClass || planet.TemperatureClass > engine.MaxTemperatureClass {
+				t.Errorf("star %d: planet %d: temperature: got %d\n", star.Id, planet.Id, planet.TemperatureClass)
+			}
+			if planet.PressureClass == 0 && len(planet.Atmosphere) != 0 {
+				t.Errorf("star %d: planet %d: vacuum: expected no atmosphere: got %v\n", star.Id, planet.Id, planet.Atmosphere)
+			} else if planet.PressureClass > 0 {
+				total := 0
+				for _, c := range planet.Atmosphere {
+					total += c.Percent
+				}
+				if total != 100 {
+					t.Errorf("star %d: planet %d: atmosphere: expected 100%%: got %d%%\n", star.Id, planet.Id, total)
+				}
+			}
+			if planet.Orbit == 1 {
+				inner, innerCount = inner+planet.TemperatureClass, innerCount+1
+			} else if planet.Orbit >= 5 {
+				outer, outerCount = outer+planet.TemperatureClass, outerCount+1
+			}
+		}
+	}
+	if inner*outerCount <= outer*innerCount {
+		t.Errorf("planets: expected inner planets to be hotter than outer planets\n")
+	}
+}
+
+func TestStar_SpectralClass(t *testing.T) {
+	for _, tc := range []struct {
+		star   engine.Star
+		expect string
+	}{
+		{engine.Star{Type: engine.MainSequence, Color: engine.Blue, Size: 8}, "O8"},
+		{engine.Star{Type: engine.Dwarf, Color: engine.YellowWhite, Size: 1}, "dF1"},
+		{engine.Star{Type: engine.Degenerate, Color: engine.White, Size: 5}, "DA5"},
+		{engine.Star{Type: engine.Giant, Color: engine.Orange, Size: 7}, "gK7"},
+	} {
+		if got := tc.star.SpectralClass(); got != tc.expect {
+			t.Errorf("SpectralClass: expected %q: got %q\n", tc.expect, got)
+		}
+	}
+}
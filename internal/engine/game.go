@@ -0,0 +1,202 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Game is the state of a game at the start of a turn.
+// Objects refer to each other by id rather than by pointer so that the
+// state can be saved and loaded without losing anything.
+type Game struct {
+	Id           string         `json:"id"`
+	Turn         int            `json:"turn"`
+	Seed         int64          `json:"seed"`      // seed for the game's random numbers
+	TurnSeed     uint64         `json:"turn_seed"` // seed used to run the last turn
+	LastTurn     int            `json:"last_turn"` // secret final turn, which the GM may extend
+	Galaxy       *Galaxy        `json:"galaxy"`
+	Species      []*Species     `json:"species,omitempty"`
+	Colonies     []*Colony      `json:"colonies,omitempty"`
+	Ships        []*Ship        `json:"ships,omitempty"`
+	Transactions []*Transaction `json:"transactions,omitempty"` // pending interspecies transactions
+	Ledgers      []*Ledger      `json:"ledgers,omitempty"`      // production for the last turn
+	Sieges       []*Siege       `json:"sieges,omitempty"`       // sieges from the last combat phase
+	Ambushes     []*Ambush      `json:"ambushes,omitempty"`     // ambushes prepared in the last production phase
+	Diplomacy    Diplomacy      `json:"diplomacy,omitempty"`    // declared stances, which last until changed
+	Log          []*LogEntry    `json:"log,omitempty"`          // results of the last turn
+	Battles      []*Battle      `json:"battles,omitempty"`      // combat logs for the last turn
+	Scans        []*Scan        `json:"scans,omitempty"`        // scans made during the last turn
+	Observations []*Observation `json:"observations,omitempty"` // gravitic telescope observations for the last turn
+	Estimates    []*Estimate    `json:"estimates,omitempty"`    // tech estimates made during the last turn
+	Audit        []*AuditEntry  `json:"audit,omitempty"`        // treasury changes during the last turn
+}
+
+// NewGame returns a new game with a galaxy sized for the number of players.
+// No species are in the game until they are added from their setup forms.
+//
+// The seed determines everything random in the game: the galaxy, the
+// final turn, and the results of every turn.
+func NewGame(id string, players int, seed int64) (*Game, error) {
+	galaxy, err := NewGalaxy(players, seed)
+	if err != nil {
+		return nil, err
+	}
+	// the manual says that a game lasts between 20 and 100 turns.
+	lastTurn := 20 + NewRNG(TurnSeed(seed, -1)).Intn(81)
+	return &Game{Id: id, Seed: seed, LastTurn: lastTurn, Galaxy: galaxy}, nil
+}
+
+// Colony is a species' population on a planet, including its home planet.
+// Each species names planets for itself, so the name belongs to the colony.
+// Mining and manufacturing bases are stored in tenths.
+type Colony struct {
+	Id                  int            `json:"id"`
+	Species             int            `json:"species"`
+	Planet              int            `json:"planet"`
+	Name                string         `json:"name"`
+	IsHome              bool           `json:"home,omitempty"`
+	MiningBase          int            `json:"mi_base"`
+	ManufacturingBase   int            `json:"ma_base"`
+	Shipyards           int            `json:"shipyards,omitempty"`
+	AvailablePopulation int            `json:"avail_pop,omitempty"`
+	Inventory           map[string]int `json:"inventory,omitempty"`
+	Hidden              bool           `json:"hidden,omitempty"`    // hidden from aliens until the end of the turn
+	Disbanded           bool           `json:"disbanded,omitempty"` // salvaged and removed at the end of the turn
+}
+
+// Transaction is a transfer between two species.
+// Transactions are created while orders are processed and are settled
+// during housekeeping at the end of the turn.
+type Transaction struct {
+	Kind   string `json:"kind"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Amount int    `json:"amount,omitempty"`
+	Item   string `json:"item,omitempty"`
+}
+
+// String implements the Stringer interface.
+func (tx *Transaction) String() string {
+	if tx.Item == "" {
+		return fmt.Sprintf("%s %d", tx.Kind, tx.Amount)
+	}
+	return fmt.Sprintf("%s %d %s", tx.Kind, tx.Amount, tx.Item)
+}
+
+// LogEntry is a line in the turn log.
+// Entries for a species are copied into that species' status report.
+type LogEntry struct {
+	Species int    `json:"species"`
+	Phase   string `json:"phase"`
+	Line    int    `json:"line,omitempty"` // line in the order file, if the entry is for an order
+	Text    string `json:"text"`
+}
+
+// SpeciesById returns the species with the given id or nil if there is no such species.
+func (g *Game) SpeciesById(id int) *Species {
+	for _, sp := range g.Species {
+		if sp.Id == id {
+			return sp
+		}
+	}
+	return nil
+}
+
+// SpeciesNamed returns the species with the given name or nil if there is
+// no such species. Case is not significant.
+func (g *Game) SpeciesNamed(name string) *Species {
+	for _, sp := range g.Species {
+		if strings.EqualFold(sp.Name, name) {
+			return sp
+		}
+	}
+	return nil
+}
+
+// ColonyNamed returns the species' colony with the given name or nil if
+// the species doesn't have one. Case is not significant.
+func (g *Game) ColonyNamed(species int, name string) *Colony {
+	for _, colony := range g.Colonies {
+		if colony.Species == species && strings.EqualFold(colony.Name, name) {
+			return colony
+		}
+	}
+	return nil
+}
+
+// ShipNamed returns the species' ship or starbase with the given name or nil
+// if the species doesn't have one. Case is not significant, and names are
+// unique across all classes.
+func (g *Game) ShipNamed(species int, name string) *Ship {
+	for _, ship := range g.Ships {
+		if ship.Species == species && strings.EqualFold(ship.Name, name) {
+			return ship
+		}
+	}
+	return nil
+}
+
+// ColonyById returns the colony with the given id or nil if there is no such colony.
+func (g *Game) ColonyById(id int) *Colony {
+	for _, colony := range g.Colonies {
+		if colony.Id == id {
+			return colony
+		}
+	}
+	return nil
+}
+
+// colonyOn returns the species' colony on the planet or nil if the species
+// doesn't have one there.
+func (g *Game) colonyOn(species, planet int) *Colony {
+	for _, colony := range g.Colonies {
+		if colony.Species == species && colony.Planet == planet {
+			return colony
+		}
+	}
+	return nil
+}
+
+// nextColonyId returns the id for a new colony.
+func (g *Game) nextColonyId() int {
+	id := 1
+	for _, colony := range g.Colonies {
+		if colony.Id >= id {
+			id = colony.Id + 1
+		}
+	}
+	return id
+}
+
+// nextShipId returns the id for a new ship.
+func (g *Game) nextShipId() int {
+	id := 1
+	for _, ship := range g.Ships {
+		if ship.Id >= id {
+			id = ship.Id + 1
+		}
+	}
+	return id
+}
+
+// removeColony removes a colony from the game.
+func (g *Game) removeColony(c *Colony) {
+	for i, colony := range g.Colonies {
+		if colony == c {
+			g.Colonies = append(g.Colonies[:i], g.Colonies[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeShip removes a ship from the game.
+func (g *Game) removeShip(s *Ship) {
+	for i, ship := range g.Ships {
+		if ship == s {
+			g.Ships = append(g.Ships[:i], g.Ships[i+1:]...)
+			return
+		}
+	}
+}
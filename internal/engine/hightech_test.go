@@ -0,0 +1,241 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestAuxiliaryUnits(t *testing.T) {
+	g, _, _ := newTestGame(t)
+	ca := &engine.Ship{Species: 1, Class: "CA", Name: "Cruiser", Tonnage: 300_000}
+	tr := &engine.Ship{Species: 1, Class: "TR30", Name: "Hauler", Tonnage: 300_000}
+	offense, defense := g.Firepower(ca)
+	trOffense, trDefense := g.Firepower(tr)
+
+	// guns and shields add the power of a ship of their tonnage to warships
+	ca.Cargo, tr.Cargo = map[string]int{"GU3": 2, "SG3": 2}, map[string]int{"GU3": 2, "SG3": 2}
+	dd, _ := g.Firepower(&engine.Ship{Species: 1, Class: "DD", Name: "Destroyer", Tonnage: 150_000})
+	if o, d := g.Firepower(ca); o-offense < 1.99*dd || o-offense > 2.01*dd || d-defense < 1.99*dd || d-defense > 2.01*dd {
+		t.Errorf("aux: expected the power of two destroyers to be added: got %.2f to %.2f and %.2f to %.2f\n", offense, o, defense, d)
+	}
+	// but only to warships
+	if o, d := g.Firepower(tr); o != trOffense || d != trDefense {
+		t.Errorf("aux: transport: expected %.2f and %.2f: got %.2f and %.2f\n", trOffense, trDefense, o, d)
+	}
+}
+
+func TestJumpPortal(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "BAS", Name: "Seneca Portal", Tonnage: 100_000, Cargo: map[string]int{"JP": 10}},
+		&engine.Ship{Species: 1, Class: "TR4", SubLight: true, Name: "Willow's Helm", Tonnage: 40_000},
+		&engine.Ship{Species: 2, Class: "CT", SubLight: true, Name: "Hippocrates", Tonnage: 20_000},
+		&engine.Ship{Species: 2, Class: "CA", SubLight: true, Name: "Big One", Tonnage: 300_000},
+	)
+	g.SpeciesById(1).Tech[engine.GV] = 200       // no chance of a mishap
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	dest := coords
+	if dest.X < g.Galaxy.Radius {
+		dest.X++
+	} else {
+		dest.X--
+	}
+	jumps := map[int]string{
+		1: fmt.Sprintf("START JUMPS\nPJUMP TR4S Willow's Helm, %d %d %d, BAS Seneca Portal\nEND\n", dest.X, dest.Y, dest.Z),
+		2: fmt.Sprintf("START JUMPS\nPJUMP CTS Hippocrates, %d %d %d, BAS Seneca Portal\nEND\n", dest.X, dest.Y, dest.Z),
+	}
+
+	// sub-light ships can jump through their own portals, but only allies
+	// can use someone else's
+	runStep(t, g, engine.DefaultSteps()[2], jumps)
+	if ship := g.ShipNamed(1, "Willow's Helm"); ship.Coords != dest {
+		t.Errorf("pjump: expected the transport at %s: got %s\n", dest, ship.Coords)
+	}
+	if errs := strings.Join(orderErrors(g), "\n"); !strings.Contains(errs, "Hippocrates") || !strings.Contains(errs, "no such ship") {
+		t.Errorf("pjump: log: expected no such ship: got %q\n", errs)
+	}
+	g.Diplomacy = engine.Diplomacy{1: {2: engine.Ally}}
+	jumps[2] = fmt.Sprintf("START JUMPS\nPJUMP CTS Hippocrates, %d %d %d, BAS Seneca Portal\nPJUMP CAS Big One, %d %d %d, BAS Seneca Portal\nEND\n", dest.X, dest.Y, dest.Z, dest.X, dest.Y, dest.Z)
+	runStep(t, g, engine.DefaultSteps()[2], map[int]string{2: jumps[2]})
+	if ship := g.ShipNamed(2, "Hippocrates"); ship == nil || ship.Coords == coords {
+		t.Errorf("pjump: expected the ally's corvette to jump: got %+v\n", ship)
+	}
+	if errs := strings.Join(orderErrors(g), "\n"); !strings.Contains(errs, "Big One") || !strings.Contains(errs, "jump portal too small") {
+		t.Errorf("pjump: log: expected the portal to be too small: got %q\n", errs)
+	}
+}
+
+func TestForcedJump(t *testing.T) {
+	for _, code := range []string{"FJ", "FM"} {
+		g, coords := newBattleGame(t,
+			&engine.Ship{Species: 1, Class: "BAS", Name: "Bouncer", Tonnage: 500_000, Cargo: map[string]int{code: 50}},
+			&engine.Ship{Species: 2, Class: "BS", Name: "Intruder", Tonnage: 450_000},
+		)
+		g.SpeciesById(1).Tech[engine.GV] = 50
+		fightTurn(t, g, map[int]string{2: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP Humanoid\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z)})
+		ship := g.ShipNamed(2, "Intruder")
+		if ship == nil || ship.Forced != code {
+			t.Fatalf("%s: expected the battleship to be forced to jump: got %+v\n", code, ship)
+		}
+
+		// the ship jumps in the next jump phase, whatever its orders, and
+		// may not survive a mis-jump
+		runStep(t, g, engine.DefaultSteps()[2], map[int]string{2: "START JUMPS\nMOVE BS Intruder, 1 1 1\nEND\n"})
+		if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "already moved") && !strings.Contains(errs[0], "no such ship") {
+			t.Errorf("%s: log: expected the order to be ignored: got %q\n", code, errs)
+		}
+		if ship = g.ShipNamed(2, "Intruder"); ship != nil && (ship.Coords == coords || ship.Forced != "") {
+			t.Errorf("%s: expected the battleship to be gone: got %+v\n", code, ship)
+		} else if ship == nil && code == "FJ" {
+			t.Errorf("%s: expected the battleship to survive a forced jump\n", code)
+		}
+	}
+
+	// a forced mis-jump goes anywhere from 0 0 0 to 99 99 99, well
+	// outside the galaxy's sectors
+	g, coords := newBattleGame(t, &engine.Ship{Species: 2, Class: "BS", Name: "Intruder", Tonnage: 450_000, Cargo: map[string]int{"FS": 100}})
+	g.SpeciesById(2).Tech[engine.GV] = 200
+	outside := 0
+	for turn := 0; turn < 10; turn++ {
+		ship := g.ShipNamed(2, "Intruder")
+		ship.Coords, ship.Forced = coords, "FM"
+		runStep(t, g, engine.DefaultSteps()[2], nil)
+		if ship = g.ShipNamed(2, "Intruder"); ship == nil {
+			t.Fatalf("FM: expected the fail-safe units to save the battleship\n")
+		}
+		c := ship.Coords
+		if c == coords || c.X < 0 || c.X > 99 || c.Y < 0 || c.Y > 99 || c.Z < 0 || c.Z > 99 {
+			t.Errorf("FM: expected coordinates from 0 to 99: got %s\n", c)
+		}
+		if size := 2*g.Galaxy.Radius + 1; c.X >= size || c.Y >= size || c.Z >= size {
+			outside++
+		}
+	}
+	if outside == 0 {
+		t.Errorf("FM: expected mis-jumps outside the galaxy's sectors\n")
+	}
+}
+
+func TestFieldDistortion(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000, Cargo: map[string]int{"FD": 30}},
+	)
+	klingon := g.SpeciesById(2)
+	if ship := g.ShipNamed(2, "Bird of Prey"); !ship.IsDistorted() {
+		t.Fatalf("distortion: expected exactly 30 units to work\n")
+	} else if ship.Cargo["FD"] = 29; ship.IsDistorted() {
+		t.Errorf("distortion: expected 29 units not to work\n")
+	} else {
+		ship.Cargo["FD"] = 30
+	}
+
+	// the others only see a number for the species, which can be attacked
+	alias := g.Pseudonym(klingon)
+	fightTurn(t, g, map[int]string{1: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP %d\nENGAGE 3\nEND\n", coords.X, coords.Y, coords.Z, alias)})
+	if len(g.Battles) != 1 {
+		t.Fatalf("distortion: expected 1 battle: got %d\n", len(g.Battles))
+	}
+	log := strings.Join(g.Battles[0].LogFor(1), "\n")
+	if !strings.Contains(log, fmt.Sprintf("SP Humanoid attacks SP %d", alias)) || !strings.Contains(log, fmt.Sprintf("SP %d CA ???", alias)) {
+		t.Errorf("distortion: expected the cruiser to be disguised: got %q\n", log)
+	}
+	if i, j := strings.Index(log, "Klingon"), strings.Index(log, "is revealed to be"); i != -1 && (j == -1 || i < j) {
+		t.Errorf("distortion: expected the Klingons to be hidden until revealed: got %q\n", log)
+	}
+}
+
+func TestTelescope(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "BAS", Name: "Peeping Tom", Tonnage: 500_000, Cargo: map[string]int{"GT": 7}},
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+	)
+	sp := g.SpeciesById(1)
+	sp.Tech[engine.GV] = 95
+	near, far, ghost := coords, coords, coords
+	near.X, far.X, ghost.Y = coords.X+3, coords.X+4, coords.Y+1
+	if coords.X > g.Galaxy.Radius {
+		near.X, far.X = coords.X-3, coords.X-4
+	}
+	if coords.Y > g.Galaxy.Radius {
+		ghost.Y = coords.Y - 1
+	}
+	g.Ships = append(g.Ships,
+		&engine.Ship{Id: 10, Species: 2, Class: "BS", Name: "Near", Tonnage: 450_000, Coords: near, Status: engine.DeepSpace},
+		&engine.Ship{Id: 11, Species: 2, Class: "BS", Name: "Far", Tonnage: 450_000, Coords: far, Status: engine.DeepSpace},
+		&engine.Ship{Id: 12, Species: 2, Class: "BS", Name: "Ghost", Tonnage: 450_000, Coords: ghost, Status: engine.DeepSpace, Cargo: map[string]int{"FD": 45}},
+	)
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nTELESCOPE BAS Peeping Tom\nTELESCOPE BAS Peeping Tom\nTELESCOPE TR1 Hauler\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "already used") || !strings.Contains(errs[1], "no gravitic telescope") {
+		t.Errorf("telescope: log: expected 2 errors: got %q\n", errs)
+	}
+	if len(g.Observations) != 1 {
+		t.Fatalf("telescope: expected 1 observation: got %d\n", len(g.Observations))
+	}
+	// seven units reach three parsecs, field distorted ships can't be seen,
+	// and large ships are always seen at high gravitics
+	obs := g.Observations[0]
+	if obs.Range != 3 {
+		t.Errorf("telescope: expected a range of 3: got %d\n", obs.Range)
+	}
+	var names []string
+	for _, s := range obs.Sightings {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ", "); got != "BS Near" {
+		t.Errorf("telescope: expected BS Near: got %q\n", got)
+	}
+}
+
+func TestGermWarfare(t *testing.T) {
+	g, coords := newBattleGame(t, &engine.Ship{Species: 2, Class: "CA", Name: "Plague Ship", Tonnage: 300_000, Cargo: map[string]int{"GW": 20}})
+	g.SpeciesById(2).Tech[engine.BI] = 50
+	earth := g.ColonyNamed(1, "Earth")
+	orbit := g.Galaxy.Planet(earth.Planet).Orbit
+	fightTurn(t, g, map[int]string{
+		2: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP Humanoid\nENGAGE 6 %d\nEND\n", coords.X, coords.Y, coords.Z, orbit),
+	})
+	if earth.MiningBase != 0 || earth.ManufacturingBase != 0 {
+		t.Errorf("germ warfare: expected Earth to be wiped out: got %d and %d\n", earth.MiningBase, earth.ManufacturingBase)
+	}
+	if ship := g.ShipNamed(2, "Plague Ship"); ship.Cargo["GW"] != 0 {
+		t.Errorf("germ warfare: expected the bombs to be used: got %d\n", ship.Cargo["GW"])
+	}
+}
+
+func TestRepair(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "BC", Name: "Big Bend", Tonnage: 400_000, Age: 20, Cargo: map[string]int{"DR": 25}},
+		&engine.Ship{Species: 1, Class: "BAS", Name: "Strong Arm", Tonnage: 90_000, Age: 40, Cargo: map[string]int{"DR": 17}},
+		&engine.Ship{Species: 1, Class: "FF", Name: "Gorby Too", Tonnage: 100_000, Age: 30, Cargo: map[string]int{"DR": 5}},
+		&engine.Ship{Species: 1, Class: "DD", Name: "Dagger", Tonnage: 150_000, Age: 10, Cargo: map[string]int{"DR": 5}},
+	)
+	// the examples from the manual
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{1: `START PRE-DEPARTURE
+	REPAIR	BC Big Bend, 25
+	REPAIR	BAS Strong Arm, 17
+	REPAIR	FF Gorby Too, 0
+	REPAIR	DD Dagger
+END
+`})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "insufficient items") {
+		t.Errorf("repair: log: expected the destroyer to be short: got %q\n", errs)
+	}
+	for name, age := range map[string]int{"Big Bend": 10, "Strong Arm": 10, "Gorby Too": 22, "Dagger": 10} {
+		if ship := g.ShipNamed(1, name); ship.Age != age {
+			t.Errorf("repair: %s: expected age %d: got %d\n", name, age, ship.Age)
+		}
+	}
+
+	// the ships in a sector pool their units for the oldest ships first
+	g.ShipNamed(1, "Big Bend").Cargo = map[string]int{"DR": 12}
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: fmt.Sprintf("START POST-ARRIVAL\nREPAIR %d %d %d 7\nEND\n", coords.X, coords.Y, coords.Z)})
+	for name, age := range map[string]int{"Big Bend": 8, "Strong Arm": 10, "Gorby Too": 7, "Dagger": 10} {
+		if ship := g.ShipNamed(1, name); ship.Age != age {
+			t.Errorf("repair: pool: %s: expected age %d: got %d\n", name, age, ship.Age)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+)
+
+// housekeeping runs after all the orders have been processed.
+// It salvages disbanded colonies, finishes installing colonial units,
+// resolves assimilation, handles population growth, advances tech levels,
+// records which species have met, ages ships, and settles interspecies
+// transactions.
+func housekeeping(t *Turn) error {
+	salvage(t)
+	finishInstallations(t)
+	assimilate(t)
+	growPopulation(t)
+	advanceTechs(t)
+	meetSpecies(t)
+	ageShips(t)
+	return settleTransactions(t)
+}
+
+// ageShips adds a turn to the age of every finished ship.
+// Ships stop aging at 49.
+func ageShips(t *Turn) {
+	for _, ship := range t.Game.Ships {
+		if !ship.IsUnderConstruction() && ship.Age < maxShipAge {
+			ship.Age++
+		}
+	}
+}
+
+// growPopulation updates the population of every colony.
+// Available population that was not used this turn does not carry over.
+// Home planets grow their mining and manufacturing bases by about 2% a
+// turn, while colonies only grow by installing colonial units. Small
+// colonies then lose a unit of population.
+func growPopulation(t *Turn) {
+	for _, colony := range t.Game.Colonies {
+		if colony.IsHome {
+			colony.MiningBase += colony.MiningBase * (180 + t.RNG.Intn(41)) / 10_000
+			colony.ManufacturingBase += colony.ManufacturingBase * (180 + t.RNG.Intn(41)) / 10_000
+		}
+		colony.AvailablePopulation = t.Game.availablePopulation(t.RNG, colony)
+		if lost := colony.attrition(); lost != "" {
+			t.Logf(colony.Species, 0, "PL %s: lost %s to attrition", colony.Name, lost)
+		}
+	}
+}
+
+// settleTransactions completes the pending transactions and reports
+// them to both of the species involved. Economic units are credited to
+// the receiver's treasury; they were taken from the sender when the
+// transaction was made.
+func settleTransactions(t *Turn) error {
+	for _, tx := range t.Game.Transactions {
+		from, to := t.Game.SpeciesById(tx.From), t.Game.SpeciesById(tx.To)
+		if from == nil || to == nil {
+			return fmt.Errorf("transaction %v: %w", tx, ErrNoSuchSpecies)
+		}
+		t.Logf(from.Id, 0, "%s to SP %s", tx, to.Name)
+		t.Logf(to.Id, 0, "%s from SP %s", tx, from.Name)
+		if tx.Item == "" && tx.Amount > 0 {
+			t.audit(to, tx.Amount, "%s from %s", tx.Kind, from)
+		}
+	}
+	t.Game.Transactions = nil
+	return nil
+}
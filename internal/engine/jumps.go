@@ -0,0 +1,383 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+)
+
+// MishapChance returns the chance, in hundredths of a percent, that a jump
+// goes wrong. The manual gives the chance as the square of the distance
+// divided by the gravitics tech level, and older ships are less reliable:
+// each turn of age takes 2% off the chance of success.
+func MishapChance(from, to Coords, gv, age int) int {
+	if gv < 1 {
+		return 10_000
+	}
+	dx, dy, dz := to.X-from.X, to.Y-from.Y, to.Z-from.Z
+	chance := 100 * (dx*dx + dy*dy + dz*dz) / gv
+	if chance >= 10_000 {
+		return 10_000
+	}
+	if age > 0 {
+		success := 10_000 - chance
+		success -= 2 * age * success / 100
+		chance = 10_000 - max(0, success)
+	}
+	return chance
+}
+
+// jumpStep returns the step for the jump phase. Ships that withdrew from
+// combat jump first, to their haven or to a random sector next to the
+// battle, and so do ships that were forced to jump. Their jump orders
+// are ignored.
+func jumpStep() Step {
+	phase := phaseStep("jumps", orders.JumpsSection, jumpOrders)
+	return Step{Name: phase.Name, Run: func(t *Turn) error {
+		var withdrawn []*Ship
+		for _, ship := range t.Game.Ships {
+			ship.Wormhole = false
+			if ship.Withdrawn || ship.Forced != "" {
+				withdrawn = append(withdrawn, ship)
+			}
+		}
+		for _, ship := range withdrawn {
+			sp := t.Game.SpeciesById(ship.Species)
+			switch ship.Forced {
+			case "FJ":
+				// the wormhole is controlled and short, so nothing goes wrong
+				t.Logf(sp.Id, 0, "%s: forced to jump", ship)
+				t.arrive(sp, ship, t.misjump(ship.Coords, ship.Coords), nil)
+				ship.InTransit = true
+			case "FM":
+				t.Logf(sp.Id, 0, "%s: forced to mis-jump", ship)
+				t.jumpShip(sp, 0, ship, t.randomCoords(), nil, sp.Tech[GV], ship.Age)
+			default:
+				dest := t.misjump(ship.Coords, ship.Coords)
+				if ship.Haven != nil {
+					dest = *ship.Haven
+				}
+				t.Logf(sp.Id, 0, "%s: withdrawing from combat", ship)
+				t.jumpShip(sp, 0, ship, dest, nil, sp.Tech[GV], ship.Age)
+			}
+			ship.Withdrawn, ship.Haven, ship.Forced = false, nil, ""
+		}
+		return phase.Run(t)
+	}}
+}
+
+// jump sends a ship to another star system through a wormhole of its own.
+func jump(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.mover(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.SubLight || ship.IsStarbase() {
+		return fmt.Errorf("%s: %w", ship, ErrNoJumpDrive)
+	}
+	dest, planet, err := t.destination(sp, cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+	t.jumpShip(sp, cmd.Line, ship, dest, planet, sp.Tech[GV], ship.Age)
+	return nil
+}
+
+// portalJump sends a ship through a jump portal, a starbase carrying jump
+// portal units. The portal's age and its owner's gravitics tech are used
+// for the mishap chance. Species can use the portals of any species that
+// has declared them an ally.
+func portalJump(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.mover(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.IsStarbase() {
+		return fmt.Errorf("%s: %w", ship, ErrNoJumpDrive)
+	}
+	dest, planet, err := t.destination(sp, cmd.Args[1:len(cmd.Args)-1])
+	if err != nil {
+		return err
+	}
+	portal, err := t.portalArg(sp, ship.Coords, cmd.Args[len(cmd.Args)-1])
+	if err != nil {
+		return err
+	} else if !portal.IsStarbase() || portal.IsUnderConstruction() {
+		return fmt.Errorf("%s: not a jump portal: %w", portal, ErrInvalidClass)
+	} else if portal.Coords != ship.Coords {
+		return fmt.Errorf("%s: %w", portal, ErrNotHere)
+	} else if portal.Cargo["JP"]*10_000 < ship.Tonnage {
+		return fmt.Errorf("%s: %d JP: %w", portal, portal.Cargo["JP"], ErrPortalTooSmall)
+	}
+	owner := t.Game.SpeciesById(portal.Species)
+	t.jumpShip(sp, cmd.Line, ship, dest, planet, owner.Tech[GV], portal.Age)
+	return nil
+}
+
+// portalArg returns the starbase named for a jump portal. The species'
+// own starbases come first, then those of allies in the same sector.
+func (t *Turn) portalArg(sp *Species, c Coords, arg orders.Arg) (*Ship, error) {
+	if portal, err := t.shipArg(sp, arg); err == nil {
+		return portal, nil
+	}
+	for _, portal := range t.Game.Ships {
+		if portal.Species != sp.Id && portal.Coords == c && strings.EqualFold(portal.Name, arg.Name) && strings.EqualFold(portal.Code(), arg.Class) && t.Game.declaredAlly(portal.Species, sp.Id) {
+			return portal, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s: %w", arg.Class, arg.Name, ErrNoSuchShip)
+}
+
+// wormhole sends a ship or starbase through a natural wormhole.
+// Natural wormholes are stable, so there is no chance of a mishap.
+func wormhole(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.mover(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	star := t.Game.Galaxy.StarAt(ship.Coords)
+	if star == nil || star.Wormhole == 0 {
+		return fmt.Errorf("%s: %w", ship, ErrNoWormhole)
+	}
+	exit := t.Game.Galaxy.Star(star.Wormhole)
+	var planet *Planet
+	if len(cmd.Args) == 2 {
+		if _, planet, err = t.destination(sp, cmd.Args[1:]); err != nil {
+			return err
+		} else if t.Game.Galaxy.StarOf(planet.Id) != exit {
+			return fmt.Errorf("%s %s: %w", cmd.Args[1].Class, cmd.Args[1].Name, ErrNotHere)
+		}
+	}
+	t.arrive(sp, ship, exit.Coords, planet)
+	ship.InTransit, ship.Wormhole = true, true
+	t.Logf(sp.Id, cmd.Line, "%s: arrived at %s", ship, exit.Coords)
+	return nil
+}
+
+// move moves a ship or starbase to an adjacent sector at sub-light speed.
+// Only one coordinate may change, and only by one parsec.
+func move(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.mover(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	dest, _, err := t.destination(sp, cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+	dx, dy, dz := abs(dest.X-ship.Coords.X), abs(dest.Y-ship.Coords.Y), abs(dest.Z-ship.Coords.Z)
+	if dx+dy+dz != 1 {
+		return fmt.Errorf("%s to %s: %w", ship.Coords, dest, ErrNotAdjacent)
+	}
+	t.arrive(sp, ship, dest, nil)
+	return nil
+}
+
+// land puts a ship on the surface of a planet in its system that the
+// species populates, where aliens can't see it unless they live there too.
+// A ship can also land on a planet populated by aliens that have declared
+// the species an ally, which it names by number. Starbases can't land.
+// Besiegers may detect a ship landing on a besieged planet and destroy it.
+func land(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, planet, err := t.placement(sp, cmd)
+	if err != nil {
+		return err
+	} else if ship.IsStarbase() {
+		return fmt.Errorf("%s: starbase: %w", ship, ErrNotAllowed)
+	} else if !t.Game.populates(sp.Id, planet.Id) && !t.Game.alliedOn(sp.Id, planet.Id) {
+		return fmt.Errorf("planet %d: %w", planet.Orbit, ErrNotColonized)
+	}
+	ship.Planet, ship.Status = planet.Id, Landed
+	for _, colony := range t.Game.Colonies {
+		if colony.Planet != planet.Id {
+			continue
+		} else if besieger := t.detected(colony, false); besieger != nil {
+			t.Game.removeShip(ship)
+			t.Logf(besieger.Id, 0, "detected a %s landing on %s and destroyed it", ship.Code(), t.Game.location(colony))
+			return fmt.Errorf("%s: %w", ship, ErrDetected)
+		}
+	}
+	return nil
+}
+
+// alliedOn returns true if the planet is populated by aliens that have
+// declared the species an ally.
+func (g *Game) alliedOn(species, planet int) bool {
+	for _, colony := range g.Colonies {
+		if colony.Planet == planet && colony.Species != species && colony.Population() > 0 && g.declaredAlly(colony.Species, species) {
+			return true
+		}
+	}
+	return false
+}
+
+// orbit puts a ship in orbit around a planet in its system. A ship that
+// moved into the sector this turn can't go into orbit in the post-arrival
+// phase, which leaves it in deep space for any pickets in the strike phase.
+func orbit(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, planet, err := t.placement(sp, cmd)
+	if err != nil {
+		return err
+	} else if t.phase == "post-arrival" && t.moved[ship.Id] && !ship.InTransit {
+		return fmt.Errorf("%s: moved into the sector this turn: %w", ship, ErrNotAllowed)
+	} else if t.Game.isSalvage(ship) {
+		return fmt.Errorf("%s: salvage: %w", ship, ErrNotAllowed)
+	}
+	ship.Planet, ship.Status = planet.Id, InOrbit
+	return nil
+}
+
+// placement returns the ship and planet for a LAND or ORBIT order.
+// The planet is one of the species' colonies given by name, a planet
+// number in the ship's star system, or, if it is left out, the planet
+// that the ship is already at. The planet must be in the ship's system.
+func (t *Turn) placement(sp *Species, cmd *orders.Command) (*Ship, *Planet, error) {
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return nil, nil, err
+	} else if ship.IsUnderConstruction() {
+		return nil, nil, fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+	}
+	if len(cmd.Args) == 1 {
+		if ship.Planet == 0 {
+			return nil, nil, fmt.Errorf("%s: deep space: %w", ship, ErrNoSuchPlanet)
+		}
+		return ship, t.Game.Galaxy.Planet(ship.Planet), nil
+	} else if arg := cmd.Args[1]; arg.Kind == orders.Number {
+		if star := t.Game.Galaxy.StarAt(ship.Coords); star != nil {
+			for _, planet := range star.Planets {
+				if planet.Orbit == arg.Number {
+					return ship, planet, nil
+				}
+			}
+		}
+		return nil, nil, fmt.Errorf("planet %d: %w", arg.Number, ErrNoSuchPlanet)
+	}
+	colony := t.Game.ColonyNamed(sp.Id, cmd.Args[1].Name)
+	if colony == nil {
+		return nil, nil, fmt.Errorf("PL %s: %w", cmd.Args[1].Name, ErrNoSuchPlanet)
+	} else if t.Game.Galaxy.StarOf(colony.Planet).Coords != ship.Coords {
+		return nil, nil, fmt.Errorf("PL %s: %w", colony.Name, ErrNotHere)
+	}
+	return ship, t.Game.Galaxy.Planet(colony.Planet), nil
+}
+
+// visited marks a star system as visited by the species.
+func visited(t *Turn, sp *Species, cmd *orders.Command) error {
+	c := Coords{X: cmd.Args[0].Number, Y: cmd.Args[1].Number, Z: cmd.Args[2].Number}
+	star := t.Game.Galaxy.StarAt(c)
+	if star == nil {
+		return fmt.Errorf("%s: %w", c, ErrNoSuchStar)
+	}
+	sp.Visit(star.Id)
+	return nil
+}
+
+// mover returns the ship for a movement order.
+// A ship can only make one jump or move in a turn, and salvage from a
+// disbanded colony can't move at all.
+func (t *Turn) mover(sp *Species, arg orders.Arg) (*Ship, error) {
+	ship, err := t.shipArg(sp, arg)
+	if err != nil {
+		return nil, err
+	} else if ship.IsUnderConstruction() {
+		return nil, fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+	} else if t.moved[ship.Id] {
+		return nil, fmt.Errorf("%s: %w", ship, ErrAlreadyMoved)
+	} else if t.Game.isSalvage(ship) {
+		return nil, fmt.Errorf("%s: salvage: %w", ship, ErrNotAllowed)
+	}
+	return ship, nil
+}
+
+// destination returns the sector and planet for the destination of a
+// movement order, which is either a planet name or X Y Z coordinates.
+// The planet is nil if the destination is given as coordinates.
+func (t *Turn) destination(sp *Species, args []orders.Arg) (Coords, *Planet, error) {
+	if len(args) == 1 {
+		colony := t.Game.ColonyNamed(sp.Id, args[0].Name)
+		if colony == nil {
+			return Coords{}, nil, fmt.Errorf("%s %s: %w", args[0].Class, args[0].Name, ErrNoSuchPlanet)
+		}
+		return t.Game.Galaxy.StarOf(colony.Planet).Coords, t.Game.Galaxy.Planet(colony.Planet), nil
+	}
+	c := Coords{X: args[0].Number, Y: args[1].Number, Z: args[2].Number}
+	if !t.Game.Galaxy.Contains(c) {
+		return Coords{}, nil, fmt.Errorf("%s: %w", c, ErrInvalidCoords)
+	}
+	return c, nil, nil
+}
+
+// jumpShip makes the rolls for a jump and moves the ship.
+//
+// If the first roll is a mishap, a fail-safe jump unit is used up and the
+// jump is tried again. Without one, a second roll against the same chance
+// decides between a mis-jump and self-destruction. The rolls are written
+// to the turn log.
+func (t *Turn) jumpShip(sp *Species, line int, ship *Ship, dest Coords, planet *Planet, gv, age int) {
+	chance := MishapChance(ship.Coords, dest, gv, age)
+	for {
+		roll := t.RNG.Intn(10_000)
+		t.Logf(sp.Id, line, "%s: mishap chance %s: rolled %s", ship, percent(chance), percent(roll))
+		if roll >= chance {
+			break
+		} else if ship.Cargo["FS"] > 0 {
+			addItems(&ship.Cargo, "FS", -1)
+			t.Logf(sp.Id, line, "%s: fail-safe jump unit used", ship)
+			continue
+		}
+		roll = t.RNG.Intn(10_000)
+		t.Logf(sp.Id, line, "%s: mishap: rolled %s", ship, percent(roll))
+		if roll < chance {
+			t.Logf(sp.Id, line, "%s: self-destructed", ship)
+			t.Game.removeShip(ship)
+			return
+		}
+		dest, planet = t.misjump(ship.Coords, dest), nil
+		t.Logf(sp.Id, line, "%s: mis-jumped", ship)
+		break
+	}
+	t.arrive(sp, ship, dest, planet)
+	ship.InTransit = true
+	t.Logf(sp.Id, line, "%s: arrived at %s", ship, dest)
+}
+
+// misjump returns a random sector near the intended destination.
+// The longer the jump, the farther off the ship can end up.
+func (t *Turn) misjump(from, dest Coords) Coords {
+	spread := max(1, int(from.DistanceTo(dest)/2))
+	for {
+		c := Coords{
+			X: dest.X + t.RNG.Intn(2*spread+1) - spread,
+			Y: dest.Y + t.RNG.Intn(2*spread+1) - spread,
+			Z: dest.Z + t.RNG.Intn(2*spread+1) - spread,
+		}
+		if c != dest && t.Game.Galaxy.Contains(c) {
+			return c
+		}
+	}
+}
+
+// randomCoords returns a random sector with each coordinate from 0 to 99,
+// which may be well outside the galaxy.
+func (t *Turn) randomCoords() Coords {
+	return Coords{X: t.RNG.Intn(100), Y: t.RNG.Intn(100), Z: t.RNG.Intn(100)}
+}
+
+// arrive puts a ship in a new sector. Ships that arrive at a planet go
+// into orbit around it, and the rest are in deep space.
+func (t *Turn) arrive(sp *Species, ship *Ship, dest Coords, planet *Planet) {
+	ship.Coords, ship.Planet, ship.Status = dest, 0, DeepSpace
+	if planet != nil {
+		ship.Planet, ship.Status = planet.Id, InOrbit
+	}
+	if star := t.Game.Galaxy.StarAt(dest); star != nil {
+		sp.Visit(star.Id)
+	}
+	t.moved[ship.Id] = true
+}
+
+// percent formats hundredths of a percent, e.g. "12.25%".
+func percent(n int) string {
+	return fmt.Sprintf("%d.%02d%%", n/100, n%100)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestMishapChance(t *testing.T) {
+	from := engine.Coords{X: 10, Y: 10, Z: 10}
+	for _, tc := range []struct {
+		to      engine.Coords
+		gv, age int
+		expect  int
+	}{
+		{engine.Coords{X: 17, Y: 10, Z: 10}, 4, 0, 1225}, // the example from the manual
+		{engine.Coords{X: 17, Y: 10, Z: 10}, 4, 10, 2980},
+		{engine.Coords{X: 10, Y: 10, Z: 10}, 4, 0, 0},
+		{engine.Coords{X: 30, Y: 10, Z: 10}, 4, 0, 10_000},
+		{engine.Coords{X: 11, Y: 10, Z: 10}, 0, 0, 10_000},
+	} {
+		if got := engine.MishapChance(from, tc.to, tc.gv, tc.age); got != tc.expect {
+			t.Errorf("MishapChance: %v: gv %d: age %d: expected %d: got %d\n", tc.to, tc.gv, tc.age, tc.expect, got)
+		}
+	}
+}
+
+func TestJumps(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	home := g.Galaxy.StarOf(earth.Planet)
+	var other *engine.Star
+	for _, star := range g.Galaxy.Stars {
+		if star != home && star.Wormhole == 0 && other == nil {
+			other = star
+		}
+	}
+	home.Wormhole, other.Wormhole = other.Id, home.Id
+
+	// a sector next door, towards the center of the cluster
+	next := home.Coords
+	if next.X < g.Galaxy.Radius {
+		next.X++
+	} else {
+		next.X--
+	}
+	ship := func(id int, class, name string, tonnage int) *engine.Ship {
+		return &engine.Ship{Id: id, Species: sp.Id, Class: class, Name: name, Tonnage: tonnage, Coords: home.Coords, Planet: earth.Planet, Status: engine.InOrbit}
+	}
+	g.Ships = []*engine.Ship{
+		ship(1, "TR1", "Scout", 10_000),
+		ship(2, "BAS", "Fort", 50_000),
+		ship(3, "FF", "Slow", 100_000),
+		ship(4, "CT", "Twice", 20_000),
+	}
+	g.Ships[2].SubLight = true
+	sp.Tech[engine.GV] = 200 // no chance of a mishap on a short jump
+
+	log := runOrders(t, g, fmt.Sprintf(`START JUMPS
+	JUMP TR1 Scout, %s
+	JUMP BAS Fort, %s
+	MOVE BAS Fort, %d %d %d
+	WORMHOLE FFS Slow
+	MOVE CT Twice, %s
+	JUMP CT Twice, %s
+	VISITED %d %d %d
+END
+`, next, next, next.X, next.Y+1, next.Z+1, next, home.Coords, other.Coords.X, other.Coords.Y, other.Coords.Z))
+	for line, expect := range map[int]string{
+		2: "arrived at " + next.String(),
+		3: "no jump drive",
+		4: "not an adjacent sector",
+		5: "arrived at " + other.Coords.String(),
+		7: "already moved this turn",
+	} {
+		if !strings.Contains(log[line], expect) {
+			t.Errorf("jumps: line %d: expected %q: got %q\n", line, expect, log[line])
+		}
+	}
+	if _, ok := log[6]; ok {
+		t.Errorf("jumps: line 6: expected no error: got %q\n", log[6])
+	}
+	if s := g.ShipNamed(sp.Id, "Scout"); s.Coords != next || s.Status != engine.DeepSpace || !s.InTransit {
+		t.Errorf("jump: scout: expected in transit in deep space at %s: got %s %s %v\n", next, s.Coords, s.Status, s.InTransit)
+	}
+	if s := g.ShipNamed(sp.Id, "Slow"); s.Coords != other.Coords || !s.InTransit {
+		t.Errorf("wormhole: expected in transit at %s: got %s %v\n", other.Coords, s.Coords, s.InTransit)
+	}
+	if s := g.ShipNamed(sp.Id, "Twice"); s.Coords != next || s.InTransit {
+		t.Errorf("move: expected at %s, not in transit: got %s %v\n", next, s.Coords, s.InTransit)
+	}
+	if !sp.HasVisited(other.Id) || !sp.HasVisited(home.Id) {
+		t.Errorf("visited: expected home and %d: got %v\n", other.Id, sp.Visited)
+	}
+
+	// a long jump without gravitics always goes wrong, and the fail-safe
+	// units are used up before the ship is lost.
+	far := engine.Coords{}
+	if next.X < g.Galaxy.Radius {
+		far.X = 2 * g.Galaxy.Radius
+	}
+	if next.Y < g.Galaxy.Radius {
+		far.Y = 2 * g.Galaxy.Radius
+	}
+	if next.Z < g.Galaxy.Radius {
+		far.Z = 2 * g.Galaxy.Radius
+	}
+	sp.Tech[engine.GV] = 1
+	g.ShipNamed(sp.Id, "Scout").Cargo = map[string]int{"FS": 2}
+	runOrders(t, g, fmt.Sprintf("START JUMPS\nJUMP TR1 Scout, %s\nEND\n", far))
+	var rolls, failSafes int
+	for _, e := range g.Log {
+		if e.Line == 2 && strings.Contains(e.Text, "mishap chance 100.00%") {
+			rolls++
+		} else if e.Line == 2 && strings.Contains(e.Text, "fail-safe") {
+			failSafes++
+		}
+	}
+	if rolls != 3 || failSafes != 2 {
+		t.Errorf("jump: mishap: expected 3 rolls and 2 fail-safes: got %d and %d\n", rolls, failSafes)
+	}
+	if g.ShipNamed(sp.Id, "Scout") != nil {
+		t.Errorf("jump: mishap: expected ship destroyed\n")
+	}
+	if s := g.ShipNamed(sp.Id, "Slow"); s.InTransit {
+		t.Errorf("wormhole: expected in transit to be cleared on the next turn\n")
+	}
+}
+
+func TestLandAndOrbit(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Lander", Tonnage: 10_000},
+		&engine.Ship{Species: 1, Class: "BAS", Name: "Base", Tonnage: 100_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000},
+	)
+	earth := g.ColonyNamed(1, "Earth")
+	home, outpost := g.Galaxy.Planet(earth.Planet), otherPlanet(g, earth)
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 99, Species: 2, Planet: outpost.Id, Name: "Outpost", Inventory: map[string]int{"CU": 10}})
+	postArrival := engine.DefaultSteps()[4]
+
+	// planets can be given by number, or left out for the planet the ship
+	// is at, but ships only land where the species lives and starbases
+	// can't land at all
+	runStep(t, g, postArrival, map[int]string{1: fmt.Sprintf(`START POST-ARRIVAL
+ORBIT TR1 Hauler, %d
+LAND TR1 Hauler
+LAND TR1 Lander, %d
+LAND BAS Base, PL Earth
+ORBIT BAS Base
+END
+`, home.Orbit, outpost.Orbit)})
+	if ship := g.ShipNamed(1, "Hauler"); ship.Planet != home.Id || ship.Status != engine.Landed {
+		t.Errorf("land: expected the transport to land on Earth: got %+v\n", ship)
+	}
+	errs := orderErrors(g)
+	if len(errs) != 3 || !strings.Contains(errs[0], "planet not colonized") || !strings.Contains(errs[1], "not allowed here") || !strings.Contains(errs[2], "no such planet") {
+		t.Errorf("land: log: expected 3 errors: got %q\n", errs)
+	}
+
+	// aliens that have declared the species an ally let its ships land
+	g.Diplomacy = engine.Diplomacy{2: {1: engine.Ally}}
+	runStep(t, g, postArrival, map[int]string{1: fmt.Sprintf("START POST-ARRIVAL\nLAND TR1 Lander, %d\nEND\n", outpost.Orbit)})
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("land: ally: expected no errors: got %q\n", errs)
+	}
+	if ship := g.ShipNamed(1, "Lander"); ship.Planet != outpost.Id || ship.Status != engine.Landed {
+		t.Errorf("land: ally: expected the transport to land on the outpost: got %+v\n", ship)
+	}
+
+	// besiegers may detect a ship landing on a besieged planet
+	g.Sieges = []*engine.Siege{{Colony: earth.Id, Besieger: 2, Effectiveness: 100}}
+	runStep(t, g, postArrival, map[int]string{1: "START POST-ARRIVAL\nLAND TR1 Lander, PL Earth\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "detected and destroyed by besiegers") {
+		t.Errorf("land: siege: expected the landing to be detected: got %q\n", errs)
+	}
+	if g.ShipNamed(1, "Lander") != nil {
+		t.Errorf("land: siege: expected the transport to be destroyed\n")
+	}
+}
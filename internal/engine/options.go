@@ -3,7 +3,16 @@
 package engine
 
 type Options struct {
-	root string // absolute path to root of file system
+	root  string // absolute path to root of file system
+	steps []Step // steps to run for a turn
 }
 
 type Option func(*Options) error
+
+// WithSteps replaces the default turn processing steps.
+func WithSteps(steps ...Step) Option {
+	return func(o *Options) error {
+		o.steps = steps
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+// Planet is a planet in a star system.
+// Gravity and mining difficulty are stored in hundredths so that the
+// production math is exact.
+type Planet struct {
+	Id               int        `json:"id"`
+	Orbit            int        `json:"orbit"`    // planet number, 1 is closest to the sun
+	Diameter         int        `json:"diameter"` // thousands of kilometers
+	Gravity          int        `json:"gravity"`  // hundredths of standard Earth gravity
+	TemperatureClass int        `json:"tc"`       // 1 (absolute zero) to 30
+	PressureClass    int        `json:"pc"`       // 0 (vacuum) to 29
+	MiningDifficulty int        `json:"md"`       // hundredths
+	Atmosphere       Atmosphere `json:"atmosphere,omitempty"`
+}
+
+// Limits on planetary conditions.
+const (
+	MinTemperatureClass = 1
+	MaxTemperatureClass = 30
+	MinPressureClass    = 0
+	MaxPressureClass    = 29
+
+	// gasGiantDiameter is the smallest diameter of a gas giant.
+	gasGiantDiameter = 40
+)
+
+// IsGasGiant returns true if the planet is a gas giant.
+func (p *Planet) IsGasGiant() bool {
+	return p.Diameter >= gasGiantDiameter
+}
+
+// generatePlanet returns a new planet for the given orbit around the star.
+// Inner planets are hotter than outer planets, and gas giants, which only
+// form in the outer orbits, have high pressure classes.
+func generatePlanet(r *RNG, s *Star, orbit, numPlanets int) *Planet {
+	p := &Planet{Orbit: orbit}
+
+	gasGiant := orbit > (numPlanets+2)/3 && r.Intn(100) < 50
+
+	// density is in hundredths of grams per cubic centimeter.
+	var density int
+	if gasGiant {
+		p.Diameter = gasGiantDiameter + r.Intn(80) + r.Intn(80)
+		density = 70 + r.Intn(100)
+	} else {
+		p.Diameter = 3 + r.Intn(10) + r.Intn(10)
+		density = 300 + r.Intn(300)
+	}
+	// Earth has a diameter of 12.75 and a density of 5.52.
+	p.Gravity = p.Diameter * density * 100 / 7038
+	if p.Gravity < 1 {
+		p.Gravity = 1
+	}
+
+	// pressure depends on how much atmosphere the planet can hold on to.
+	if gasGiant {
+		p.PressureClass = 16 + r.Intn(14)
+	} else if p.Gravity < 30 || p.Diameter < 5 {
+		p.PressureClass = r.Intn(4)
+	} else {
+		p.PressureClass = p.Gravity/10 + r.Intn(5) - 2
+	}
+	p.PressureClass = clamp(p.PressureClass, MinPressureClass, MaxPressureClass)
+
+	// temperature drops with distance from the star, and a thick atmosphere
+	// traps some heat on rocky planets.
+	p.TemperatureClass = s.heat() - 3*(orbit-1) + r.Intn(5) - 2
+	if !gasGiant {
+		p.TemperatureClass += p.PressureClass / 5
+	}
+	p.TemperatureClass = clamp(p.TemperatureClass, MinTemperatureClass, MaxTemperatureClass)
+
+	if gasGiant {
+		p.MiningDifficulty = 40 + r.Intn(100) + r.Intn(200) + r.Intn(100)
+	} else {
+		p.MiningDifficulty = 30 + r.Intn(50) + r.Intn(50) + r.Intn(100)
+	}
+
+	if p.PressureClass > 0 {
+		p.Atmosphere = generateAtmosphere(r, p.TemperatureClass, gasGiant)
+	}
+
+	return p
+}
+
+// generateAtmosphere returns a random atmosphere made up of gases that
+// are plausible for the temperature of the planet.
+func generateAtmosphere(r *RNG, tc int, gasGiant bool) Atmosphere {
+	var candidates []Gas
+	switch {
+	case gasGiant:
+		candidates = []Gas{H2, He, CH4, NH3}
+	case tc <= 6:
+		candidates = []Gas{N2, CH4, NH3, He, H2}
+	case tc <= 14:
+		candidates = []Gas{N2, CO2, O2, H2O, NH3, CH4}
+	default:
+		candidates = []Gas{CO2, SO2, H2S, HCl, Cl2, F2, N2, H2O}
+	}
+
+	// gas giants are mostly hydrogen
+	var gases []Gas
+	if gasGiant {
+		gases = append(gases, H2)
+	}
+	for want := 1 + r.Intn(3); len(gases) < want || len(gases) == 0; {
+		gas := candidates[r.Intn(len(candidates))]
+		found := false
+		for _, g := range gases {
+			found = found || g == gas
+		}
+		if !found {
+			gases = append(gases, gas)
+		}
+	}
+
+	// split 100 percent between the gases
+	weights, total := make([]int, len(gases)), 0
+	for i := range gases {
+		weights[i] = 1 + r.Intn(100)
+		if gasGiant && gases[i] == H2 {
+			weights[i] += 100
+		}
+		total += weights[i]
+	}
+	a, remaining, largest := make(Atmosphere, len(gases)), 100, 0
+	for i, gas := range gases {
+		a[i] = Constituent{Gas: gas, Percent: weights[i] * 100 / total}
+		if a[i].Percent < 1 {
+			a[i].Percent = 1
+		}
+		remaining -= a[i].Percent
+		if a[i].Percent > a[largest].Percent {
+			largest = i
+		}
+	}
+	a[largest].Percent += remaining
+
+	return a
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	} else if n > hi {
+		return hi
+	}
+	return n
+}
@@ -0,0 +1,679 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+)
+
+// Ledger is the record of a planet's production for a turn.
+//
+// The amount that a planet can spend is the lower of the raw material
+// units it has and its production capacity, since both are used up in
+// equal amounts. Whatever isn't spent becomes economic units, and raw
+// material units that production capacity couldn't use carry over.
+//
+// Raw material and capacity are net of the production penalty and the
+// planet's economic efficiency. Mining and resort colonies can't spend
+// anything themselves; their output is converted to economic units.
+type Ledger struct {
+	Colony      int            `json:"colony"`
+	Penalty     int            `json:"penalty,omitempty"`     // percent of output needed to survive
+	Efficiency  int            `json:"efficiency"`            // percent economic efficiency
+	RawMaterial int            `json:"rm"`                    // raw material units produced this turn
+	Stockpile   int            `json:"stockpile"`             // raw material units carried over from earlier turns
+	Capacity    int            `json:"capacity"`              // production capacity
+	Converted   int            `json:"converted,omitempty"`   // economic units from a mining or resort colony
+	Recycled    int            `json:"recycled,omitempty"`    // economic units from recycling
+	Siege       int            `json:"siege,omitempty"`       // production lost to sieges
+	Maintenance int            `json:"maintenance,omitempty"` // share of the fleet maintenance cost
+	Treasury    int            `json:"treasury,omitempty"`    // economic units taken from the treasury
+	Spent       int            `json:"spent"`
+	Entries     []*LedgerEntry `json:"entries,omitempty"`
+	Unspent     int            `json:"unspent"`    // balance left at the end of production
+	CarryOver   int            `json:"carry_over"` // raw material units carried over to the next turn
+}
+
+// LedgerEntry is an amount spent or received by an order.
+// Credits, like recycling, are negative.
+type LedgerEntry struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Amount int    `json:"amount"`
+}
+
+// Production returns the amount that the planet's own production provides.
+func (l *Ledger) Production() int {
+	return min(l.RawMaterial+l.Stockpile, l.Capacity)
+}
+
+// Available returns the total amount that the planet can spend this turn.
+func (l *Ledger) Available() int {
+	return l.Production() - l.Siege - l.Maintenance + l.Recycled + l.Treasury
+}
+
+// Balance returns the amount that is left to spend.
+func (l *Ledger) Balance() int {
+	return l.Available() - l.Spent
+}
+
+// RawMaterial returns the raw material units a colony produces in a turn:
+// the mining tech level times the mining base divided by the mining
+// difficulty, dropping fractions.
+func RawMaterial(sp *Species, colony *Colony, planet *Planet) int {
+	// the mining base is in tenths and the difficulty in hundredths
+	return sp.Tech[MI] * colony.MiningBase * 10 / planet.MiningDifficulty
+}
+
+// ProductionCapacity returns the production capacity of a colony:
+// the manufacturing tech level times the manufacturing base.
+func ProductionCapacity(sp *Species, colony *Colony) int {
+	// the manufacturing base is in tenths
+	return sp.Tech[MA] * colony.ManufacturingBase / 10
+}
+
+// productionStep opens a ledger for every colony, runs the production
+// orders, intercepts ships that jumped in, and then closes the ledgers.
+func productionStep() Step {
+	phase := phaseStep("production", orders.ProductionSection, productionOrders)
+	return Step{Name: phase.Name, Run: func(t *Turn) error {
+		if err := openLedgers(t); err != nil {
+			return err
+		}
+		if err := phase.Run(t); err != nil {
+			return err
+		}
+		interceptions(t)
+		closeLedgers(t)
+		return nil
+	}}
+}
+
+// openLedgers computes the production for every colony.
+func openLedgers(t *Turn) error {
+	t.Game.Ledgers, t.producing = nil, make(map[int]*Colony)
+	t.shipyards, t.expanded, t.intercepts = make(map[int]int), make(map[int]bool), make(map[int]map[Coords]int)
+	for _, colony := range t.Game.Colonies {
+		sp, planet := t.Game.SpeciesById(colony.Species), t.Game.Galaxy.Planet(colony.Planet)
+		if sp == nil {
+			return fmt.Errorf("colony %d: %w", colony.Id, ErrNoSuchSpecies)
+		} else if planet == nil {
+			return fmt.Errorf("colony %d: %w", colony.Id, ErrNoSuchPlanet)
+		}
+		ledger := &Ledger{
+			Colony:      colony.Id,
+			Penalty:     ProductionPenalty(sp, planet),
+			Efficiency:  t.Game.EconomicEfficiency(colony),
+			RawMaterial: RawMaterial(sp, colony, planet),
+			Stockpile:   colony.Inventory["RM"],
+			Capacity:    ProductionCapacity(sp, colony),
+		}
+		ledger.RawMaterial = ledger.RawMaterial * (100 - ledger.Penalty) / 100 * ledger.Efficiency / 100
+		ledger.Capacity = ledger.Capacity * (100 - ledger.Penalty) / 100 * ledger.Efficiency / 100
+
+		// mining colonies sell what they mine, three raw material units
+		// for two economic units, and the planet gets harder to mine.
+		// resort colonies convert their capacity at the same rate.
+		if colony.IsMiningColony() {
+			ledger.Converted = ledger.RawMaterial * 2 / 3
+			planet.MiningDifficulty += (ledger.RawMaterial + 99) / 100
+			ledger.RawMaterial = 0
+		} else if t.Game.IsResortColony(colony) {
+			ledger.Converted = ledger.Capacity * 2 / 3
+			ledger.Capacity = 0
+		}
+		t.Game.Ledgers = append(t.Game.Ledgers, ledger)
+	}
+	applySieges(t)
+	applyMaintenance(t)
+	return nil
+}
+
+// closeLedgers records what each planet didn't spend and carries over
+// the raw material units that weren't used. What wasn't spent goes into
+// the treasury, as does what mining and resort colonies converted if it
+// wasn't credited by a PRODUCTION order.
+func closeLedgers(t *Turn) {
+	for _, ledger := range t.Game.Ledgers {
+		colony := t.Game.ColonyById(ledger.Colony)
+		sp := t.Game.SpeciesById(colony.Species)
+		ledger.Unspent = ledger.Balance()
+		ledger.CarryOver = ledger.RawMaterial + ledger.Stockpile - ledger.Production()
+		addItems(&colony.Inventory, "RM", ledger.CarryOver-colony.Inventory["RM"])
+		if ledger.Unspent > 0 {
+			t.audit(sp, ledger.Unspent, "unspent production on PL %s", colony.Name)
+		}
+		if ledger.Converted > 0 && !t.hasProduced(colony.Id) {
+			t.audit(sp, ledger.Converted, "converted production on PL %s", colony.Name)
+		}
+	}
+}
+
+// LedgerFor returns the colony's ledger for the turn or nil if the colony
+// has no production.
+func (g *Game) LedgerFor(colony int) *Ledger {
+	for _, ledger := range g.Ledgers {
+		if ledger.Colony == colony {
+			return ledger
+		}
+	}
+	return nil
+}
+
+// produce starts the production orders for a planet.
+// All the production orders that follow are for this planet.
+// What a mining or resort colony converted goes into the treasury
+// right away, so planets that come after it can spend it.
+func produce(t *Turn, sp *Species, cmd *orders.Command) error {
+	delete(t.producing, sp.Id)
+	colony := t.Game.ColonyNamed(sp.Id, cmd.Args[0].Name)
+	if colony == nil {
+		return ErrNoSuchPlanet
+	} else if t.hasProduced(colony.Id) {
+		return ErrDuplicateProduction
+	}
+	t.producing[sp.Id] = colony
+	t.produced = append(t.produced, colony.Id)
+	if ledger := t.Game.LedgerFor(colony.Id); ledger != nil && ledger.Converted > 0 {
+		t.audit(sp, ledger.Converted, "converted production on PL %s", colony.Name)
+	}
+	return nil
+}
+
+// hasProduced returns true if the colony has had a PRODUCTION order this turn.
+func (t *Turn) hasProduced(colony int) bool {
+	for _, c := range t.produced {
+		if c == colony {
+			return true
+		}
+	}
+	return false
+}
+
+// spend debits the ledger of the producing planet.
+// When the planet's own production runs out, the rest comes from the
+// treasury.
+func (t *Turn) spend(sp *Species, cmd *orders.Command, amount int) error {
+	colony := t.producing[sp.Id]
+	if colony == nil {
+		return ErrNoProduction
+	}
+	ledger := t.Game.LedgerFor(colony.Id)
+	if short := amount - ledger.Balance(); short > 0 {
+		if short > t.drawable(sp, colony, ledger) {
+			return fmt.Errorf("need %d, have %d: %w", amount, t.funds(sp), ErrInsufficientFunds)
+		}
+		ledger.Treasury += short
+		t.audit(sp, -short, "spent on PL %s: %s", colony.Name, cmd.Text)
+	}
+	ledger.Spent += amount
+	ledger.Entries = append(ledger.Entries, &LedgerEntry{Line: cmd.Line, Text: cmd.Text, Amount: amount})
+	return nil
+}
+
+// funds returns the amount that the producing planet can spend, including
+// what it can take from the treasury.
+func (t *Turn) funds(sp *Species) int {
+	colony := t.producing[sp.Id]
+	if colony == nil {
+		return 0
+	}
+	ledger := t.Game.LedgerFor(colony.Id)
+	return ledger.Balance() + t.drawable(sp, colony, ledger)
+}
+
+// unspent returns what is left of the producing planet's own production.
+// Orders given a quantity of zero do as much as this pays for, and never
+// draw on the treasury.
+func (t *Turn) unspent(sp *Species) int {
+	colony := t.producing[sp.Id]
+	if colony == nil {
+		return 0
+	}
+	return max(0, t.Game.LedgerFor(colony.Id).Balance())
+}
+
+// credit adds economic units to the ledger of the producing planet.
+func (t *Turn) credit(sp *Species, cmd *orders.Command, amount int) {
+	ledger := t.Game.LedgerFor(t.producing[sp.Id].Id)
+	ledger.Recycled += amount
+	ledger.Entries = append(ledger.Entries, &LedgerEntry{Line: cmd.Line, Text: cmd.Text, Amount: -amount})
+}
+
+// producer returns the planet that production orders apply to.
+func (t *Turn) producer(sp *Species) (*Colony, *Planet, error) {
+	colony := t.producing[sp.Id]
+	if colony == nil {
+		return nil, nil, ErrNoProduction
+	}
+	return colony, t.Game.Galaxy.Planet(colony.Planet), nil
+}
+
+// build builds items or starts construction of a ship or starbase.
+// A quantity or amount of zero builds as much as the planet's remaining
+// production pays for.
+func build(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Pattern() == "na" {
+		n, code := cmd.Args[0].Number, cmd.Args[1].Class
+		if n == 0 {
+			if n, err = t.affordable(sp, colony, code); err != nil {
+				return err
+			}
+		}
+		item, cost, err := itemCost(sp, code, n)
+		if err != nil {
+			return err
+		}
+		// colonists and planetary defenses have to be hired from the available population
+		if item.Code == "CU" || item.Code == "PD" {
+			if n > colony.AvailablePopulation {
+				return fmt.Errorf("need %d, have %d: %w", n, colony.AvailablePopulation, ErrInsufficientPopulation)
+			}
+		}
+		if err := t.spend(sp, cmd, cost); err != nil {
+			return err
+		}
+		if item.Code == "CU" || item.Code == "PD" {
+			colony.AvailablePopulation -= n
+		}
+		addItems(&colony.Inventory, item.Code, n)
+		// besiegers that see planetary defenses being built destroy all of them
+		if item.Code == "PD" {
+			if besieger := t.detected(colony, false); besieger != nil {
+				addItems(&colony.Inventory, "PD", -colony.Inventory["PD"])
+				t.Logf(besieger.Id, 0, "detected construction of planetary defenses on %s and destroyed them", t.Game.location(colony))
+				return fmt.Errorf("PD: %w", ErrDetected)
+			}
+		}
+		return nil
+	}
+
+	ship, err := t.newShip(sp, sp, planet, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if err := t.shipyardFree(colony); err != nil {
+		return err
+	}
+
+	// starbases are built in orbit, and the amount paid sets the tonnage
+	if ship.IsStarbase() {
+		if len(cmd.Args) != 2 {
+			return fmt.Errorf("starbase needs an amount: %w", ErrInvalidAmount)
+		}
+		pay := t.starbasePayment(sp, 0, cmd.Args[1].Number)
+		tonnage, err := starbaseTonnage(sp, 0, pay)
+		if err != nil {
+			return err
+		} else if err := t.spend(sp, cmd, pay); err != nil {
+			return err
+		}
+		t.useShipyard(colony)
+		ship.Tonnage, ship.Status = tonnage, InOrbit
+		if err := t.besieged(colony, ship); err != nil {
+			return err
+		}
+		t.Game.Ships = append(t.Game.Ships, ship)
+		return nil
+	}
+
+	pay := ship.Cost()
+	if len(cmd.Args) == 2 {
+		pay = min(t.payment(sp, cmd.Args[1].Number), pay)
+	}
+	if err := t.spend(sp, cmd, pay); err != nil {
+		return err
+	}
+	t.useShipyard(colony)
+	ship.Remaining = ship.Cost() - pay
+	if err := t.besieged(colony, ship); err != nil {
+		return err
+	}
+	t.Game.Ships = append(t.Game.Ships, ship)
+	return nil
+}
+
+// affordable returns how many of an item the producing planet's remaining
+// production pays for. Colonists and planetary defenses are also limited
+// by the available population.
+func (t *Turn) affordable(sp *Species, colony *Colony, code string) (int, error) {
+	item, cost, err := itemCost(sp, code, 1)
+	if err != nil {
+		return 0, err
+	}
+	n := t.unspent(sp) / max(1, cost)
+	if item.Code == "CU" || item.Code == "PD" {
+		n = min(n, colony.AvailablePopulation)
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s: none affordable: %w", item.Code, ErrInsufficientFunds)
+	}
+	return n, nil
+}
+
+// payment returns the amount to pay for an order that gives one. Zero
+// means whatever is left of the producing planet's production.
+func (t *Turn) payment(sp *Species, amount int) int {
+	if amount == 0 {
+		return t.unspent(sp)
+	}
+	return amount
+}
+
+// starbasePayment returns the amount to pay towards a starbase. Zero
+// means as many increments as the planet's remaining production pays
+// for, up to the species' tonnage limit.
+func (t *Turn) starbasePayment(sp *Species, tonnage, amount int) int {
+	if amount != 0 {
+		return amount
+	}
+	increment := catalog.ShipCost(catalog.StarbaseIncrement, false)
+	room := max(0, catalog.TonnagePerMA*sp.Tech[MA]-tonnage) / catalog.StarbaseIncrement
+	return min(t.unspent(sp)/increment, room) * increment
+}
+
+// itemCost returns the item and the cost for a species to build n of them.
+// The species needs the item's minimum tech level, and some items cost
+// less at higher levels.
+func itemCost(sp *Species, code string, n int) (*catalog.Item, int, error) {
+	item, ok := catalog.LookupItem(code)
+	if !ok || item.Cost == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", code, ErrNotBuildable)
+	}
+	level := 0
+	if item.Tech != "" {
+		tech, _ := ParseTech(item.Tech)
+		if level = sp.Tech[tech]; level < item.MinLevel {
+			return nil, 0, fmt.Errorf("%s needs %s %d: %w", item.Code, item.Tech, item.MinLevel, ErrTechTooLow)
+		}
+	}
+	return item, n * item.UnitCost(level), nil
+}
+
+// newShip returns a new ship built by one species for another on the
+// planet. The name must be unused by the owner, and the builder's tech
+// levels limit what can be built.
+func (t *Turn) newShip(builder, owner *Species, planet *Planet, arg orders.Arg) (*Ship, error) {
+	class, subLight, err := catalog.LookupShip(arg.Class)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg.Class, ErrInvalidClass)
+	} else if err := ValidateName(arg.Name); err != nil {
+		return nil, err
+	} else if t.Game.ShipNamed(owner.Id, arg.Name) != nil {
+		return nil, fmt.Errorf("%q: %w", arg.Name, ErrDuplicateName)
+	} else if !subLight && class.Kind != catalog.Starbase && builder.Tech[GV] == 0 {
+		return nil, fmt.Errorf("%s: %w", arg.Class, ErrSubLightOnly)
+	} else if class.Kind != catalog.Starbase && class.Tonnage > catalog.TonnagePerMA*builder.Tech[MA] {
+		return nil, fmt.Errorf("%s needs MA %d: %w", arg.Class, catalog.MinMA(class.Tonnage), ErrTonnageLimit)
+	}
+	return &Ship{
+		Id:       t.Game.nextShipId(),
+		Species:  owner.Id,
+		Class:    class.Code,
+		SubLight: subLight,
+		Name:     arg.Name,
+		Tonnage:  class.Tonnage,
+		Coords:   t.Game.Galaxy.StarOf(planet.Id).Coords,
+		Planet:   planet.Id,
+		Status:   Landed,
+	}, nil
+}
+
+// continueBuilding pays more on a ship that is under construction
+// or adds tonnage to a starbase. An amount of zero pays as much as the
+// planet's remaining production allows.
+func continueBuilding(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.Planet != planet.Id {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	} else if err := t.shipyardFree(colony); err != nil {
+		return err
+	}
+
+	// the age of a starbase is the weighted average of its contributions
+	if ship.IsStarbase() {
+		if len(cmd.Args) != 2 {
+			return fmt.Errorf("starbase needs an amount: %w", ErrInvalidAmount)
+		}
+		pay := t.starbasePayment(sp, ship.Tonnage, cmd.Args[1].Number)
+		tonnage, err := starbaseTonnage(sp, ship.Tonnage, pay)
+		if err != nil {
+			return err
+		} else if err := t.spend(sp, cmd, pay); err != nil {
+			return err
+		}
+		t.useShipyard(colony)
+		ship.grow(tonnage)
+		return t.besieged(colony, ship)
+	}
+
+	if !ship.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", ship, ErrNotUnderConstruction)
+	}
+	pay := ship.Remaining
+	if len(cmd.Args) == 2 {
+		pay = min(t.payment(sp, cmd.Args[1].Number), pay)
+	}
+	if err := t.spend(sp, cmd, pay); err != nil {
+		return err
+	}
+	t.useShipyard(colony)
+	ship.Remaining -= pay
+	return t.besieged(colony, ship)
+}
+
+// besieged checks whether besiegers detect construction of a ship on the
+// colony and destroy it. Work on a starbase is always detected.
+func (t *Turn) besieged(colony *Colony, ship *Ship) error {
+	besieger := t.detected(colony, ship.IsStarbase())
+	if besieger == nil {
+		return nil
+	}
+	t.Game.removeShip(ship)
+	t.Logf(besieger.Id, 0, "detected construction of a %s on %s and destroyed it", ship.Code(), t.Game.location(colony))
+	return fmt.Errorf("%s: %w", ship, ErrDetected)
+}
+
+// starbaseTonnage returns the tonnage of a starbase after paying an amount
+// towards it. Starbases grow in increments of 10,000 tons, which cost 100,
+// and can't grow past the limit set by the species' manufacturing tech.
+func starbaseTonnage(sp *Species, tonnage, pay int) (int, error) {
+	increment := catalog.ShipCost(catalog.StarbaseIncrement, false)
+	if pay <= 0 || pay%increment != 0 {
+		return 0, fmt.Errorf("%d is not a multiple of %d: %w", pay, increment, ErrInvalidAmount)
+	}
+	tonnage += pay / increment * catalog.StarbaseIncrement
+	if tonnage > catalog.TonnagePerMA*sp.Tech[MA] {
+		return 0, fmt.Errorf("%d tons needs MA %d: %w", tonnage, catalog.MinMA(tonnage), ErrTonnageLimit)
+	}
+	return tonnage, nil
+}
+
+// research spends on a technology.
+// Knowledge taught by other species is applied right away. Whether the
+// rest of the spending raises the tech level is decided in housekeeping.
+// A tech at level zero can only be researched once it has been taught.
+func research(t *Turn, sp *Species, cmd *orders.Command) error {
+	n := cmd.Args[0].Number
+	tech, ok := ParseTech(cmd.Args[1].Class)
+	if !ok {
+		return fmt.Errorf("%s: %w", cmd.Args[1].Class, ErrInvalidTech)
+	} else if sp.Tech[tech] == 0 && sp.Knowledge[tech] == 0 {
+		return fmt.Errorf("%s is zero and must be taught first: %w", tech, ErrInvalidTech)
+	}
+	if err := t.spend(sp, cmd, n); err != nil {
+		return err
+	}
+	if t.research[sp.Id] == nil {
+		t.research[sp.Id] = &TechLevels{}
+	}
+	t.research[sp.Id][tech] += t.applyKnowledge(sp, tech, n)
+	return nil
+}
+
+// upgrade reduces the age of a ship or starbase.
+// The age is reduced by 40 times the amount spent divided by the original
+// cost, and without an amount the age is reduced to zero. An amount of
+// zero pays as much as the planet's remaining production allows.
+func upgrade(t *Turn, sp *Species, cmd *orders.Command) error {
+	_, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+	} else if t.moved[ship.Id] {
+		return fmt.Errorf("%s: %w", ship, ErrAlreadyMoved)
+	} else if ship.Coords != t.Game.Galaxy.StarOf(planet.Id).Coords {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	}
+	cost := ship.Cost()
+	full := (ship.Age*cost + 39) / 40 // round up
+	pay := full
+	if len(cmd.Args) == 2 {
+		pay = min(t.payment(sp, cmd.Args[1].Number), full)
+	}
+	if err := t.spend(sp, cmd, pay); err != nil {
+		return err
+	}
+	if pay == full {
+		ship.Age = 0
+	} else {
+		ship.Age = max(0, ship.Age-40*pay/cost)
+	}
+	return nil
+}
+
+// recycle sells items or a ship for economic units. A quantity of zero
+// sells all that are available.
+// Cargo on a recycled ship is moved to the planet first, and isn't sold.
+// Raw material units moved on or off the planet change the stockpile in
+// the planet's ledger, and units that production is using can't be sold.
+func recycle(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	ledger := t.Game.LedgerFor(colony.Id)
+
+	if cmd.Pattern() == "na" {
+		n, code := cmd.Args[0].Number, cmd.Args[1].Class
+		item, ok := catalog.LookupItem(code)
+		if !ok {
+			return fmt.Errorf("%s: %w", code, ErrInvalidClass)
+		}
+		have := colony.Inventory[item.Code]
+		if item.Code == "RM" {
+			have = ledger.Stockpile - max(0, ledger.Production()-ledger.RawMaterial)
+		}
+		if n == 0 {
+			n = have
+		}
+		if have < n || n == 0 {
+			return fmt.Errorf("%s: have %d: %w", item.Code, have, ErrInsufficientItems)
+		}
+		value := itemValue(sp, item, n)
+		addItems(&colony.Inventory, item.Code, -n)
+		if item.Code == "RM" {
+			ledger.Stockpile -= n
+		} else if item.Code == "CU" || item.Code == "PD" {
+			colony.AvailablePopulation += n
+		}
+		t.credit(sp, cmd, value)
+		t.Logf(sp.Id, cmd.Line, "%d %s: recycled for %d", n, item.Code, value)
+		return nil
+	}
+
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if t.moved[ship.Id] {
+		return fmt.Errorf("%s: %w", ship, ErrAlreadyMoved)
+	} else if ship.Planet != planet.Id {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	}
+	value := ship.recycleValue()
+	for item, n := range ship.Cargo {
+		addItems(&colony.Inventory, item, n)
+		if item == "RM" {
+			ledger.Stockpile += n
+		}
+	}
+	t.Game.removeShip(ship)
+	t.credit(sp, cmd, value)
+	t.Logf(sp.Id, cmd.Line, "%s: recycled for %d", ship, value)
+	return nil
+}
+
+// itemValue returns the economic units that n of an item are sold for.
+// Items that don't age return half of what they cost the species, and raw
+// material units return one unit for every five.
+func itemValue(sp *Species, item *catalog.Item, n int) int {
+	if item.Code == "RM" {
+		return n / 5
+	}
+	level := 0
+	if tech, ok := ParseTech(item.Tech); ok {
+		level = sp.Tech[tech]
+	}
+	return n * item.UnitCost(level) / 2
+}
+
+// cargoValue returns the economic units that everything in a ship's cargo
+// or a colony's inventory is sold for by the species.
+func cargoValue(sp *Species, cargo map[string]int) int {
+	value := 0
+	for code, n := range cargo {
+		if item, ok := catalog.LookupItem(code); ok {
+			value += itemValue(sp, item, n)
+		}
+	}
+	return value
+}
+
+// recycleValue returns the economic units that the ship is sold for,
+// without its cargo. A ship under construction returns half of what has
+// been spent on it, and a finished ship returns three quarters of its cost
+// scaled by (60 - age) / 50.
+func (s *Ship) recycleValue() int {
+	if s.IsUnderConstruction() {
+		return (s.Cost() - s.Remaining) / 2
+	}
+	return 3 * s.Cost() * (60 - min(s.Age, maxShipAge)) / 200
+}
+
+// shipArg returns the species' ship for an argument. The class must match.
+func (t *Turn) shipArg(sp *Species, arg orders.Arg) (*Ship, error) {
+	ship := t.Game.ShipNamed(sp.Id, arg.Name)
+	if ship == nil || !strings.EqualFold(ship.Code(), arg.Class) {
+		return nil, fmt.Errorf("%s %s: %w", arg.Class, arg.Name, ErrNoSuchShip)
+	}
+	return ship, nil
+}
+
+// addItems adds n units (or removes them, if n is negative) to an inventory.
+func addItems(inventory *map[string]int, item string, n int) {
+	if *inventory == nil {
+		*inventory = make(map[string]int)
+	}
+	(*inventory)[item] += n
+	if (*inventory)[item] <= 0 {
+		delete(*inventory, item)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+	"testing"
+)
+
+// newTestGame returns a game with a single species, the Humanoids, on Earth.
+func newTestGame(t *testing.T) (*engine.Game, *engine.Species, *engine.Colony) {
+	t.Helper()
+	g, err := engine.NewGame("test", 3, 1)
+	if err != nil {
+		t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+	}
+	sp, err := g.AddSpecies(engine.SpeciesSetup{Name: "Humanoid", HomePlanet: "Earth", Government: "United Nations", GovernmentType: "Democracy", ML: 4, GV: 4, LS: 4, BI: 3})
+	if err != nil {
+		t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+	}
+	return g, sp, g.ColonyNamed(sp.Id, "Earth")
+}
+
+// runOrders runs a turn with the orders for species 1 and returns the log entries for the species.
+func runOrders(t *testing.T, g *engine.Game, text string) map[int]string {
+	t.Helper()
+	o, err := orders.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Parse: err: expected nil: got %v\n", err)
+	}
+	if err := engine.RunTurn(g, map[int]*orders.Orders{1: o}); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+	log := make(map[int]string)
+	for _, e := range g.Log {
+		if e.Species == 1 && e.Line != 0 {
+			log[e.Line] = e.Text
+		}
+	}
+	return log
+}
+
+func TestProductionMath(t *testing.T) {
+	// the examples from the manual
+	sp := &engine.Species{}
+	sp.Tech[engine.MI], sp.Tech[engine.MA] = 4, 6
+	colony := &engine.Colony{MiningBase: 1360, ManufacturingBase: 1420}
+	if got := engine.RawMaterial(sp, colony, &engine.Planet{MiningDifficulty: 124}); got != 438 {
+		t.Errorf("RawMaterial: expected 438: got %d\n", got)
+	}
+	if got := engine.ProductionCapacity(sp, colony); got != 852 {
+		t.Errorf("ProductionCapacity: expected 852: got %d\n", got)
+	}
+}
+
+func TestProduction(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	sp.Tech[engine.MA] = 20 // big enough for a frigate
+	log := runOrders(t, g, `START PRODUCTION
+	PRODUCTION PL Earth
+	Build	50 PD
+	BUILD	FFS Farragut, 300
+	RESEARCH 27 BI
+	BUILD	CT Farragut
+	BUILD	TR99 Hopeless
+END
+`)
+	if len(log) != 2 || !strings.Contains(log[6], "duplicate name") || !strings.Contains(log[7], "tonnage exceeds manufacturing limit") {
+		t.Errorf("production: log: expected errors on lines 6 and 7: got %v\n", log)
+	}
+
+	ledger := g.LedgerFor(earth.Id)
+	if ledger == nil {
+		t.Fatalf("production: ledger: expected ledger: got nil\n")
+	} else if ledger.Spent != 377 || len(ledger.Entries) != 3 {
+		t.Errorf("production: spent: expected 377 in 3 entries: got %d in %d\n", ledger.Spent, len(ledger.Entries))
+	} else if ledger.Unspent != ledger.Production()-377 {
+		t.Errorf("production: unspent: expected %d: got %d\n", ledger.Production()-377, ledger.Unspent)
+	}
+	if earth.Inventory["PD"] != 50 {
+		t.Errorf("production: PD: expected 50: got %d\n", earth.Inventory["PD"])
+	}
+	if ship := g.ShipNamed(1, "farragut"); ship == nil {
+		t.Errorf("production: ship: expected Farragut: got nil\n")
+	} else if ship.Code() != "FFS" || ship.Remaining != 450 || !ship.IsUnderConstruction() {
+		t.Errorf("production: ship: expected FFS with 450 remaining: got %s with %d\n", ship.Code(), ship.Remaining)
+	}
+
+	// finish the ship on the next turn
+	log = runOrders(t, g, "START PRODUCTION\nPRODUCTION PL Earth\nCONTINUE FFS Farragut\nEND\n")
+	if len(log) != 0 {
+		t.Errorf("continue: log: expected no errors: got %v\n", log)
+	} else if ship := g.ShipNamed(1, "Farragut"); ship.IsUnderConstruction() {
+		t.Errorf("continue: ship: expected finished: got %d remaining\n", ship.Remaining)
+	}
+}
+
+func TestProduction_MiningColony(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	planet := otherPlanet(g, earth)
+	sp.Tech[engine.LS] = 99
+	mine := &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Mine", MiningBase: 5000}
+	g.Colonies = append(g.Colonies, mine)
+	earth.MiningBase, earth.ManufacturingBase = 0, 0
+	rm := engine.RawMaterial(sp, mine, planet) * (100 - engine.ProductionPenalty(sp, planet)) / 100 * g.EconomicEfficiency(mine) / 100
+	converted := rm * 2 / 3
+	if converted <= 0 {
+		t.Fatalf("mining: expected the mine to convert something: got %d\n", converted)
+	}
+
+	// Earth produces nothing, so it can only spend what the mine converted
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: fmt.Sprintf("START PRODUCTION\nPRODUCTION PL Mine\nPRODUCTION PL Earth\nRESEARCH %d BI\nEND\n", converted)})
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("mining: log: expected no errors: got %q\n", errs)
+	}
+	if ledger := g.LedgerFor(mine.Id); ledger.Converted != converted {
+		t.Errorf("mining: expected %d converted: got %d\n", converted, ledger.Converted)
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Treasury != converted {
+		t.Errorf("mining: expected Earth to draw %d from the treasury: got %d\n", converted, ledger.Treasury)
+	}
+	if sp.Treasury != 0 {
+		t.Errorf("mining: expected an empty treasury: got %d\n", sp.Treasury)
+	}
+}
+
+func TestProduction_Errors(t *testing.T) {
+	g, _, _ := newTestGame(t)
+	log := runOrders(t, g, `START PRODUCTION
+	BUILD 10 PD
+	PRODUCTION PL Mars
+	PRODUCTION PL Earth
+	BUILD 10 RM
+	RESEARCH 10 MI
+	PRODUCTION PL Earth
+END
+`)
+	for line, expect := range map[int]string{
+		2: "missing PRODUCTION order",
+		3: "no such planet",
+		5: "item can not be built",
+		7: "duplicate production order",
+	} {
+		if !strings.Contains(log[line], expect) {
+			t.Errorf("production: line %d: expected %q: got %q\n", line, expect, log[line])
+		}
+	}
+	if _, ok := log[6]; ok {
+		t.Errorf("production: line 6: expected no error: got %q\n", log[6])
+	}
+}
+
+func TestUpgradeAndRecycle(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: sp.Id, Class: "TR8", Name: "Old Timer", Tonnage: 80_000, Age: 17, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit},
+		{Id: 2, Species: sp.Id, Class: "CT", Name: "Dragon", Tonnage: 20_000, Age: 10, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit, Cargo: map[string]int{"CU": 5}},
+	}
+	earth.Inventory = map[string]int{"RM": 29, "PD": 20}
+
+	log := runOrders(t, g, `START PRODUCTION
+	PRODUCTION PL Earth
+	UPGRADE TR8 Old Timer
+	RECYCLE CT Dragon
+	RECYCLE 29 RM
+	rec 20 pd
+END
+`)
+	// recycling is reported with the amount returned
+	expect := map[int]string{4: "CT Dragon: recycled for 150", 5: "29 RM: recycled for 5", 6: "20 PD: recycled for 10"}
+	if len(log) != len(expect) {
+		t.Fatalf("production: log: expected %v: got %v\n", expect, log)
+	}
+	for line, text := range expect {
+		if log[line] != text {
+			t.Errorf("recycle: line %d: expected %q: got %q\n", line, text, log[line])
+		}
+	}
+
+	ledger := g.LedgerFor(earth.Id)
+	// upgrade: 17 * 800 / 40 = 340
+	// recycle: 3 * 200 / 4 * (60 - 10) / 50 = 150, plus 29 / 5 = 5 and 20 / 2 = 10
+	if ledger.Spent != 340 {
+		t.Errorf("upgrade: spent: expected 340: got %d\n", ledger.Spent)
+	} else if ledger.Recycled != 165 {
+		t.Errorf("recycle: expected 165: got %d\n", ledger.Recycled)
+	}
+	// ships still age during the turn they are upgraded
+	if ship := g.ShipNamed(sp.Id, "Old Timer"); ship.Age != 1 {
+		t.Errorf("upgrade: age: expected 1: got %d\n", ship.Age)
+	}
+	if g.ShipNamed(sp.Id, "Dragon") != nil {
+		t.Errorf("recycle: ship: expected removed\n")
+	}
+	if earth.Inventory["CU"] != 5 || earth.Inventory["PD"] != 0 {
+		t.Errorf("recycle: inventory: got %v\n", earth.Inventory)
+	}
+
+	// a partial upgrade: 40 * 100 / 800 = 5 turns
+	g.ShipNamed(sp.Id, "Old Timer").Age = 12
+	runOrders(t, g, "START PRODUCTION\nPRODUCTION PL Earth\nUPGRADE TR8 Old Timer, 100\nEND\n")
+	if ship := g.ShipNamed(sp.Id, "Old Timer"); ship.Age != 8 {
+		t.Errorf("upgrade: partial: age: expected 8: got %d\n", ship.Age)
+	}
+}
+
+func TestRecycle_RawMaterial(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	g.Ships = []*engine.Ship{{Id: 1, Species: sp.Id, Class: "TR1", Name: "Hauler", Tonnage: 10_000, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit, Cargo: map[string]int{"RM": 50}}}
+	earth.Inventory = map[string]int{"RM": 100_000}
+	sp.Treasury = 0
+
+	// Earth mines 600 and can use 600, so the stockpile isn't needed
+	// and selling it doesn't bring it back. Raw material from a recycled
+	// ship stays on the planet.
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nRECYCLE 100000 RM\nRECYCLE TR1 Hauler\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 2 || errs[0] != "100000 RM: recycled for 20000" || errs[1] != "TR1 Hauler: recycled for 90" {
+		t.Errorf("recycle: log: expected both to be recycled: got %q\n", errs)
+	}
+	// 600 produced less 3 for maintenance, 100000 / 5 = 20000 for the raw
+	// material and 3 * 100 / 4 * 60 / 50 = 90 for the transport
+	if sp.Treasury != 20_687 {
+		t.Errorf("recycle: treasury: expected 20687: got %d\n", sp.Treasury)
+	}
+	if earth.Inventory["RM"] != 50 {
+		t.Errorf("recycle: expected 50 RM left: got %d\n", earth.Inventory["RM"])
+	}
+
+	// without mining, production uses the stockpile, which can't be sold
+	earth.MiningBase = 0
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nRECYCLE 1 RM\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "RM: have 0: insufficient items") {
+		t.Errorf("recycle: used: expected insufficient items: got %q\n", errs)
+	}
+	if earth.Inventory["RM"] != 0 {
+		t.Errorf("recycle: used: expected the stockpile to be used: got %d\n", earth.Inventory["RM"])
+	}
+}
+
+func TestRecycle_TerraformingPlants(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	sp.Tech[engine.BI] = 40
+	earth.Inventory = map[string]int{"TP": 2}
+	log := runOrders(t, g, "START PRODUCTION\nPRODUCTION PL Earth\nRECYCLE 2 TP\nEND\n")
+	// a plant costs 50000 / 40 = 1250 at BI 40, and sells for half that
+	if log[3] != "2 TP: recycled for 1250" {
+		t.Errorf("recycle: expected 2 TP for 1250: got %q\n", log[3])
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Recycled != 1250 {
+		t.Errorf("recycle: expected 1250: got %d\n", ledger.Recycled)
+	}
+}
+
+func TestZeroQuantity(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	sp.Tech[engine.MA] = 20
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: sp.Id, Class: "TR8", Name: "Old Timer", Tonnage: 80_000, Age: 17, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit},
+		{Id: 2, Species: sp.Id, Class: "TR10", Name: "Unfinished", Tonnage: 100_000, Remaining: 10_000, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit},
+		{Id: 3, Species: sp.Id, Class: "BAS", Name: "Outpost", Tonnage: 10_000, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit},
+	}
+	earth.Inventory = map[string]int{"PD": 20}
+
+	// Earth has 600 less 80 for maintenance to spend, and the orders
+	// don't touch the treasury
+	for _, tc := range []struct {
+		order     string
+		spent     int
+		recycled  int
+		inventory string
+		n         int
+	}{
+		{order: "BUILD 0 PD", spent: 520, inventory: "PD", n: 540},
+		{order: "BUILD 0 CU", spent: 520, inventory: "CU", n: 520},
+		{order: "RECYCLE 0 PD", recycled: 10, inventory: "PD", n: 0},
+		{order: "UPGRADE TR8 Old Timer, 0", spent: 340},
+		{order: "CONTINUE TR10 Unfinished, 0", spent: 520},
+		{order: "CONTINUE BAS Outpost, 0", spent: 500},
+	} {
+		g.Log, earth.Inventory = nil, map[string]int{"PD": 20}
+		earth.AvailablePopulation, sp.Treasury = 1_000, 1_000
+		g.ShipNamed(sp.Id, "Old Timer").Age = 17
+		runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\n" + tc.order + "\nEND\n"})
+		for _, e := range orderErrors(g) {
+			if !strings.Contains(e, "recycled for") {
+				t.Errorf("%s: expected no errors: got %q\n", tc.order, e)
+			}
+		}
+		ledger := g.LedgerFor(earth.Id)
+		if ledger.Spent != tc.spent {
+			t.Errorf("%s: spent: expected %d: got %d\n", tc.order, tc.spent, ledger.Spent)
+		}
+		if ledger.Recycled != tc.recycled {
+			t.Errorf("%s: recycled: expected %d: got %d\n", tc.order, tc.recycled, ledger.Recycled)
+		}
+		if tc.inventory != "" && earth.Inventory[tc.inventory] != tc.n {
+			t.Errorf("%s: %s: expected %d: got %d\n", tc.order, tc.inventory, tc.n, earth.Inventory[tc.inventory])
+		}
+		// what wasn't spent goes to the treasury
+		if expect := 1_000 + 520 - tc.spent + tc.recycled; sp.Treasury != expect {
+			t.Errorf("%s: treasury: expected %d: got %d\n", tc.order, expect, sp.Treasury)
+		}
+	}
+	if ship := g.ShipNamed(sp.Id, "Unfinished"); ship.Remaining != 9_480 {
+		t.Errorf("continue: remaining: expected 9480: got %d\n", ship.Remaining)
+	}
+	// 520 pays for five increments of 10,000 tons at 100 each
+	if ship := g.ShipNamed(sp.Id, "Outpost"); ship.Tonnage != 60_000 {
+		t.Errorf("continue: starbase: expected 60000 tons: got %d\n", ship.Tonnage)
+	}
+
+	// nothing to recycle is still an error
+	g.Log, earth.Inventory = nil, nil
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nRECYCLE 0 PD\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "PD: have 0: insufficient items") {
+		t.Errorf("recycle: none: expected insufficient items: got %q\n", errs)
+	}
+}
+
+func TestBuild_DesignRules(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	earth.Shipyards = 2
+	log := runOrders(t, g, `START PRODUCTION
+	PRODUCTION PL Earth
+	BUILD	ES Escort, 100
+	BUILD	FF Frigate
+	BUILD	10 SU
+	BUILD	BAS Fortress, 250
+	BUILD	BAS Fortress
+	BUILD	BAS Fortress, 200
+END
+`)
+	for line, expect := range map[int]string{
+		4: "tonnage exceeds manufacturing limit",
+		5: "tech level too low",
+		6: "not a multiple of 100",
+		7: "starbase needs an amount",
+	} {
+		if !strings.Contains(log[line], expect) {
+			t.Errorf("build: line %d: expected %q: got %q\n", line, expect, log[line])
+		}
+	}
+	if len(log) != 4 {
+		t.Errorf("build: log: expected 4 errors: got %v\n", log)
+	}
+	if ship := g.ShipNamed(sp.Id, "Fortress"); ship == nil {
+		t.Fatalf("build: starbase: expected Fortress: got nil\n")
+	} else if !ship.IsStarbase() || ship.Tonnage != 20_000 || ship.IsUnderConstruction() || ship.Status != engine.InOrbit {
+		t.Errorf("build: starbase: expected 20,000 tons in orbit: got %d tons %s\n", ship.Tonnage, ship.Status)
+	} else if ship.CarryingCapacity() != 20 {
+		t.Errorf("build: starbase: capacity: expected 20: got %d\n", ship.CarryingCapacity())
+	}
+
+	// adding tonnage averages the age of the contributions
+	g.ShipNamed(sp.Id, "Fortress").Age = 10
+	log = runOrders(t, g, "START PRODUCTION\nPRODUCTION PL Earth\nCONTINUE BAS Fortress, 300\nCONTINUE BAS Fortress, 100\nEND\n")
+	if !strings.Contains(log[4], "tonnage exceeds manufacturing limit") {
+		t.Errorf("continue: line 4: expected tonnage limit: got %q\n", log[4])
+	}
+	if ship := g.ShipNamed(sp.Id, "Fortress"); ship.Tonnage != 50_000 || ship.Age != 5 {
+		t.Errorf("continue: starbase: expected 50,000 tons, age 5: got %d tons, age %d\n", ship.Tonnage, ship.Age)
+	}
+
+	// without gravitics, only sub-light ships can be built
+	sp.Tech[engine.GV] = 0
+	log = runOrders(t, g, "START PRODUCTION\nPRODUCTION PL Earth\nBUILD PB Scout\nBUILD PBS Scout\nEND\n")
+	if !strings.Contains(log[3], "only sub-light ships") || len(log) != 1 {
+		t.Errorf("build: sub-light: expected error on line 3 only: got %v\n", log)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+	"sort"
+)
+
+// repairTonnage is the tonnage whose age one damage repair unit reduces by a turn.
+const repairTonnage = 160_000
+
+// repairUnits returns the damage repair units a ship needs to reduce its age
+// by the given number of turns.
+func repairUnits(ship *Ship, turns int) int {
+	return (turns*ship.Tonnage + repairTonnage - 1) / repairTonnage
+}
+
+// repairShip uses n damage repair units on a ship. Each unit reduces the
+// age by 160,000 divided by the tonnage, dropping fractions, but never
+// below zero.
+func repairShip(ship *Ship, n int) {
+	ship.Age = max(0, ship.Age-repairTonnage*n/ship.Tonnage)
+}
+
+// repair uses the damage repair units carried by a ship, or pooled by all
+// of the species' ships in a sector, to reduce the ages of ships in the
+// field. Without a count, the ship must carry every unit needed to bring
+// its age to zero. A count of zero uses the units on board, but no more
+// than needed. A sector repairs the oldest ships first, down to the
+// desired age, if one is given.
+func repair(t *Turn, sp *Species, cmd *orders.Command) error {
+	if cmd.Args[0].Kind == orders.Number {
+		c := Coords{X: cmd.Args[0].Number, Y: cmd.Args[1].Number, Z: cmd.Args[2].Number}
+		desired := 0
+		if len(cmd.Args) == 4 {
+			desired = cmd.Args[3].Number
+		}
+		return t.repairAt(sp, c, desired)
+	}
+
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if ship.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", ship, ErrUnderConstruction)
+	}
+	have, needed := ship.Cargo["DR"], repairUnits(ship, ship.Age)
+	n := needed
+	if len(cmd.Args) == 2 {
+		if n = cmd.Args[1].Number; n == 0 {
+			n = min(have, needed)
+		}
+	}
+	if n > have {
+		return fmt.Errorf("%s: have %d DR: %w", ship, have, ErrInsufficientItems)
+	}
+	addItems(&ship.Cargo, "DR", -n)
+	repairShip(ship, n)
+	return nil
+}
+
+// repairAt pools the damage repair units of the species' ships in a sector
+// and repairs the oldest ships first. Leftover units stay on the ships
+// that were carrying them.
+func (t *Turn) repairAt(sp *Species, c Coords, desired int) error {
+	var ships []*Ship
+	pool := 0
+	for _, ship := range t.Game.Ships {
+		if ship.Species == sp.Id && ship.Coords == c && !ship.IsUnderConstruction() {
+			ships = append(ships, ship)
+			pool += ship.Cargo["DR"]
+		}
+	}
+	if len(ships) == 0 {
+		return fmt.Errorf("%s: %w", c, ErrNoForces)
+	}
+	used := 0
+	sort.SliceStable(ships, func(i, j int) bool { return ships[i].Age > ships[j].Age })
+	for _, ship := range ships {
+		if ship.Age <= desired {
+			break
+		}
+		n := min(pool-used, repairUnits(ship, ship.Age-desired))
+		repairShip(ship, n)
+		ship.Age = max(ship.Age, desired)
+		used += n
+	}
+	for _, ship := range ships {
+		n := min(used, ship.Cargo["DR"])
+		addItems(&ship.Cargo, "DR", -n)
+		used -= n
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+const (
+	// estimateCost is the cost of an ESTIMATE order.
+	estimateCost = 25
+
+	// battleExperience is the military experience a species gains from
+	// taking part in a battle.
+	battleExperience = 5
+)
+
+// Estimate is a species' estimate of another species' tech levels.
+type Estimate struct {
+	Species int        `json:"species"`
+	Of      int        `json:"of"`
+	Tech    TechLevels `json:"tech"`
+}
+
+// researchCost returns the average cost of raising a tech level by one.
+func researchCost(level int) int {
+	return level * level
+}
+
+// taughtCost returns the cost of raising a tech level by one using
+// knowledge taught by another species: the average cost less 25%, and
+// at least 1 so that getting started in a tech isn't free.
+func taughtCost(level int) int {
+	cost := researchCost(level)
+	return max(1, cost-cost/4)
+}
+
+// applyKnowledge uses research funds to raise a tech level up to the
+// knowledge taught by other species. There is no luck involved. It
+// returns the funds that are left for normal research.
+func (t *Turn) applyKnowledge(sp *Species, tech Tech, funds int) int {
+	from := sp.Tech[tech]
+	for sp.Tech[tech] < sp.Knowledge[tech] && taughtCost(sp.Tech[tech]) <= funds {
+		funds -= taughtCost(sp.Tech[tech])
+		sp.Tech[tech]++
+	}
+	if sp.Tech[tech] != from {
+		t.Logf(sp.Id, 0, "%s: tech level raised from %d to %d using transferred knowledge", tech, from, sp.Tech[tech])
+	}
+	return funds
+}
+
+// experience returns the points a species earned this turn by using its
+// technology: mining and manufacturing from the raw material and
+// production capacity of its colonies, military from battles, gravitics
+// from ship movement, and life support from colonies that need it.
+func (t *Turn) experience(sp *Species) TechLevels {
+	var xp TechLevels
+	for _, ledger := range t.Game.Ledgers {
+		if colony := t.Game.ColonyById(ledger.Colony); colony != nil && colony.Species == sp.Id {
+			xp[MI] += ledger.RawMaterial / 100
+			xp[MA] += ledger.Capacity / 100
+		}
+	}
+	for _, b := range t.Game.Battles {
+		for _, id := range b.Species {
+			if id == sp.Id {
+				xp[ML] += battleExperience
+			}
+		}
+	}
+	for _, ship := range t.Game.Ships {
+		if ship.Species == sp.Id && t.moved[ship.Id] {
+			xp[GV]++
+		}
+	}
+	for _, colony := range t.Game.Colonies {
+		if colony.Species == sp.Id && LifeSupportNeeded(sp, t.Game.Galaxy.Planet(colony.Planet)) > 0 {
+			xp[LS]++
+		}
+	}
+	return xp
+}
+
+// advanceTechs turns research and experience into tech levels.
+// Each level costs the square of the current level, varied by up to 25%
+// either way, and points left over are kept for the next turn. Techs at
+// level zero can't advance until they are taught.
+func advanceTechs(t *Turn) {
+	for _, id := range t.SpeciesIds() {
+		sp := t.Game.SpeciesById(id)
+		var spent TechLevels
+		if t.research[id] != nil {
+			spent = *t.research[id]
+		}
+		xp := t.experience(sp)
+		for tech := MI; tech < NumTechs; tech++ {
+			if sp.Tech[tech] == 0 {
+				continue
+			}
+			from, points := sp.Tech[tech], sp.Experience[tech]+spent[tech]+xp[tech]
+			for {
+				cost := max(1, researchCost(sp.Tech[tech])*(75+t.RNG.Intn(51))/100)
+				if points < cost {
+					break
+				}
+				points -= cost
+				sp.Tech[tech]++
+			}
+			sp.Experience[tech] = points
+			if sp.Tech[tech] != from {
+				t.Logf(sp.Id, 0, "%s: tech level raised from %d to %d", tech, from, sp.Tech[tech])
+			}
+		}
+	}
+}
+
+// estimate estimates another species' tech levels. The margin of error
+// for each tech is the amount the other species is ahead, and at least 1.
+func estimate(t *Turn, sp *Species, cmd *orders.Command) error {
+	other := t.Game.SpeciesNamed(cmd.Args[0].Name)
+	if other == nil {
+		return ErrNoSuchSpecies
+	} else if !sp.HasMet(other.Id) {
+		return fmt.Errorf("%s: %w", other, ErrNotMet)
+	}
+	if err := t.spend(sp, cmd, estimateCost); err != nil {
+		return err
+	}
+	e := &Estimate{Species: sp.Id, Of: other.Id}
+	for tech := MI; tech < NumTechs; tech++ {
+		if other.Tech[tech] == 0 {
+			continue
+		}
+		margin := max(1, other.Tech[tech]-sp.Tech[tech])
+		e.Tech[tech] = max(0, other.Tech[tech]+t.RNG.Roll(2*margin+1)-(margin+1))
+	}
+	t.Game.Estimates = append(t.Game.Estimates, e)
+	return nil
+}
+
+// teach gives another species knowledge of a tech, up to the teacher's
+// level or the optional limit. The recipient still has to research the
+// tech to use the knowledge. Species can't teach a species that either
+// one has declared an enemy.
+func teach(t *Turn, sp *Species, cmd *orders.Command) error {
+	tech, ok := ParseTech(cmd.Args[0].Class)
+	if !ok {
+		return fmt.Errorf("%s: %w", cmd.Args[0].Class, ErrInvalidTech)
+	}
+	level := sp.Tech[tech]
+	if len(cmd.Args) == 3 {
+		level = min(level, cmd.Args[1].Number)
+	}
+	other := t.Game.SpeciesNamed(cmd.Args[len(cmd.Args)-1].Name)
+	if other == nil {
+		return ErrNoSuchSpecies
+	} else if !sp.HasMet(other.Id) {
+		return fmt.Errorf("%s: %w", other, ErrNotMet)
+	} else if t.Game.declaredEnemy(sp.Id, other.Id) || t.Game.declaredEnemy(other.Id, sp.Id) {
+		return fmt.Errorf("%s: %w", other, ErrDeclaredEnemy)
+	}
+	if level > other.Tech[tech] && level > other.Knowledge[tech] {
+		other.Knowledge[tech] = level
+		t.Logf(other.Id, 0, "%s: %s taught us up to level %d", tech, sp, level)
+	}
+	return nil
+}
+
+// meetSpecies records which species have met. A species meets another
+// when it can see the other's ships or colonies and knows who owns them.
+func meetSpecies(t *Turn) {
+	g := t.Game
+	for _, sp := range g.Species {
+		for _, ship := range g.Ships {
+			if ship.Species != sp.Id && !ship.IsDistorted() && g.CanSeeShip(sp, ship) {
+				sp.Meet(ship.Species)
+			}
+		}
+		for _, colony := range g.Colonies {
+			if colony.Species != sp.Id && g.CanSeeColony(sp, colony) {
+				sp.Meet(colony.Species)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestResearch(t *testing.T) {
+	g, sp, _ := newTestGame(t)
+	sp.Tech[engine.GV], sp.Knowledge[engine.GV] = 18, 20
+	sp.Tech[engine.MI], sp.Tech[engine.MA] = 50, 50 // enough production for the research
+	log := runOrders(t, g, `START PRODUCTION
+	PRODUCTION PL Earth
+	RESEARCH 750 GV
+	RESEARCH 100 BI
+END
+`)
+	if len(log) != 0 {
+		t.Fatalf("research: log: expected no errors: got %v\n", log)
+	}
+	// the example from the manual: 243 and 271 to raise GV from 18 to 20,
+	// leaving 236 for research that can't pay for the next level
+	if sp.Tech[engine.GV] != 20 || sp.Experience[engine.GV] != 236 {
+		t.Errorf("research: GV: expected 20 with 236 points: got %d with %d\n", sp.Tech[engine.GV], sp.Experience[engine.GV])
+	}
+	if sp.Tech[engine.BI] <= 3 {
+		t.Errorf("research: BI: expected an increase from 3: got %d\n", sp.Tech[engine.BI])
+	}
+	var lines []string
+	for _, e := range g.Log {
+		if e.Species == 1 && strings.Contains(e.Text, "tech level raised") {
+			lines = append(lines, e.Text)
+		}
+	}
+	if len(lines) < 2 || lines[0] != "GV: tech level raised from 18 to 20 using transferred knowledge" || !strings.HasPrefix(lines[1], "BI: tech level raised from 3 to ") {
+		t.Errorf("research: log: expected itemized tech increases: got %q\n", lines)
+	}
+}
+
+func TestEstimateAndTeach(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	sp, klingon := g.SpeciesById(1), g.SpeciesById(2)
+	production := map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nESTIMATE SP Klingon\nESTIMATE SP Nobody\nEND\n"}
+	postArrival := map[int]string{1: "START POST-ARRIVAL\nTEACH GV SP Klingon\nEND\n"}
+
+	// species have to meet first
+	runStep(t, g, engine.DefaultSteps()[3], production)
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "species not met") || !strings.Contains(errs[1], "no such species") {
+		t.Errorf("estimate: log: expected species not met: got %q\n", errs)
+	}
+	runStep(t, g, engine.DefaultSteps()[4], postArrival)
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "species not met") {
+		t.Errorf("teach: log: expected species not met: got %q\n", errs)
+	}
+	runStep(t, g, engine.DefaultSteps()[6], nil)
+	if !sp.HasMet(2) || !klingon.HasMet(1) {
+		t.Fatalf("meet: expected the species to have met\n")
+	}
+
+	production[1] = "START PRODUCTION\nPRODUCTION PL Earth\nESTIMATE SP Klingon\nEND\n"
+	runStep(t, g, engine.DefaultSteps()[3], production)
+	if len(g.Estimates) != 1 {
+		t.Fatalf("estimate: expected 1 estimate: got %d\n", len(g.Estimates))
+	}
+	e := g.Estimates[0]
+	if e.Species != 1 || e.Of != 2 {
+		t.Errorf("estimate: expected SP 1 of SP 2: got %+v\n", e)
+	}
+	for tech := engine.MI; tech < engine.NumTechs; tech++ {
+		margin := max(1, klingon.Tech[tech]-sp.Tech[tech])
+		if klingon.Tech[tech] == 0 && e.Tech[tech] != 0 {
+			t.Errorf("estimate: %s: expected 0: got %d\n", tech, e.Tech[tech])
+		} else if e.Tech[tech] < klingon.Tech[tech]-margin || e.Tech[tech] > klingon.Tech[tech]+margin {
+			t.Errorf("estimate: %s: expected %d±%d: got %d\n", tech, klingon.Tech[tech], margin, e.Tech[tech])
+		}
+	}
+	if ledger := g.LedgerFor(g.ColonyNamed(1, "Earth").Id); ledger.Spent != 25 {
+		t.Errorf("estimate: expected 25 spent: got %d\n", ledger.Spent)
+	}
+
+	// declared enemies can't be taught
+	g.Diplomacy = engine.Diplomacy{2: {1: engine.Enemy}}
+	runStep(t, g, engine.DefaultSteps()[4], postArrival)
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "declared enemy") {
+		t.Errorf("teach: log: expected declared enemy: got %q\n", errs)
+	}
+	if klingon.Knowledge[engine.GV] != 0 {
+		t.Errorf("teach: expected no knowledge: got %d\n", klingon.Knowledge[engine.GV])
+	}
+
+	g.Diplomacy = nil
+	runStep(t, g, engine.DefaultSteps()[4], postArrival)
+	if klingon.Knowledge[engine.GV] != sp.Tech[engine.GV] {
+		t.Errorf("teach: expected knowledge %d: got %d\n", sp.Tech[engine.GV], klingon.Knowledge[engine.GV])
+	}
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{2: "START PRODUCTION\nPRODUCTION PL Kronos\nRESEARCH 100 GV\nEND\n"})
+	if klingon.Tech[engine.GV] <= 1 {
+		t.Errorf("teach: expected GV to rise from 1 using the knowledge: got %d\n", klingon.Tech[engine.GV])
+	}
+}
+
+func TestResearch_TaughtFromZero(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	sp, klingon := g.SpeciesById(1), g.SpeciesById(2)
+	sp.Tech[engine.BI] = 10
+	steps := engine.DefaultSteps()
+	research := func(n int) map[int]string {
+		return map[int]string{2: fmt.Sprintf("START PRODUCTION\nPRODUCTION PL Kronos\nRESEARCH %d BI\nEND\n", n)}
+	}
+
+	// a tech at zero has to be taught before it can be researched
+	runStep(t, g, steps[3], research(100))
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "must be taught first") {
+		t.Errorf("research: log: expected BI to need teaching: got %q\n", errs)
+	}
+	runStep(t, g, steps[6], nil) // the species meet
+	runStep(t, g, steps[4], map[int]string{1: "START POST-ARRIVAL\nTEACH BI SP Klingon\nEND\n"})
+	if klingon.Knowledge[engine.BI] != 10 {
+		t.Fatalf("teach: expected knowledge of 10: got %d\n", klingon.Knowledge[engine.BI])
+	}
+
+	// the first level costs 1, and the rest 1 + 3 + 7 + 12 + 19 + 27 + 37 + 48 + 61 = 215
+	runStep(t, g, steps[3], research(1))
+	if klingon.Tech[engine.BI] != 1 {
+		t.Errorf("research: expected BI 1: got %d\n", klingon.Tech[engine.BI])
+	}
+	runStep(t, g, steps[3], research(215))
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("research: log: expected no errors: got %q\n", errs)
+	}
+	if klingon.Tech[engine.BI] != 10 {
+		t.Errorf("research: expected BI 10: got %d\n", klingon.Tech[engine.BI])
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+// RNG is the random number generator used for every decision the engine
+// makes. It is a SplitMix64 generator. The whole state is a single number,
+// so a generator is easy to record and to restart from a known point.
+//
+// The engine never uses the global generators from math/rand. Anything
+// random must come from an RNG seeded from the game, so that running a
+// turn again with the same inputs gives exactly the same results.
+type RNG struct {
+	state uint64
+}
+
+// NewRNG returns a generator for the seed.
+func NewRNG(seed uint64) *RNG {
+	return &RNG{state: seed}
+}
+
+// TurnSeed returns the seed for the generator used to run a turn.
+// It mixes the game's seed with the turn number so that each turn gets
+// a different sequence.
+func TurnSeed(gameSeed int64, turn int) uint64 {
+	return mix64(uint64(gameSeed) ^ mix64(uint64(turn)+0x9e3779b97f4a7c15))
+}
+
+// Uint64 returns the next number in the sequence.
+func (r *RNG) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	return mix64(r.state)
+}
+
+// Intn returns a number in the range 0 to n-1. It panics if n is not positive.
+func (r *RNG) Intn(n int) int {
+	if n <= 0 {
+		panic("engine: RNG.Intn: invalid argument")
+	}
+	// reject values from the short, final block to avoid modulo bias
+	limit := ^uint64(0) - ^uint64(0)%uint64(n)
+	for {
+		if v := r.Uint64(); v < limit {
+			return int(v % uint64(n))
+		}
+	}
+}
+
+// Roll returns a number in the range 1 to n, like rolling an n-sided die.
+func (r *RNG) Roll(n int) int {
+	return r.Intn(n) + 1
+}
+
+// Percent returns true with the given percent chance.
+func (r *RNG) Percent(pct int) bool {
+	return r.Intn(100) < pct
+}
+
+// Float64 returns a number in the range [0.0, 1.0).
+func (r *RNG) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// mix64 is the SplitMix64 output function.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/mdhender/fh/internal/engine"
+	"testing"
+)
+
+func TestRNG(t *testing.T) {
+	a, b := engine.NewRNG(42), engine.NewRNG(42)
+	for i := 0; i < 1000; i++ {
+		if x, y := a.Uint64(), b.Uint64(); x != y {
+			t.Fatalf("RNG: same seed: roll %d: expected %d: got %d\n", i, x, y)
+		}
+	}
+
+	r, counts := engine.NewRNG(7), make([]int, 6)
+	for i := 0; i < 6000; i++ {
+		n := r.Roll(6)
+		if n < 1 || n > 6 {
+			t.Fatalf("Roll: expected 1..6: got %d\n", n)
+		}
+		counts[n-1]++
+	}
+	for i, n := range counts {
+		if n < 800 || n > 1200 {
+			t.Errorf("Roll: %d: expected about 1000: got %d\n", i+1, n)
+		}
+	}
+
+	if engine.TurnSeed(1, 1) == engine.TurnSeed(1, 2) || engine.TurnSeed(1, 1) == engine.TurnSeed(2, 1) {
+		t.Errorf("TurnSeed: expected different seeds for different turns and games\n")
+	}
+}
+
+func TestRunTurn_Repeatable(t *testing.T) {
+	// a step that uses the turn's random numbers
+	step := engine.Step{Name: "dice", Run: func(t *engine.Turn) error {
+		for _, id := range t.SpeciesIds() {
+			t.Logf(id, 0, "rolled %d", t.RNG.Roll(100))
+		}
+		return nil
+	}}
+
+	run := func() []byte {
+		g, err := engine.NewGame("test", 3, 99)
+		if err != nil {
+			t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+		}
+		g.Species = []*engine.Species{{Id: 1, Name: "Humans"}, {Id: 2, Name: "Klingons"}}
+		if err := engine.RunTurn(g, nil, engine.WithSteps(step)); err != nil {
+			t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+		} else if g.TurnSeed != engine.TurnSeed(99, 0) {
+			t.Errorf("RunTurn: turn seed: expected %d: got %d\n", engine.TurnSeed(99, 0), g.TurnSeed)
+		}
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("Marshal: err: expected nil: got %v\n", err)
+		}
+		return data
+	}
+	if a, b := run(), run(); !bytes.Equal(a, b) {
+		t.Errorf("RunTurn: same inputs: expected identical results\n")
+	}
+}
+
+func TestNewGame_LastTurn(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		g, err := engine.NewGame("test", 3, seed)
+		if err != nil {
+			t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+		} else if g.LastTurn < 20 || g.LastTurn > 100 {
+			t.Errorf("NewGame: seed %d: last turn: expected 20..100: got %d\n", seed, g.LastTurn)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// destroy blows up one of the species' ships or starbases and everything
+// on board. Nothing is salvaged, and the ship doesn't have to be at a
+// planet. Aliens who can see the ship are told that it is gone.
+func destroy(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	var witnesses []*Species
+	for _, id := range t.SpeciesIds() {
+		if other := t.Game.SpeciesById(id); other != sp && t.Game.CanSeeShip(other, ship) {
+			witnesses = append(witnesses, other)
+		}
+	}
+	t.Game.removeShip(ship)
+	t.Logf(sp.Id, cmd.Line, "%s: destroyed", ship)
+	for _, other := range witnesses {
+		t.Logf(other.Id, 0, "%s %s: destroyed by its crew at %s", t.Game.Identify(other, ship), ship, ship.Coords)
+	}
+	return nil
+}
+
+// disband marks a colony to be salvaged at the end of the turn. Its mining
+// and manufacturing bases are turned back into colonist units and colonial
+// units, the reverse of installing them, but only half of the colonial
+// units are recovered. Until the end of the turn the units can still be
+// transferred away. Home planets and colonies under siege can't be
+// disbanded.
+func disband(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony := t.Game.ColonyNamed(sp.Id, cmd.Args[0].Name)
+	if colony == nil {
+		return fmt.Errorf("PL %s: %w", cmd.Args[0].Name, ErrNoSuchPlanet)
+	} else if colony.IsHome {
+		return fmt.Errorf("PL %s: home planet: %w", colony.Name, ErrNotAllowed)
+	} else if len(t.sieges(colony)) != 0 {
+		return fmt.Errorf("PL %s: under siege: %w", colony.Name, ErrNotAllowed)
+	} else if colony.Disbanded {
+		return fmt.Errorf("PL %s: %w", colony.Name, ErrAlreadyUsed)
+	}
+	addItems(&colony.Inventory, "CU", colony.MiningBase+colony.ManufacturingBase)
+	addItems(&colony.Inventory, "IU", colony.MiningBase/2)
+	addItems(&colony.Inventory, "AU", colony.ManufacturingBase/2)
+	colony.MiningBase, colony.ManufacturingBase, colony.Disbanded = 0, 0, true
+	return nil
+}
+
+// isSalvage returns true if the ship will be salvaged with a disbanded
+// colony: it is landed or under construction on the planet, or is a
+// starbase in orbit around it. Salvage can't move or go into orbit.
+func (g *Game) isSalvage(ship *Ship) bool {
+	colony := g.colonyOn(ship.Species, ship.Planet)
+	return colony != nil && colony.Disbanded && (ship.Status == Landed || ship.IsStarbase() || ship.IsUnderConstruction())
+}
+
+// salvage turns what is left of each disbanded colony into economic units
+// for the treasury. Items on the planet are sold at their recycle value.
+// Salvaged ships and their cargo are sold at half of theirs, including any
+// that arrived after the colony was disbanded. The colony is then
+// forgotten, name and all, and aliens who could see it are told.
+func salvage(t *Turn) {
+	for _, colony := range append([]*Colony{}, t.Game.Colonies...) {
+		if !colony.Disbanded {
+			continue
+		}
+		sp := t.Game.SpeciesById(colony.Species)
+		var witnesses []*Species
+		for _, id := range t.SpeciesIds() {
+			if other := t.Game.SpeciesById(id); other != sp && t.Game.CanSeeColony(other, colony) {
+				witnesses = append(witnesses, other)
+			}
+		}
+		value := cargoValue(sp, colony.Inventory)
+		for _, ship := range append([]*Ship{}, t.Game.Ships...) {
+			if ship.Species == sp.Id && t.Game.isSalvage(ship) && ship.Planet == colony.Planet {
+				value += (ship.recycleValue() + cargoValue(sp, ship.Cargo)) / 2
+				t.Logf(sp.Id, 0, "%s: salvaged", ship)
+				t.Game.removeShip(ship)
+			}
+		}
+		t.Logf(sp.Id, 0, "PL %s: disbanded: salvaged for %d", colony.Name, value)
+		if value > 0 {
+			t.audit(sp, value, "salvaged from PL %s", colony.Name)
+		}
+		for _, other := range witnesses {
+			t.Logf(other.Id, 0, "%s abandoned their colony on %s", sp, t.Game.location(colony))
+		}
+		t.Game.removeColony(colony)
+	}
+}
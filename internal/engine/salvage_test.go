@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+// logFor returns the species' turn log entries that aren't for orders.
+func logFor(g *engine.Game, species int) []string {
+	var lines []string
+	for _, e := range g.Log {
+		if e.Species == species && e.Line == 0 {
+			lines = append(lines, e.Text)
+		}
+	}
+	return lines
+}
+
+func TestDestroy(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "CT", Name: "Scout", Tonnage: 20_000, Cargo: map[string]int{"CU": 10}},
+		&engine.Ship{Species: 2, Class: "CT", Name: "Watcher", Tonnage: 20_000},
+	)
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{1: "START PRE-DEPARTURE\nDESTROY CT Scout\nDESTROY CT Nobody\nEND\n"})
+	errs := orderErrors(g)
+	if len(errs) != 2 || errs[0] != "CT Scout: destroyed" || !strings.Contains(errs[1], "no such ship") {
+		t.Errorf("destroy: log: expected the ship destroyed and 1 error: got %q\n", errs)
+	}
+	if g.ShipNamed(1, "Scout") != nil {
+		t.Errorf("destroy: expected the ship to be gone\n")
+	}
+	expect := fmt.Sprintf("SP Humanoid CT Scout: destroyed by its crew at %s", coords)
+	if got := logFor(g, 2); len(got) != 1 || got[0] != expect {
+		t.Errorf("destroy: witness: expected %q: got %q\n", expect, got)
+	}
+}
+
+func TestDisband(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Lander", Tonnage: 10_000},
+		&engine.Ship{Species: 1, Class: "BAS", Name: "Dock", Tonnage: 20_000},
+		&engine.Ship{Species: 1, Class: "CT", Name: "Scout", Tonnage: 20_000},
+		&engine.Ship{Species: 2, Class: "CT", Name: "Watcher", Tonnage: 20_000},
+	)
+	sp := g.SpeciesById(1)
+	sp.Treasury = 0
+	earth := g.ColonyNamed(1, "Earth")
+	planet := otherPlanet(g, earth)
+	mars := &engine.Colony{Id: 99, Species: 1, Planet: planet.Id, Name: "Mars", MiningBase: 213, ManufacturingBase: 100, Inventory: map[string]int{"RM": 29}}
+	g.Colonies = append(g.Colonies, mars)
+	g.ShipNamed(1, "Lander").Planet, g.ShipNamed(1, "Lander").Status = planet.Id, engine.Landed
+	g.ShipNamed(1, "Dock").Planet, g.ShipNamed(1, "Dock").Status = planet.Id, engine.InOrbit
+	g.ShipNamed(1, "Scout").Planet, g.ShipNamed(1, "Scout").Status = planet.Id, engine.InOrbit
+	next := neighbor(g, coords)
+	steps := engine.DefaultSteps()
+
+	// the bases are turned back into units, and ships that are salvage can't leave
+	runSteps(t, g, []engine.Step{steps[1], steps[2]}, map[int]string{1: fmt.Sprintf(
+		"START PRE-DEPARTURE\nDISBAND PL Earth\nDISBAND PL Mars\nEND\nSTART JUMPS\nMOVE TR1 Lander, %s\nMOVE CT Scout, %s\nEND\n", next, next)})
+	errs := orderErrors(g)
+	if len(errs) != 2 || !strings.Contains(errs[0], "home planet") || !strings.Contains(errs[1], "salvage") {
+		t.Errorf("disband: log: expected 2 errors: got %q\n", errs)
+	}
+	if !mars.Disbanded || mars.MiningBase != 0 || mars.ManufacturingBase != 0 {
+		t.Errorf("disband: expected the colony to be disbanded: got %+v\n", mars)
+	}
+	if inv := mars.Inventory; inv["CU"] != 313 || inv["IU"] != 106 || inv["AU"] != 50 {
+		t.Errorf("disband: expected 313 CU, 106 IU and 50 AU: got %v\n", inv)
+	}
+	if ship := g.ShipNamed(1, "Scout"); ship.Coords != next {
+		t.Errorf("disband: expected the corvette to leave: got %+v\n", ship)
+	}
+
+	// items: 5 + 156 + 53 + 25 = 239, transport: 90 / 2 = 45, starbase: 180 / 2 = 90
+	runStep(t, g, steps[6], nil)
+	if g.ColonyNamed(1, "Mars") != nil || g.ShipNamed(1, "Lander") != nil || g.ShipNamed(1, "Dock") != nil {
+		t.Errorf("disband: expected the colony and the salvage to be gone\n")
+	}
+	if sp.Treasury != 374 {
+		t.Errorf("disband: expected 374 in the treasury: got %d\n", sp.Treasury)
+	}
+	expect := fmt.Sprintf("SP Humanoid abandoned their colony on planet %d at %s", planet.Orbit, coords)
+	if got := logFor(g, 2); len(got) != 1 || got[0] != expect {
+		t.Errorf("disband: witness: expected %q: got %q\n", expect, got)
+	}
+}
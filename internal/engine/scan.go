@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// Scan is the result of a ship's sensor scan of its location.
+// The life support needed and habitability of each planet are for the
+// species that made the scan.
+type Scan struct {
+	Species  int           `json:"species"`
+	Coords   Coords        `json:"coords"`
+	Star     string        `json:"star,omitempty"` // spectral class, empty if there is no star system
+	Wormhole bool          `json:"wormhole,omitempty"`
+	Planets  []*PlanetScan `json:"planets,omitempty"`
+}
+
+// PlanetScan is what a scan shows about a planet.
+type PlanetScan struct {
+	Orbit            int        `json:"orbit"`
+	Diameter         int        `json:"diameter"`
+	Gravity          int        `json:"gravity"`
+	TemperatureClass int        `json:"tc"`
+	PressureClass    int        `json:"pc"`
+	MiningDifficulty int        `json:"md"`
+	LSN              int        `json:"lsn"`
+	Habitability     int        `json:"habitability"` // percent, see Habitability
+	Atmosphere       Atmosphere `json:"atmosphere,omitempty"`
+}
+
+// ScanAt returns the scan of a location for a species.
+func (g *Game) ScanAt(sp *Species, c Coords) *Scan {
+	scan := &Scan{Species: sp.Id, Coords: c}
+	star := g.Galaxy.StarAt(c)
+	if star == nil {
+		return scan
+	}
+	scan.Star, scan.Wormhole = star.SpectralClass(), star.Wormhole != 0
+	for _, planet := range star.Planets {
+		score, lsn := Habitability(sp, planet)
+		scan.Planets = append(scan.Planets, &PlanetScan{
+			Orbit:            planet.Orbit,
+			Diameter:         planet.Diameter,
+			Gravity:          planet.Gravity,
+			TemperatureClass: planet.TemperatureClass,
+			PressureClass:    planet.PressureClass,
+			MiningDifficulty: planet.MiningDifficulty,
+			LSN:              lsn,
+			Habitability:     score,
+			Atmosphere:       planet.Atmosphere,
+		})
+	}
+	return scan
+}
+
+// Lines returns the scan in the format used on status reports.
+func (s *Scan) Lines() []string {
+	if s.Star == "" {
+		return []string{fmt.Sprintf("Coordinates:    x = %d   y = %d  z = %d  no star system.", s.Coords.X, s.Coords.Y, s.Coords.Z)}
+	}
+	lines := []string{
+		fmt.Sprintf("Coordinates:    x = %d   y = %d  z = %d  stellar type = %3s  %d planets.", s.Coords.X, s.Coords.Y, s.Coords.Z, s.Star, len(s.Planets)),
+		"",
+		"                Temp  Press Mining",
+		"   #  Dia  Grav Class Class  Diff  LSN  Hab  Atmosphere",
+		"  --------------------------------------------------------------------------",
+	}
+	for _, p := range s.Planets {
+		lines = append(lines, fmt.Sprintf("  %2d  %3d  %d.%02d  %2d    %2d    %d.%02d  %3d  %3d%%  %s",
+			p.Orbit, p.Diameter, p.Gravity/100, p.Gravity%100, p.TemperatureClass, p.PressureClass,
+			p.MiningDifficulty/100, p.MiningDifficulty%100, p.LSN, p.Habitability, p.Atmosphere))
+	}
+	if s.Wormhole {
+		lines = append(lines, "", "This star system is the terminus of a natural wormhole.")
+	}
+	return lines
+}
+
+// scan has a ship scan its location.
+func scan(t *Turn, sp *Species, cmd *orders.Command) error {
+	ship, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	t.Game.Scans = append(t.Game.Scans, t.Game.ScanAt(sp, ship.Coords))
+	return nil
+}
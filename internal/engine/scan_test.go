@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	g, coords := newBattleGame(t, &engine.Ship{Species: 1, Class: "TR1", Name: "Intrepid", Tonnage: 10_000})
+	earth := g.ColonyNamed(1, "Earth")
+	log := runOrders(t, g, "START PRE-DEPARTURE\nSCAN TR1 Intrepid\nSCAN TR1 Nowhere\nEND\n")
+	if len(log) != 1 || !strings.Contains(log[3], "no such ship") {
+		t.Errorf("scan: log: expected an error on line 3: got %v\n", log)
+	}
+	if len(g.Scans) != 1 {
+		t.Fatalf("scan: expected 1 scan: got %d\n", len(g.Scans))
+	}
+	scan := g.Scans[0]
+	if scan.Species != 1 || scan.Coords != coords || len(scan.Planets) != len(g.Galaxy.StarAt(coords).Planets) {
+		t.Errorf("scan: expected every planet at %s: got %+v\n", coords, scan)
+	}
+	// the home planet is perfect for the species that lives there
+	home := scan.Planets[g.Galaxy.Planet(earth.Planet).Orbit-1]
+	if home.LSN != 0 || home.Habitability != 100 {
+		t.Errorf("scan: home: expected LSN 0 and 100%%: got %d and %d%%\n", home.LSN, home.Habitability)
+	}
+	// but not for anyone else
+	if other := g.ScanAt(g.SpeciesById(2), coords).Planets[home.Orbit-1]; other.LSN == 0 {
+		t.Errorf("scan: home: expected the Klingons to need life support\n")
+	}
+	lines := scan.Lines()
+	if len(lines) != 5+len(scan.Planets) || !strings.Contains(lines[0], "stellar type") || !strings.Contains(lines[3], "LSN  Hab") {
+		t.Errorf("scan: lines: unexpected format: got %q\n", lines)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules for setting up a new species, from the manual.
+const (
+	MaxNameLength        = 31
+	MinSpeciesNameLength = 7
+	SetupTechPoints      = 15 // points to split between ML, GV, LS and BI
+	StartingTechLevel    = 10 // starting level for MI and MA
+
+	// The starting manufacturing base of a home planet, in tenths.
+	// The mining base is set so that the planet produces about as many
+	// raw materials as it can use.
+	startingManufacturingBase = 600
+)
+
+// SpeciesSetup is the information from a player's set-up form.
+type SpeciesSetup struct {
+	Name           string
+	HomePlanet     string
+	Government     string
+	GovernmentType string
+	ML, GV, LS, BI int
+}
+
+// Validate returns an error if the set-up doesn't follow the rules.
+func (s SpeciesSetup) Validate() error {
+	for _, field := range []struct {
+		label, value string
+	}{
+		{"species name", s.Name},
+		{"home planet name", s.HomePlanet},
+		{"government name", s.Government},
+		{"government type", s.GovernmentType},
+	} {
+		if err := ValidateName(field.value); err != nil {
+			return fmt.Errorf("%s: %w", field.label, err)
+		}
+	}
+	if len(s.Name) < MinSpeciesNameLength {
+		return fmt.Errorf("species name: must have at least %d characters: %w", MinSpeciesNameLength, ErrInvalidName)
+	}
+	if s.ML < 0 || s.GV < 0 || s.LS < 0 || s.BI < 0 {
+		return fmt.Errorf("tech levels may not be negative: %w", ErrInvalidTechPoints)
+	} else if total := s.ML + s.GV + s.LS + s.BI; total != SetupTechPoints {
+		return fmt.Errorf("tech levels must add up to %d, not %d: %w", SetupTechPoints, total, ErrInvalidTechPoints)
+	}
+	return nil
+}
+
+// ValidateName returns an error if a name can't be used in the game.
+// Names may have spaces and any printable characters except commas and
+// semi-colons, and are limited to 31 characters.
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("missing name: %w", ErrInvalidName)
+	} else if name != strings.TrimSpace(name) {
+		return fmt.Errorf("%q: leading or trailing spaces: %w", name, ErrInvalidName)
+	} else if len(name) > MaxNameLength {
+		return fmt.Errorf("%q: more than %d characters: %w", name, MaxNameLength, ErrInvalidName)
+	}
+	for _, ch := range name {
+		if ch == ',' || ch == ';' {
+			return fmt.Errorf("%q: commas and semi-colons are not allowed: %w", name, ErrInvalidName)
+		} else if ch < ' ' || ch == 0x7f {
+			return fmt.Errorf("%q: tabs and control characters are not allowed: %w", name, ErrInvalidName)
+		}
+	}
+	return nil
+}
+
+// AddSpecies adds a species to the game and creates its home planet.
+// The home planet is placed in an unused star system as far from the other
+// home systems as the galaxy allows, and the species' environment is taken
+// from the home planet.
+func (g *Game) AddSpecies(s SpeciesSetup) (*Species, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	for _, sp := range g.Species {
+		if strings.EqualFold(sp.Name, s.Name) {
+			return nil, fmt.Errorf("species name %q: %w", s.Name, ErrDuplicateName)
+		}
+	}
+
+	sp := &Species{
+		Id:             len(g.Species) + 1,
+		Name:           s.Name,
+		Government:     s.Government,
+		GovernmentType: s.GovernmentType,
+	}
+	for _, other := range g.Species {
+		if other.Id >= sp.Id {
+			sp.Id = other.Id + 1
+		}
+	}
+	sp.Tech[MI], sp.Tech[MA] = StartingTechLevel, StartingTechLevel
+	sp.Tech[ML], sp.Tech[GV], sp.Tech[LS], sp.Tech[BI] = s.ML, s.GV, s.LS, s.BI
+
+	// each species gets its own sequence so that the order that the forms
+	// are processed in doesn't change the other species' home planets.
+	r := NewRNG(TurnSeed(g.Seed, -1-sp.Id))
+
+	star := g.homeSystem(r)
+	if star == nil {
+		return nil, ErrNoHomeSystem
+	}
+	planet := homePlanet(r, star)
+	sp.HomePlanet = planet.Id
+	sp.Visit(star.Id)
+	sp.TemperatureClass, sp.PressureClass = planet.TemperatureClass, planet.PressureClass
+
+	// the required gas is one of the gases in the atmosphere. the other
+	// gases in the atmosphere are harmless, and about half of the rest
+	// are poisonous.
+	required := planet.Atmosphere[r.Intn(len(planet.Atmosphere))]
+	sp.RequiredGas = required.Gas
+	sp.RequiredMin = clamp(required.Percent-10, 1, 100)
+	sp.RequiredMax = clamp(required.Percent+30, 1, 100)
+	for _, gas := range Gases {
+		if planet.Atmosphere.Percent(gas) == 0 && r.Percent(60) {
+			sp.PoisonGases = append(sp.PoisonGases, gas)
+		}
+	}
+
+	colony := &Colony{
+		Id:                g.nextColonyId(),
+		Species:           sp.Id,
+		Planet:            planet.Id,
+		Name:              s.HomePlanet,
+		IsHome:            true,
+		MiningBase:        startingManufacturingBase * planet.MiningDifficulty / 100,
+		ManufacturingBase: startingManufacturingBase,
+		Shipyards:         1,
+	}
+	g.Species = append(g.Species, sp)
+	g.Colonies = append(g.Colonies, colony)
+	colony.AvailablePopulation = g.availablePopulation(r, colony)
+
+	return sp, nil
+}
+
+// homeSystem returns a star system for a new home planet.
+// It must have at least three planets, no other home planets, and it should
+// be as far from the other home systems as the galaxy allows.
+func (g *Game) homeSystem(r *RNG) *Star {
+	var homes []*Star
+	for _, colony := range g.Colonies {
+		if colony.IsHome {
+			homes = append(homes, g.Galaxy.StarOf(colony.Planet))
+		}
+	}
+
+	for minDistance := float64(g.Galaxy.Radius); minDistance >= 0; minDistance-- {
+		var candidates []*Star
+		for _, star := range g.Galaxy.Stars {
+			if len(star.Planets) < 3 || !hasRockyPlanet(star) {
+				continue
+			}
+			ok := true
+			for _, home := range homes {
+				ok = ok && home != star && star.Coords.DistanceTo(home.Coords) >= minDistance
+			}
+			if ok {
+				candidates = append(candidates, star)
+			}
+		}
+		if len(candidates) != 0 {
+			return candidates[r.Intn(len(candidates))]
+		}
+	}
+	return nil
+}
+
+func hasRockyPlanet(star *Star) bool {
+	for _, planet := range star.Planets {
+		if !planet.IsGasGiant() {
+			return true
+		}
+	}
+	return false
+}
+
+// homePlanet turns the rocky planet in the system with the most moderate
+// temperature into a world that could have given rise to a species.
+func homePlanet(r *RNG, star *Star) *Planet {
+	var home *Planet
+	for _, planet := range star.Planets {
+		if planet.IsGasGiant() {
+			continue
+		} else if home == nil || abs(planet.TemperatureClass-11) < abs(home.TemperatureClass-11) {
+			home = planet
+		}
+	}
+
+	home.TemperatureClass = clamp(home.TemperatureClass, 7, 15)
+	if home.PressureClass < 3 || home.PressureClass > 15 {
+		home.PressureClass = 3 + r.Intn(13)
+	}
+	home.MiningDifficulty = clamp(home.MiningDifficulty, 80, 200)
+	for home.Atmosphere = nil; len(home.Atmosphere) < 2; {
+		home.Atmosphere = generateAtmosphere(r, home.TemperatureClass, false)
+	}
+
+	return home
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
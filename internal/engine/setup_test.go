@@ -0,0 +1,77 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/engine"
+	"testing"
+)
+
+func TestGame_AddSpecies(t *testing.T) {
+	g, err := engine.NewGame("test", 6, 1)
+	if err != nil {
+		t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+	}
+
+	setups := []engine.SpeciesSetup{
+		{Name: "Humanoid", HomePlanet: "Earth", Government: "United Nations", GovernmentType: "Democracy", ML: 4, GV: 4, LS: 4, BI: 3},
+		{Name: "Klingon", HomePlanet: "Qo'noS", Government: "Klingon Empire", GovernmentType: "Feudal Monarchy", ML: 15},
+		{Name: "Ferengi", HomePlanet: "Ferenginar", Government: "Ferengi Alliance", GovernmentType: "Plutocracy", GV: 5, LS: 5, BI: 5},
+	}
+	homes := make(map[int]bool)
+	for _, s := range setups {
+		sp, err := g.AddSpecies(s)
+		if err != nil {
+			t.Fatalf("AddSpecies: %s: err: expected nil: got %v\n", s.Name, err)
+		}
+		if sp.Tech[engine.MI] != 10 || sp.Tech[engine.MA] != 10 || sp.Tech[engine.ML] != s.ML || sp.Tech[engine.BI] != s.BI {
+			t.Errorf("AddSpecies: %s: tech: got %v\n", s.Name, sp.Tech)
+		}
+
+		planet := g.Galaxy.Planet(sp.HomePlanet)
+		if planet == nil || planet.IsGasGiant() {
+			t.Fatalf("AddSpecies: %s: home planet: expected rocky planet: got %+v\n", s.Name, planet)
+		}
+		star := g.Galaxy.StarOf(planet.Id)
+		if homes[star.Id] {
+			t.Errorf("AddSpecies: %s: home system: expected unused system: got star %d\n", s.Name, star.Id)
+		}
+		homes[star.Id] = true
+
+		if pct := planet.Atmosphere.Percent(sp.RequiredGas); pct < sp.RequiredMin || pct > sp.RequiredMax {
+			t.Errorf("AddSpecies: %s: required gas: expected %d..%d: got %d\n", s.Name, sp.RequiredMin, sp.RequiredMax, pct)
+		}
+		for _, c := range planet.Atmosphere {
+			if sp.IsPoison(c.Gas) {
+				t.Errorf("AddSpecies: %s: home atmosphere: %s is poisonous\n", s.Name, c.Gas)
+			}
+		}
+		if sp.TemperatureClass != planet.TemperatureClass || sp.PressureClass != planet.PressureClass {
+			t.Errorf("AddSpecies: %s: environment: expected home planet's\n", s.Name)
+		}
+	}
+
+	if len(g.Colonies) != 3 {
+		t.Fatalf("AddSpecies: colonies: expected 3: got %d\n", len(g.Colonies))
+	}
+	for _, colony := range g.Colonies {
+		planet := g.Galaxy.Planet(colony.Planet)
+		if !colony.IsHome || colony.Shipyards != 1 {
+			t.Errorf("AddSpecies: %s: expected home planet with a shipyard\n", colony.Name)
+		}
+		// raw materials should about match production capacity
+		rm := 10 * colony.MiningBase * 10 / planet.MiningDifficulty
+		capacity := 10 * colony.ManufacturingBase / 10
+		if rm < capacity-10 || rm > capacity {
+			t.Errorf("AddSpecies: %s: raw materials: expected about %d: got %d\n", colony.Name, capacity, rm)
+		}
+	}
+
+	if _, err := g.AddSpecies(engine.SpeciesSetup{Name: "KLINGON", HomePlanet: "Kronos", Government: "Empire", GovernmentType: "Monarchy", ML: 15}); !errors.Is(err, engine.ErrDuplicateName) {
+		t.Errorf("AddSpecies: duplicate: err: expected ErrDuplicateName: got %v\n", err)
+	}
+	if _, err := g.AddSpecies(engine.SpeciesSetup{Name: "Romulan", HomePlanet: "Romulus", Government: "Star Empire", GovernmentType: "Senate", ML: 16}); !errors.Is(err, engine.ErrInvalidTechPoints) {
+		t.Errorf("AddSpecies: points: err: expected ErrInvalidTechPoints: got %v\n", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"github.com/mdhender/fh/internal/catalog"
+)
+
+// Ship is a ship or starbase.
+// A ship that still has a balance to pay is under construction.
+type Ship struct {
+	Id        int            `json:"id"`
+	Species   int            `json:"species"`
+	Class     string         `json:"class"` // class abbreviation without the sub-light suffix, e.g. "CL", "TR10" or "BAS"
+	SubLight  bool           `json:"sub_light,omitempty"`
+	Name      string         `json:"name"`
+	Tonnage   int            `json:"tonnage"`
+	Age       int            `json:"age"`
+	Remaining int            `json:"remaining,omitempty"` // cost left to pay while under construction
+	Coords    Coords         `json:"coords"`
+	Planet    int            `json:"planet,omitempty"` // planet the ship is at, 0 for deep space
+	Status    ShipStatus     `json:"status"`
+	InTransit bool           `json:"in_transit,omitempty"` // jumped this turn and can't communicate
+	Wormhole  bool           `json:"wormhole,omitempty"`   // came through a natural wormhole in the last jump phase
+	Withdrawn bool           `json:"withdrawn,omitempty"`  // withdrew from combat and jumps away in the jump phase
+	Haven     *Coords        `json:"haven,omitempty"`      // where a withdrawn ship jumps to
+	Forced    string         `json:"forced,omitempty"`     // "FJ" or "FM" if forced to jump in the next jump phase
+	Cargo     map[string]int `json:"cargo,omitempty"`
+}
+
+// maxShipAge is the oldest a ship can get.
+const maxShipAge = 49
+
+// ShipStatus is where a ship is in its star system.
+// The values are the codes used on status reports.
+type ShipStatus string
+
+const (
+	Landed    ShipStatus = "L"
+	InOrbit   ShipStatus = "O"
+	DeepSpace ShipStatus = "D"
+)
+
+// Code returns the class abbreviation, including any sub-light suffix.
+func (s *Ship) Code() string {
+	if s.SubLight {
+		return s.Class + "S"
+	}
+	return s.Class
+}
+
+// Cost returns the original cost of the ship.
+func (s *Ship) Cost() int {
+	return catalog.ShipCost(s.Tonnage, s.SubLight)
+}
+
+// Kind returns the kind of ship: warship, transport or starbase.
+func (s *Ship) Kind() catalog.Kind {
+	sc, _, err := catalog.LookupShip(s.Class)
+	if err != nil {
+		return catalog.Warship
+	}
+	return sc.Kind
+}
+
+// IsStarbase returns true if the ship is a starbase.
+func (s *Ship) IsStarbase() bool {
+	return s.Kind() == catalog.Starbase
+}
+
+// CarryingCapacity returns the number of cargo units the ship can carry.
+func (s *Ship) CarryingCapacity() int {
+	return catalog.CarryingCapacity(s.Kind(), s.Tonnage)
+}
+
+// IsUnderConstruction returns true if the ship still has a balance to pay.
+func (s *Ship) IsUnderConstruction() bool {
+	return s.Remaining > 0
+}
+
+// IsDistorted returns true if the ship's field distortion units are working.
+// That takes exactly one unit for every 10,000 tons, and the units don't
+// work on the surface of a planet.
+func (s *Ship) IsDistorted() bool {
+	return s.Cargo["FD"] > 0 && s.Cargo["FD"]*10_000 == s.Tonnage && s.Status != Landed
+}
+
+// String implements the Stringer interface.
+func (s *Ship) String() string {
+	return s.Code() + " " + s.Name
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+const (
+	// shipyardCost is the cost of a shipyard per level of manufacturing tech.
+	shipyardCost = 10
+
+	// interspeciesMA is the manufacturing tech level needed to build for
+	// other species.
+	interspeciesMA = 25
+
+	// premium is the percent added to the cost of building for another species.
+	premium = 10
+)
+
+// shipyardFree returns an error if every shipyard on the colony has been
+// used this turn. Each order to build or continue a ship or starbase uses
+// a shipyard.
+func (t *Turn) shipyardFree(colony *Colony) error {
+	if t.shipyards[colony.Id] >= colony.Shipyards {
+		return fmt.Errorf("PL %s has %d: %w", colony.Name, colony.Shipyards, ErrShipyardCapacity)
+	}
+	return nil
+}
+
+// useShipyard uses one of the colony's shipyards for the turn.
+func (t *Turn) useShipyard(colony *Colony) {
+	t.shipyards[colony.Id]++
+}
+
+// shipyard adds a shipyard to the producing planet. A planet can only add
+// one shipyard a turn, and the new shipyard can't be used until the next
+// turn. Mining and resort colonies can't have shipyards.
+func shipyard(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, _, err := t.producer(sp)
+	if err != nil {
+		return err
+	} else if colony.IsMiningColony() || t.Game.IsResortColony(colony) {
+		return fmt.Errorf("PL %s: %w", colony.Name, ErrNotAllowed)
+	} else if t.expanded[colony.Id] {
+		return fmt.Errorf("PL %s: %w", colony.Name, ErrDuplicateShipyard)
+	}
+	if err := t.spend(sp, cmd, shipyardCost*sp.Tech[MA]); err != nil {
+		return err
+	}
+	colony.Shipyards++
+	t.shipyards[colony.Id]++
+	t.expanded[colony.Id] = true
+	return nil
+}
+
+// recipient returns the species that an interspecies construction order
+// is for. The builder needs enough manufacturing tech and must have met
+// the recipient, and can't build for a declared enemy.
+func (t *Turn) recipient(sp *Species, arg orders.Arg) (*Species, error) {
+	other := t.Game.SpeciesNamed(arg.Name)
+	if sp.Tech[MA] < interspeciesMA {
+		return nil, fmt.Errorf("needs MA %d: %w", interspeciesMA, ErrTechTooLow)
+	} else if other == nil || other == sp {
+		return nil, fmt.Errorf("SP %s: %w", arg.Name, ErrNoSuchSpecies)
+	} else if !sp.HasMet(other.Id) {
+		return nil, fmt.Errorf("%s: %w", other, ErrNotMet)
+	} else if t.Game.declaredEnemy(sp.Id, other.Id) {
+		return nil, fmt.Errorf("%s is an enemy: %w", other, ErrNotAllowed)
+	}
+	return other, nil
+}
+
+// withPremium returns the cost of building for another species, which
+// adds 10% of the basis, rounded up.
+func withPremium(cost, basis int) int {
+	return cost + (premium*basis+99)/100
+}
+
+// ibuild builds items, a ship, or a starbase for another species.
+// Construction is always finished in the same turn. Items go to the
+// recipient's colony on the producing planet, which is named for it if it
+// doesn't have one. Ships and starbases are left in orbit.
+func ibuild(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	other, err := t.recipient(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if cmd.Pattern() == "xna" {
+		n, code := cmd.Args[1].Number, cmd.Args[2].Class
+		if code == "CU" || code == "PD" {
+			return fmt.Errorf("%s: %w", code, ErrNotBuildable)
+		}
+		item, cost, err := itemCost(sp, code, n)
+		if err != nil {
+			return err
+		}
+		dst := t.Game.colonyOn(other.Id, planet.Id)
+		if dst == nil && t.Game.ColonyNamed(other.Id, colony.Name) != nil {
+			return fmt.Errorf("PL %s: %w", colony.Name, ErrDuplicateName)
+		}
+		if err := t.spend(sp, cmd, withPremium(cost, cost)); err != nil {
+			return err
+		}
+		if dst == nil {
+			dst = &Colony{Id: t.Game.nextColonyId(), Species: other.Id, Planet: planet.Id, Name: colony.Name}
+			t.Game.Colonies = append(t.Game.Colonies, dst)
+		}
+		addItems(&dst.Inventory, item.Code, n)
+		t.Logf(other.Id, 0, "%s built %d %s for PL %s", sp, n, item.Code, dst.Name)
+		return nil
+	}
+
+	ship, err := t.newShip(sp, other, planet, cmd.Args[1])
+	if err != nil {
+		return err
+	} else if err := t.shipyardFree(colony); err != nil {
+		return err
+	}
+	if ship.IsStarbase() {
+		if len(cmd.Args) != 3 {
+			return fmt.Errorf("starbase needs an amount: %w", ErrInvalidAmount)
+		}
+		if ship.Tonnage, err = starbaseTonnage(sp, 0, cmd.Args[2].Number); err != nil {
+			return err
+		}
+	} else if len(cmd.Args) == 3 {
+		return fmt.Errorf("%s must be finished this turn: %w", ship, ErrInvalidAmount)
+	}
+	if err := t.spend(sp, cmd, withPremium(ship.Cost(), ship.Cost())); err != nil {
+		return err
+	}
+	t.useShipyard(colony)
+	ship.Status = InOrbit
+	if err := t.besieged(colony, ship); err != nil {
+		return err
+	}
+	t.Game.Ships = append(t.Game.Ships, ship)
+	t.Logf(other.Id, 0, "%s built %s for us at PL %s", sp, ship, colony.Name)
+	return nil
+}
+
+// icontinue finishes a ship, or adds to a starbase, and then gives it to
+// another species. The premium is based on the total cost of the ship or
+// starbase, not just what is paid this turn.
+func icontinue(t *Turn, sp *Species, cmd *orders.Command) error {
+	colony, planet, err := t.producer(sp)
+	if err != nil {
+		return err
+	}
+	other, err := t.recipient(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	ship, err := t.shipArg(sp, cmd.Args[1])
+	if err != nil {
+		return err
+	} else if ship.Planet != planet.Id {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	} else if t.Game.ShipNamed(other.Id, ship.Name) != nil {
+		return fmt.Errorf("%q: %w", ship.Name, ErrDuplicateName)
+	} else if err := t.shipyardFree(colony); err != nil {
+		return err
+	}
+
+	tonnage, pay := ship.Tonnage, ship.Remaining
+	if ship.IsStarbase() {
+		if len(cmd.Args) != 3 {
+			return fmt.Errorf("starbase needs an amount: %w", ErrInvalidAmount)
+		} else if tonnage, err = starbaseTonnage(sp, ship.Tonnage, cmd.Args[2].Number); err != nil {
+			return err
+		}
+		pay = cmd.Args[2].Number
+	} else if !ship.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", ship, ErrNotUnderConstruction)
+	} else if len(cmd.Args) == 3 {
+		return fmt.Errorf("%s must be finished this turn: %w", ship, ErrInvalidAmount)
+	}
+	if err := t.spend(sp, cmd, withPremium(pay, catalog.ShipCost(tonnage, ship.SubLight))); err != nil {
+		return err
+	}
+	t.useShipyard(colony)
+	ship.grow(tonnage)
+	ship.Remaining, ship.Species, ship.Status = 0, other.Id, InOrbit
+	if err := t.besieged(colony, ship); err != nil {
+		return err
+	}
+	t.Logf(other.Id, 0, "%s finished %s for us at PL %s", sp, ship, colony.Name)
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestShipyard(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	sp.Tech[engine.MA] = 20
+	production := map[int]string{1: `START PRODUCTION
+	PRODUCTION PL Earth
+	SHIPYARD
+	SHIPYARD
+	BUILD	CT Picket, 100
+	BUILD	CT Sentry
+	BUILD	50 IU
+END
+`}
+
+	// the new shipyard can't be used until the next turn
+	runStep(t, g, engine.DefaultSteps()[3], production)
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "duplicate shipyard order") || !strings.Contains(errs[1], "shipyard capacity exceeded") {
+		t.Errorf("shipyard: log: expected 2 errors: got %q\n", errs)
+	}
+	if earth.Shipyards != 2 {
+		t.Errorf("shipyard: expected 2 shipyards: got %d\n", earth.Shipyards)
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Spent != 200+100+50 {
+		t.Errorf("shipyard: expected 350 spent: got %d\n", ledger.Spent)
+	}
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nCONTINUE CT Picket\nBUILD CT Sentry\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("shipyard: next turn: expected no errors: got %q\n", errs)
+	}
+
+	// mining colonies can't have shipyards
+	mine := &engine.Colony{Id: 100, Species: 1, Planet: otherPlanet(g, earth).Id, Name: "Mine", MiningBase: 100}
+	g.Colonies = append(g.Colonies, mine)
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Mine\nSHIPYARD\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "not allowed here") {
+		t.Errorf("shipyard: mine: expected not allowed: got %q\n", errs)
+	}
+}
+
+func TestInterspeciesConstruction(t *testing.T) {
+	g, _ := newBattleGame(t, &engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000})
+	sp, earth := g.SpeciesById(1), g.ColonyNamed(1, "Earth")
+	sp.Tech[engine.MI], sp.Tech[engine.MA] = 50, 50
+	earth.Shipyards = 5
+	production := map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nIBUILD SP Klingon, 21 IU\nEND\n"}
+
+	// the species have to meet first
+	runStep(t, g, engine.DefaultSteps()[3], production)
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "species not met") {
+		t.Errorf("ibuild: log: expected species not met: got %q\n", errs)
+	}
+	runStep(t, g, engine.DefaultSteps()[6], nil)
+
+	// the examples from the manual
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: `START PRODUCTION
+	PRODUCTION PL Earth
+	IBUILD	SP Klingon, 21 IU
+	IBUILD	SP Klingon, 5 CU
+	IBUILD	SP Klingon, DD Hammer, 250
+	BUILD	BAS Dagger, 500
+	BUILD	DD Hammer, 250
+END
+`})
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "item can not be built") || !strings.Contains(errs[1], "must be finished this turn") {
+		t.Errorf("ibuild: log: expected 2 errors: got %q\n", errs)
+	}
+	if colony := g.ColonyNamed(2, "Earth"); colony == nil || colony.Planet != earth.Planet || colony.Inventory["IU"] != 21 {
+		t.Errorf("ibuild: expected 21 IU on the Klingons' Earth: got %+v\n", colony)
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Spent != 24+500+250 {
+		t.Errorf("ibuild: expected 774 spent: got %d\n", ledger.Spent)
+	}
+
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: `START PRODUCTION
+	PRODUCTION PL Earth
+	ICONTINUE	SP Klingon, BAS Dagger, 200
+	ICONTINUE	SP Klingon, DD Hammer
+END
+`})
+	if errs := orderErrors(g); len(errs) != 0 {
+		t.Errorf("icontinue: log: expected no errors: got %q\n", errs)
+	}
+	if ledger := g.LedgerFor(earth.Id); ledger.Spent != 270+1400 {
+		t.Errorf("icontinue: expected 1670 spent: got %d\n", ledger.Spent)
+	}
+	for _, name := range []string{"Dagger", "Hammer"} {
+		if ship := g.ShipNamed(2, name); ship == nil || ship.IsUnderConstruction() || ship.Status != engine.InOrbit {
+			t.Errorf("icontinue: expected the Klingons to own %s in orbit: got %+v\n", name, ship)
+		} else if g.ShipNamed(1, name) != nil {
+			t.Errorf("icontinue: expected the Humanoids to give up %s\n", name)
+		}
+	}
+	if ship := g.ShipNamed(2, "Dagger"); ship != nil && ship.Tonnage != 70_000 {
+		t.Errorf("icontinue: expected 70,000 tons: got %d\n", ship.Tonnage)
+	}
+
+	// nothing can be built for an enemy
+	g.Diplomacy = engine.Diplomacy{1: {2: engine.Enemy}}
+	runStep(t, g, engine.DefaultSteps()[3], production)
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "not allowed here") {
+		t.Errorf("ibuild: enemy: expected not allowed: got %q\n", errs)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+)
+
+const (
+	// maxSiegeEffectiveness is the most production a siege can take, in percent.
+	maxSiegeEffectiveness = 95
+
+	// occupationDetection is the chance, in percent, that besiegers living
+	// on the besieged planet detect construction.
+	occupationDetection = 95
+)
+
+// Siege is a colony that was besieged during the last combat phase.
+// Sieges last until the next combat phase, so they must be renewed with
+// combat orders every turn.
+type Siege struct {
+	Colony        int  `json:"colony"`
+	Besieger      int  `json:"besieger"`
+	Effectiveness int  `json:"effectiveness"`        // percent of production lost
+	Occupation    bool `json:"occupation,omitempty"` // the besieger has a colony on the same planet
+}
+
+// DetectionChance returns the percent chance that the besieger detects
+// construction on the besieged planet. Besiegers that live on the planet
+// detect almost everything.
+func (s *Siege) DetectionChance() int {
+	if s.Occupation {
+		return occupationDetection
+	}
+	return s.Effectiveness
+}
+
+// SiegesOf returns the sieges of a colony.
+func (g *Game) SiegesOf(colony int) []*Siege {
+	var sieges []*Siege
+	for _, s := range g.Sieges {
+		if s.Colony == colony {
+			sieges = append(sieges, s)
+		}
+	}
+	return sieges
+}
+
+// sieges returns the sieges of a colony that are still in place. Besiegers
+// need a warship or starbase in the system for the whole turn, so a siege
+// is lifted when the last of them leaves.
+func (t *Turn) sieges(colony *Colony) []*Siege {
+	coords := t.Game.Galaxy.StarOf(colony.Planet).Coords
+	var sieges []*Siege
+	for _, s := range t.Game.SiegesOf(colony.Id) {
+		for _, ship := range t.Game.Ships {
+			if ship.Species == s.Besieger && ship.Coords == coords && !t.moved[ship.Id] && !ship.Withdrawn && ship.Kind() != catalog.Transport {
+				sieges = append(sieges, s)
+				break
+			}
+		}
+	}
+	return sieges
+}
+
+// applySieges takes the production lost to sieges out of each besieged
+// planet's ledger. A quarter of what is lost goes to the besiegers, divided
+// according to their effectiveness, and the rest is wasted.
+func applySieges(t *Turn) {
+	for _, ledger := range t.Game.Ledgers {
+		colony := t.Game.ColonyById(ledger.Colony)
+		sieges := t.sieges(colony)
+		total := 0
+		for _, s := range sieges {
+			total += s.Effectiveness
+		}
+		if total == 0 {
+			continue
+		}
+		ledger.Siege = ledger.Production() * min(maxSiegeEffectiveness, total) / 100
+		for _, s := range sieges {
+			if amount := ledger.Siege / 4 * s.Effectiveness / total; amount > 0 {
+				t.Game.Transactions = append(t.Game.Transactions, &Transaction{Kind: "SIEGE", From: colony.Species, To: s.Besieger, Amount: amount})
+			}
+		}
+		t.Logf(colony.Species, 0, "PL %s: lost %d to the siege", colony.Name, ledger.Siege)
+	}
+}
+
+// detected returns the besieger that detects construction on the colony,
+// or nil if the construction goes unnoticed. Starbases can't be hidden
+// from besiegers.
+func (t *Turn) detected(colony *Colony, always bool) *Species {
+	for _, s := range t.sieges(colony) {
+		if always || t.RNG.Percent(s.DetectionChance()) {
+			return t.Game.SpeciesById(s.Besieger)
+		}
+	}
+	return nil
+}
+
+// assimilate hands besieged colonies over to besiegers that live on the
+// same planet. A colony is assimilated when the siege is at full
+// effectiveness and the besiegers' installed population is more than a
+// fifth of the colony's. Home planets are too big to assimilate.
+//
+// Half of the colony's mining and manufacturing base goes to the
+// besiegers' colonies, divided by effectiveness, and the colony's
+// inventory goes to the most effective besieger.
+func assimilate(t *Turn) {
+	for _, colony := range append([]*Colony{}, t.Game.Colonies...) {
+		if colony.IsHome {
+			continue
+		}
+		total, population := 0, 0
+		var occupiers []*Siege
+		for _, s := range t.sieges(colony) {
+			total += s.Effectiveness
+			if occupier := t.Game.colonyOn(s.Besieger, colony.Planet); occupier != nil && s.Occupation {
+				occupiers = append(occupiers, s)
+				population += installedPopulation(occupier)
+			}
+		}
+		if total < maxSiegeEffectiveness || len(occupiers) == 0 || 5*population <= installedPopulation(colony) {
+			continue
+		}
+
+		effectiveness := 0
+		for _, s := range occupiers {
+			effectiveness += s.Effectiveness
+		}
+		var heir *Colony
+		heirEffectiveness := 0
+		for _, s := range occupiers {
+			occupier := t.Game.colonyOn(s.Besieger, colony.Planet)
+			occupier.MiningBase += colony.MiningBase / 2 * s.Effectiveness / effectiveness
+			occupier.ManufacturingBase += colony.ManufacturingBase / 2 * s.Effectiveness / effectiveness
+			if s.Effectiveness > heirEffectiveness {
+				heir, heirEffectiveness = occupier, s.Effectiveness
+			}
+			t.Logf(occupier.Species, 0, "PL %s: assimilated %s", occupier.Name, t.Game.SpeciesById(colony.Species))
+		}
+		for _, item := range catalog.Items {
+			addItems(&heir.Inventory, item.Code, colony.Inventory[item.Code])
+		}
+		t.Logf(colony.Species, 0, "PL %s: assimilated by the besiegers", colony.Name)
+		t.Game.removeColony(colony)
+	}
+}
+
+// installedPopulation returns the population of a colony that comes from
+// its installed mining and manufacturing bases, in tenths of a base.
+// Anything over 200.0 in either base counts for only 5% so that a colony
+// can't pump up its base to avoid assimilation.
+func installedPopulation(colony *Colony) int {
+	effective := func(base int) int {
+		if base <= 2000 {
+			return base
+		}
+		return 2000 + (base-2000)/20
+	}
+	return effective(colony.MiningBase) + effective(colony.ManufacturingBase)
+}
+
+// location describes where a colony is without using the name its owner
+// gave it, e.g. "planet 3 at 10 4 7".
+func (g *Game) location(colony *Colony) string {
+	planet := g.Galaxy.Planet(colony.Planet)
+	return fmt.Sprintf("planet %d at %s", planet.Orbit, g.Galaxy.StarOf(planet.Id).Coords)
+}
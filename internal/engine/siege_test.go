@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestSiege(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 2, Class: "ES", Name: "Bird of Prey", Tonnage: 50_000},
+		&engine.Ship{Species: 2, Class: "ES", Name: "Lurker", Tonnage: 50_000},
+	)
+	earth := g.ColonyNamed(1, "Earth")
+	orbit := g.Galaxy.Planet(earth.Planet).Orbit
+	g.Ships[1].Planet, g.Ships[1].Status = earth.Planet, engine.Landed
+	fightTurn(t, g, map[int]string{
+		2: fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nHIDE ES Lurker\nATTACK SP Humanoid\nENGAGE 7 %d\nEND\n", coords.X, coords.Y, coords.Z, orbit),
+	})
+
+	// the undefended planet falls without a fight, so the hidden escort
+	// never joins in: 50,000 tons at ML 10 against
+	// 10 x 99.6 + 10 x 60.0 = 1596 at ML 4 is 500,000 / 7980 = 62%
+	expect := 62
+	if sieges := g.SiegesOf(earth.Id); len(sieges) != 1 {
+		t.Fatalf("siege: expected 1 siege: got %d\n", len(sieges))
+	} else if s := sieges[0]; s.Besieger != 2 || s.Effectiveness != expect || s.Occupation {
+		t.Errorf("siege: expected SP 2 at %d%%: got %+v\n", expect, *s)
+	}
+
+	// the siege carries into the production phase
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nBUILD BAS Fortress, 100\nEND\n"})
+	if len(g.Log) == 0 || !strings.Contains(g.Log[len(g.Log)-1].Text, "detected and destroyed by besiegers") {
+		t.Errorf("production: expected the starbase to be destroyed: got %v\n", g.Log)
+	} else if g.ShipNamed(1, "Fortress") != nil {
+		t.Errorf("production: expected no starbase\n")
+	}
+	ledger := g.LedgerFor(earth.Id)
+	if lost := ledger.Production() * expect / 100; ledger.Siege != lost {
+		t.Errorf("production: siege: expected %d lost: got %d\n", lost, ledger.Siege)
+	}
+
+	// and is forgotten at the next combat phase unless it is renewed
+	fightTurn(t, g, nil)
+	if len(g.Sieges) != 0 {
+		t.Errorf("siege: expected the siege to end: got %d\n", len(g.Sieges))
+	}
+}
+
+func TestSiege_Besiegers(t *testing.T) {
+	g, coords := newBattleGame(t, &engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000})
+	if _, err := g.AddSpecies(engine.SpeciesSetup{Name: "Vulcanian", HomePlanet: "ShiKahr", Government: "Science Academy", GovernmentType: "Republic", ML: 10, GV: 1, LS: 4, BI: 0}); err != nil {
+		t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+	}
+	earth := g.ColonyNamed(1, "Earth")
+	embassy := &engine.Colony{Id: 99, Species: 3, Planet: earth.Planet, Name: "Embassy", MiningBase: 100, ManufacturingBase: 100}
+	g.Colonies = append(g.Colonies, embassy)
+	orbit := g.Galaxy.Planet(earth.Planet).Orbit
+	besiege := fmt.Sprintf("START COMBAT\nBATTLE %d %d %d\nATTACK SP Humanoid\nATTACK SP Vulcanian\nENGAGE 7 %d\nEND\n", coords.X, coords.Y, coords.Z, orbit)
+
+	// two colonies on one planet are a single planet besieged
+	fightTurn(t, g, map[int]string{2: besiege})
+	// 300,000 tons at ML 10 against 1596 at ML 4 is 3,000,000 / 7980 = 375%,
+	// which is capped at 95%
+	if sieges := g.SiegesOf(earth.Id); len(sieges) != 1 || sieges[0].Effectiveness != 95 {
+		t.Errorf("siege: expected Earth at 95%%: got %v\n", sieges)
+	}
+	if sieges := g.SiegesOf(embassy.Id); len(sieges) != 1 {
+		t.Errorf("siege: expected the embassy to be besieged: got %d sieges\n", len(sieges))
+	}
+
+	// planetary defenses can't besiege on their own
+	g.Ships = nil
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 100, Species: 2, Planet: earth.Planet, Name: "Outpost", MiningBase: 100, ManufacturingBase: 100, Inventory: map[string]int{"PD": 500}})
+	fightTurn(t, g, map[int]string{2: besiege})
+	if len(g.Sieges) != 0 {
+		t.Errorf("siege: planetary defenses: expected no sieges: got %d\n", len(g.Sieges))
+	}
+
+	// field distorted ships can't besiege and are revealed by trying
+	g.Colonies = g.Colonies[:len(g.Colonies)-1]
+	g.Ships = []*engine.Ship{{Id: 1, Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000, Coords: coords, Status: engine.DeepSpace, Cargo: map[string]int{"FD": 30}}}
+	fightTurn(t, g, map[int]string{2: besiege})
+	if len(g.Sieges) != 0 {
+		t.Errorf("siege: distorted: expected no sieges: got %d\n", len(g.Sieges))
+	}
+	if len(g.Battles) != 1 {
+		t.Fatalf("siege: distorted: expected 1 battle: got %d\n", len(g.Battles))
+	} else if log := strings.Join(g.Battles[0].LogFor(1), "\n"); !strings.Contains(log, "is revealed to be SP Klingon") {
+		t.Errorf("siege: distorted: expected the besiegers to be revealed: got %q\n", log)
+	}
+}
+
+func TestSiege_Assimilation(t *testing.T) {
+	g, _ := newBattleGame(t, &engine.Ship{Species: 2, Class: "CA", Name: "Bird of Prey", Tonnage: 300_000})
+	earth := g.ColonyNamed(1, "Earth")
+	var planet *engine.Planet
+	for _, p := range g.Galaxy.StarOf(earth.Planet).Planets {
+		if p.Id != earth.Planet {
+			planet = p
+		}
+	}
+	colony := &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Vega III", MiningBase: 618, ManufacturingBase: 562, Inventory: map[string]int{"CU": 10}}
+	outpost := &engine.Colony{Id: 101, Species: 2, Planet: planet.Id, Name: "Kitomer", MiningBase: 150, ManufacturingBase: 100}
+	g.Colonies = append(g.Colonies, colony, outpost)
+	g.Sieges = []*engine.Siege{{Colony: colony.Id, Besieger: 2, Effectiveness: 95, Occupation: true}}
+
+	if err := engine.RunTurn(g, nil, engine.WithSteps(engine.DefaultSteps()[6])); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+	if g.ColonyById(colony.Id) != nil {
+		t.Errorf("assimilation: expected Vega III to be gone\n")
+	}
+	// the example from the manual: half of 61.8 and 56.2
+	if outpost.MiningBase != 150+309 || outpost.ManufacturingBase != 100+281 || outpost.Inventory["CU"] != 10 {
+		t.Errorf("assimilation: expected 45.9, 38.1 and 10 CU: got %d, %d and %v\n", outpost.MiningBase, outpost.ManufacturingBase, outpost.Inventory)
+	}
+}
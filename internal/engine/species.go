@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tech is one of the six technologies.
+type Tech int
+
+const (
+	MI Tech = iota // mining
+	MA             // manufacturing
+	ML             // military
+	GV             // gravitics
+	LS             // life support
+	BI             // biology
+	NumTechs
+)
+
+// Techs is the list of all technologies, in the order used on reports.
+var Techs = []Tech{MI, MA, ML, GV, LS, BI}
+
+var techCodes = []string{"MI", "MA", "ML", "GV", "LS", "BI"}
+
+var techNames = []string{"Mining", "Manufacturing", "Military", "Gravitics", "Life Support", "Biology"}
+
+// Name returns the full name of the technology.
+func (t Tech) Name() string {
+	if t < MI || t > BI {
+		return "?"
+	}
+	return techNames[t]
+}
+
+// String implements the Stringer interface by returning the abbreviation.
+func (t Tech) String() string {
+	if t < MI || t > BI {
+		return "?"
+	}
+	return techCodes[t]
+}
+
+// ParseTech returns the technology for an abbreviation. Case is not significant.
+func ParseTech(code string) (Tech, bool) {
+	for _, t := range Techs {
+		if strings.EqualFold(code, techCodes[t]) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// TechLevels are a species' levels in each technology, indexed by Tech.
+type TechLevels [NumTechs]int
+
+// Species is a player's species.
+// The environment that the species needs is the one on its home planet
+// when the species entered the game.
+type Species struct {
+	Id               int        `json:"id"`
+	Name             string     `json:"name"`
+	Government       string     `json:"govt"`
+	GovernmentType   string     `json:"govt_type"`
+	HomePlanet       int        `json:"home_planet"`
+	Tech             TechLevels `json:"tech"`
+	Treasury         int        `json:"treasury"` // economic units
+	TemperatureClass int        `json:"tc"`
+	PressureClass    int        `json:"pc"`
+	RequiredGas      Gas        `json:"required_gas,omitempty"`
+	RequiredMin      int        `json:"required_min"` // percent
+	RequiredMax      int        `json:"required_max"` // percent
+	PoisonGases      []Gas      `json:"poison_gases,omitempty"`
+	Knowledge        TechLevels `json:"knowledge"`         // tech levels taught by other species
+	Experience       TechLevels `json:"experience"`        // research and experience points toward the next level
+	Visited          []int      `json:"visited,omitempty"` // ids of the stars the species has visited, in order
+	Met              []int      `json:"met,omitempty"`     // ids of the species it has met, in order
+}
+
+// HasVisited returns true if the species has visited the star.
+func (sp *Species) HasVisited(starId int) bool {
+	i := sort.SearchInts(sp.Visited, starId)
+	return i < len(sp.Visited) && sp.Visited[i] == starId
+}
+
+// Visit records that the species has visited the star.
+func (sp *Species) Visit(starId int) {
+	if i := sort.SearchInts(sp.Visited, starId); i == len(sp.Visited) || sp.Visited[i] != starId {
+		sp.Visited = append(sp.Visited[:i], append([]int{starId}, sp.Visited[i:]...)...)
+	}
+}
+
+// HasMet returns true if the species has met the other species.
+func (sp *Species) HasMet(species int) bool {
+	i := sort.SearchInts(sp.Met, species)
+	return i < len(sp.Met) && sp.Met[i] == species
+}
+
+// Meet records that the species has met the other species.
+func (sp *Species) Meet(species int) {
+	if i := sort.SearchInts(sp.Met, species); i == len(sp.Met) || sp.Met[i] != species {
+		sp.Met = append(sp.Met[:i], append([]int{species}, sp.Met[i:]...)...)
+	}
+}
+
+// IsPoison returns true if the gas is poisonous to the species.
+func (sp *Species) IsPoison(g Gas) bool {
+	for _, poison := range sp.PoisonGases {
+		if g == poison {
+			return true
+		}
+	}
+	return false
+}
+
+// Pseudonym returns the number that others see in place of the species'
+// name when its ships are field distorted. The number is random, but it
+// stays the same for as long as the species' life support tech level does.
+func (g *Game) Pseudonym(sp *Species) int {
+	return 1 + NewRNG(TurnSeed(g.Seed, -1000*sp.Id-sp.Tech[LS])).Intn(250)
+}
+
+// String implements the Stringer interface.
+func (sp *Species) String() string {
+	return fmt.Sprintf("SP %s", sp.Name)
+}
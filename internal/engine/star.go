@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+)
+
+// Star is a usable star system.
+type Star struct {
+	Id       int       `json:"id"`
+	Coords   Coords    `json:"coords"`
+	Type     StarType  `json:"type"`
+	Color    StarColor `json:"color"`
+	Size     int       `json:"size"` // 0 is the hottest in the class, 9 the coolest
+	Planets  []*Planet `json:"planets"`
+	Wormhole int       `json:"wormhole,omitempty"` // id of the star at the other end of a natural wormhole
+}
+
+// SpectralClass returns the star's spectral class (e.g. "gF6" or "DA5").
+func (s *Star) SpectralClass() string {
+	return fmt.Sprintf("%s%s%d", s.Type, s.Color, s.Size)
+}
+
+// StarColor is the color of a star, from the hottest (blue) to the coolest (red).
+type StarColor int
+
+const (
+	Blue StarColor = iota + 1
+	BlueWhite
+	White
+	YellowWhite
+	Yellow
+	Orange
+	Red
+)
+
+// String implements the Stringer interface.
+func (c StarColor) String() string {
+	switch c {
+	case Blue:
+		return "O"
+	case BlueWhite:
+		return "B"
+	case White:
+		return "A"
+	case YellowWhite:
+		return "F"
+	case Yellow:
+		return "G"
+	case Orange:
+		return "K"
+	case Red:
+		return "M"
+	}
+	return "?"
+}
+
+// StarType is the type of star.
+type StarType int
+
+const (
+	MainSequence StarType = iota + 1
+	Dwarf
+	Giant
+	Degenerate
+)
+
+// String implements the Stringer interface.
+// Main sequence stars are not marked.
+func (t StarType) String() string {
+	switch t {
+	case MainSequence:
+		return ""
+	case Dwarf:
+		return "d"
+	case Giant:
+		return "g"
+	case Degenerate:
+		return "D"
+	}
+	return "?"
+}
+
+// generateStar returns a new star with random spectral class and planets.
+func generateStar(r *RNG, c Coords) *Star {
+	s := &Star{Coords: c, Size: r.Intn(10)}
+
+	// most stars are main sequence stars.
+	switch n := r.Intn(20); {
+	case n < 2:
+		s.Type = Dwarf
+	case n < 4:
+		s.Type = Degenerate
+	case n < 6:
+		s.Type = Giant
+	default:
+		s.Type = MainSequence
+	}
+
+	// cooler stars are more common than hotter ones.
+	switch n := r.Intn(100); {
+	case n < 2:
+		s.Color = Blue
+	case n < 7:
+		s.Color = BlueWhite
+	case n < 17:
+		s.Color = White
+	case n < 32:
+		s.Color = YellowWhite
+	case n < 52:
+		s.Color = Yellow
+	case n < 75:
+		s.Color = Orange
+	default:
+		s.Color = Red
+	}
+
+	// large stars have more usable planets than small stars.
+	numPlanets := 9 - int(s.Color) + r.Intn(3)
+	switch s.Type {
+	case Dwarf:
+		numPlanets--
+	case Degenerate:
+		numPlanets -= 2
+	case Giant:
+		numPlanets++
+	}
+	if numPlanets < 1 {
+		numPlanets = 1
+	} else if numPlanets > 9 {
+		numPlanets = 9
+	}
+
+	for orbit := 1; orbit <= numPlanets; orbit++ {
+		s.Planets = append(s.Planets, generatePlanet(r, s, orbit, numPlanets))
+	}
+
+	return s
+}
+
+// heat returns the temperature class of a planet in the first orbit of the star.
+func (s *Star) heat() int {
+	// hot blue stars start at 30, cool red stars at 12.
+	h := 30 - 3*(int(s.Color)-1) - s.Size/4
+	switch s.Type {
+	case Dwarf:
+		h -= 2
+	case Degenerate:
+		h -= 5
+	case Giant:
+		h += 3
+	}
+	return h
+}
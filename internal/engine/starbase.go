@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+)
+
+// base builds a starbase out of starbase units, or adds them to an
+// existing starbase in the same sector. Each unit adds 10,000 tons.
+// A count of zero, or no count, uses all the units in the ship or planet.
+//
+// A new starbase is built in orbit around the planet that the units are
+// on or at. Away from a planet, it is built in deep space, which is only
+// allowed where there is no star system.
+func base(t *Turn, sp *Species, cmd *orders.Command) error {
+	n, args := 0, cmd.Args
+	if args[0].Kind == orders.Number {
+		n, args = args[0].Number, args[1:]
+	}
+	src, err := t.holdArg(sp, args[0])
+	if err != nil {
+		return err
+	}
+	have := (*src.inventory())["SU"]
+	if n == 0 {
+		n = have
+	}
+	if n <= 0 {
+		return fmt.Errorf("%d: %w", n, ErrInvalidAmount)
+	} else if have < n {
+		return fmt.Errorf("%s: have %d SU: %w", src, have, ErrInsufficientItems)
+	}
+	increment := catalog.ShipCost(catalog.StarbaseIncrement, false)
+
+	arg := args[1]
+	ship := t.Game.ShipNamed(sp.Id, arg.Name)
+	if ship == nil {
+		if ship, err = t.newStarbase(sp, src, arg); err != nil {
+			return err
+		}
+		if ship.Tonnage, err = starbaseTonnage(sp, 0, n*increment); err != nil {
+			return err
+		}
+		t.Game.Ships = append(t.Game.Ships, ship)
+	} else if !ship.IsStarbase() || !strings.EqualFold(ship.Code(), arg.Class) {
+		return fmt.Errorf("%q: %w", arg.Name, ErrDuplicateName)
+	} else if ship.Coords != src.coords(t.Game) {
+		return fmt.Errorf("%s: %w", ship, ErrNotHere)
+	} else {
+		tonnage, err := starbaseTonnage(sp, ship.Tonnage, n*increment)
+		if err != nil {
+			return err
+		}
+		ship.grow(tonnage)
+	}
+	addItems(src.inventory(), "SU", -n)
+	if src.colony != nil {
+		return t.besieged(src.colony, ship)
+	}
+	return nil
+}
+
+// newStarbase returns an empty starbase at the ship or planet that
+// provides its starbase units.
+func (t *Turn) newStarbase(sp *Species, src hold, arg orders.Arg) (*Ship, error) {
+	class, _, err := catalog.LookupShip(arg.Class)
+	if err != nil || class.Kind != catalog.Starbase {
+		return nil, fmt.Errorf("%s: %w", arg.Class, ErrInvalidClass)
+	} else if err := ValidateName(arg.Name); err != nil {
+		return nil, err
+	}
+	ship := &Ship{Id: t.Game.nextShipId(), Species: sp.Id, Class: class.Code, Name: arg.Name, Coords: src.coords(t.Game), Status: InOrbit}
+	if src.colony != nil {
+		ship.Planet = src.colony.Planet
+	} else if src.ship.Planet != 0 {
+		ship.Planet = src.ship.Planet
+	} else if t.Game.Galaxy.StarAt(ship.Coords) != nil {
+		return nil, fmt.Errorf("%s must orbit a planet: %w", arg.Name, ErrNotAllowed)
+	} else {
+		ship.Status = DeepSpace
+	}
+	return ship, nil
+}
+
+// grow sets the tonnage of a starbase that has been added to. The age of
+// a starbase is the weighted average of its contributions.
+func (s *Ship) grow(tonnage int) {
+	s.Age = s.Age * s.Tonnage / tonnage
+	s.Tonnage = tonnage
+}
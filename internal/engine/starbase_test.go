@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestStarbaseUnits(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	sp.Tech[engine.MA] = 25
+	var empty engine.Coords
+	for x := 0; g.Galaxy.StarAt(empty) != nil; x++ {
+		empty = engine.Coords{X: x, Y: 1, Z: 1}
+	}
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: 1, Class: "TR16", Name: "Barrel of Monkeys", Tonnage: 160_000, Coords: empty, Status: engine.DeepSpace, Cargo: map[string]int{"SU": 7}},
+		{Id: 2, Species: 1, Class: "TR20", Name: "Tub of Lard", Tonnage: 200_000, Coords: empty, Status: engine.DeepSpace, Cargo: map[string]int{"SU": 10}},
+		{Id: 3, Species: 1, Class: "TR20", Name: "Drifter", Tonnage: 200_000, Coords: g.Galaxy.StarOf(earth.Planet).Coords, Status: engine.DeepSpace, Cargo: map[string]int{"SU": 1}},
+	}
+	earth.Inventory = map[string]int{"SU": 2}
+
+	// the examples from the manual
+	runStep(t, g, engine.DefaultSteps()[1], map[int]string{1: `START PRE-DEPARTURE
+	BASE	TR16 Barrel of Monkeys, BAS Deep Space 3
+	BASE	4 TR20 Tub of Lard, BAS Deep Space 3
+	BASE	TR20 Tub of Lard, BAS Deep Space 3
+	BASE	TR20 Drifter, BAS Wanderer
+	BASE	PL Earth, BAS Deep Space 3
+	BASE	PL Earth, BAS Guardian
+END
+`})
+	for i, expect := range []string{"tonnage exceeds manufacturing limit", "must orbit a planet", "not at this location"} {
+		if errs := orderErrors(g); len(errs) != 3 || !strings.Contains(errs[i], expect) {
+			t.Errorf("base: log: error %d: expected %q: got %q\n", i, expect, errs)
+		}
+	}
+	if ship := g.ShipNamed(1, "Deep Space 3"); ship == nil {
+		t.Fatalf("base: expected a starbase\n")
+	} else if !ship.IsStarbase() || ship.Tonnage != 110_000 || ship.Coords != empty || ship.Status != engine.DeepSpace {
+		t.Errorf("base: expected 110,000 tons in deep space: got %d tons %s at %s\n", ship.Tonnage, ship.Status, ship.Coords)
+	}
+	if n := g.ShipNamed(1, "Tub of Lard").Cargo["SU"]; n != 6 {
+		t.Errorf("base: expected 6 SU left on the transport: got %d\n", n)
+	}
+	if ship := g.ShipNamed(1, "Guardian"); ship == nil || ship.Tonnage != 20_000 || ship.Planet != earth.Planet || ship.Status != engine.InOrbit {
+		t.Errorf("base: expected a 20,000 ton starbase orbiting Earth: got %+v\n", ship)
+	}
+	if earth.Inventory["SU"] != 0 {
+		t.Errorf("base: expected no SU left on Earth: got %d\n", earth.Inventory["SU"])
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// Observation is what a gravitic telescope saw during the post-arrival phase.
+type Observation struct {
+	Species   int         `json:"species"`
+	Telescope string      `json:"telescope"` // the starbase carrying the telescope
+	Coords    Coords      `json:"coords"`
+	Range     int         `json:"range"`
+	Sightings []*Sighting `json:"sightings,omitempty"`
+}
+
+// Sighting is an alien ship or populated planet seen by a telescope.
+type Sighting struct {
+	Species   int    `json:"species"`
+	Coords    Coords `json:"coords"`
+	Name      string `json:"name"`                // ship class and name, or "PL" and the colony name
+	Base      int    `json:"base,omitempty"`      // economic base of a colony, rounded to the nearest 10
+	Telescope int    `json:"telescope,omitempty"` // gravitic telescope units on an observed starbase
+}
+
+// telescope uses a starbase's gravitic telescope units to look for alien
+// ships and populated planets in nearby sectors. The range is half the
+// number of units, up to a tenth of the gravitics tech level. A starbase
+// can only use its telescope once a turn.
+//
+// Landed ships, ships under construction and field distorted ships can't
+// be seen, and the chance of seeing anything else depends on the observer's
+// gravitics tech and on the size of the ship or colony. Hidden colonies are
+// half as likely to be seen. Ships and starbases in orbit may notice that
+// they are being watched.
+func telescope(t *Turn, sp *Species, cmd *orders.Command) error {
+	base, err := t.shipArg(sp, cmd.Args[0])
+	if err != nil {
+		return err
+	} else if !base.IsStarbase() || base.Cargo["GT"] == 0 {
+		return fmt.Errorf("%s: no gravitic telescope: %w", base, ErrInvalidClass)
+	} else if base.IsUnderConstruction() {
+		return fmt.Errorf("%s: %w", base, ErrUnderConstruction)
+	} else if t.telescopes[base.Id] {
+		return fmt.Errorf("%s: %w", base, ErrAlreadyUsed)
+	}
+	t.telescopes[base.Id] = true
+
+	obs := &Observation{Species: sp.Id, Telescope: base.String(), Coords: base.Coords, Range: min(base.Cargo["GT"]/2, sp.Tech[GV]/10)}
+	for _, ship := range t.Game.Ships {
+		if ship.Species == sp.Id || !ship.isVisibleFromAfar() || !obs.inRange(ship.Coords) {
+			continue
+		} else if !t.RNG.Percent(min(95, sp.Tech[GV]+ship.Tonnage/10_000)) {
+			continue
+		}
+		obs.Sightings = append(obs.Sightings, &Sighting{Species: ship.Species, Coords: ship.Coords, Name: ship.String(), Telescope: ship.Cargo["GT"]})
+		if ship.Status == InOrbit {
+			other := t.Game.SpeciesById(ship.Species)
+			if t.RNG.Percent(2*(other.Tech[GV]-sp.Tech[GV]) + 2*ship.Cargo["GT"]) {
+				t.Logf(other.Id, 0, "%s: detected a gravitic telescope at %s", ship, base.Coords)
+			}
+		}
+	}
+	for _, colony := range t.Game.Colonies {
+		c := t.Game.Galaxy.StarOf(colony.Planet).Coords
+		if colony.Species == sp.Id || colony.Population() == 0 || !obs.inRange(c) {
+			continue
+		}
+		base := colony.MiningBase + colony.ManufacturingBase
+		chance := min(95, sp.Tech[GV]+base/10)
+		if colony.Hidden {
+			chance /= 2
+		}
+		if !t.RNG.Percent(chance) {
+			continue
+		}
+		obs.Sightings = append(obs.Sightings, &Sighting{Species: colony.Species, Coords: c, Name: "PL " + colony.Name, Base: (base + 50) / 100 * 10})
+	}
+	t.Game.Observations = append(t.Game.Observations, obs)
+	return nil
+}
+
+// inRange returns true if the sector is within the telescope's range.
+func (o *Observation) inRange(c Coords) bool {
+	return o.Coords.DistanceTo(c) <= float64(o.Range)
+}
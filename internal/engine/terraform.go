@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+const (
+	// terraformBiology is the biology tech level needed to terraform.
+	terraformBiology = 40
+
+	// terraformStep is the number of plants needed for each change to the
+	// planet, which is the same as the life support it saves.
+	terraformStep = 3
+)
+
+// terraform uses the terraforming plants at a colony to make the planet
+// more like the species' home planet. Without a count, or with zero, it
+// uses the plants in the colony's inventory, but no more than the life
+// support needed on the planet. Plants are used up once installed.
+// A species may not terraform a planet that another species lives on
+// unless that species has declared it an ally.
+func terraform(t *Turn, sp *Species, cmd *orders.Command) error {
+	arg := cmd.Args[len(cmd.Args)-1]
+	colony := t.Game.ColonyNamed(sp.Id, arg.Name)
+	if colony == nil {
+		return fmt.Errorf("PL %s: %w", arg.Name, ErrNoSuchPlanet)
+	} else if sp.Tech[BI] < terraformBiology {
+		return fmt.Errorf("BI %d: %w", sp.Tech[BI], ErrTechTooLow)
+	}
+	for _, other := range t.Game.Colonies {
+		if other.Planet == colony.Planet && other.Species != sp.Id && other.Population() > 0 && !t.Game.declaredAlly(other.Species, sp.Id) {
+			return fmt.Errorf("PL %s: inhabited by SP %s: %w", colony.Name, t.Game.SpeciesById(other.Species).Name, ErrNotAllowed)
+		}
+	}
+	planet := t.Game.Galaxy.Planet(colony.Planet)
+	have, needed := colony.Inventory["TP"], LifeSupportNeeded(sp, planet)
+	n := min(have, needed)
+	if len(cmd.Args) == 2 && cmd.Args[0].Number != 0 {
+		if n = cmd.Args[0].Number; n > have {
+			return fmt.Errorf("PL %s: have %d TP: %w", colony.Name, have, ErrInsufficientItems)
+		}
+		n = min(n, needed)
+	}
+	if n < terraformStep {
+		return fmt.Errorf("PL %s: %d TP: %w", colony.Name, n, ErrInsufficientItems)
+	}
+	used := terraformPlanet(sp, planet, t.Game.Galaxy.Planet(sp.HomePlanet), n)
+	addItems(&colony.Inventory, "TP", -used)
+	t.Logf(sp.Id, cmd.Line, "PL %s: %d TP installed: LSN %d", colony.Name, used, LifeSupportNeeded(sp, planet))
+	return nil
+}
+
+// terraformPlanet changes the planet toward the species' needs using up to
+// the given number of plants, and returns the number used. Each change
+// takes three plants and they are made in the order given by the manual:
+// remove poisonous gases, add the required gas, then shift the temperature
+// and pressure classes one step at a time. Gases added to fill out the
+// atmosphere come from the home planet.
+func terraformPlanet(sp *Species, planet, home *Planet, plants int) (used int) {
+	for _, c := range append(Atmosphere{}, planet.Atmosphere...) {
+		if plants-used >= terraformStep && sp.IsPoison(c.Gas) {
+			planet.Atmosphere = planet.Atmosphere.without(c.Gas)
+			used += terraformStep
+		}
+	}
+	if pct := planet.Atmosphere.Percent(sp.RequiredGas); (pct < sp.RequiredMin || pct > sp.RequiredMax) && plants-used >= terraformStep {
+		var filler Atmosphere
+		for _, c := range home.Atmosphere {
+			if c.Gas != sp.RequiredGas && !sp.IsPoison(c.Gas) {
+				filler = append(filler, c)
+			}
+		}
+		planet.Atmosphere = planet.Atmosphere.with(sp.RequiredGas, clamp(pct, sp.RequiredMin, sp.RequiredMax), filler)
+		used += terraformStep
+	}
+	for planet.TemperatureClass != sp.TemperatureClass && plants-used >= terraformStep {
+		planet.TemperatureClass += sign(sp.TemperatureClass - planet.TemperatureClass)
+		used += terraformStep
+	}
+	for planet.PressureClass != sp.PressureClass && plants-used >= terraformStep {
+		planet.PressureClass += sign(sp.PressureClass - planet.PressureClass)
+		used += terraformStep
+	}
+	return used
+}
+
+// without returns the atmosphere with the gas removed and the rest of the
+// gases scaled up to fill its share.
+func (a Atmosphere) without(g Gas) Atmosphere {
+	var rest Atmosphere
+	for _, c := range a {
+		if c.Gas != g {
+			rest = append(rest, c)
+		}
+	}
+	return rest.scaled(100)
+}
+
+// with returns the atmosphere with the gas set to the percentage and the
+// other gases scaled to fill the rest. If there are no other gases, the
+// filler gases are used. If there is nothing to fill with, the gas makes
+// up the whole atmosphere.
+func (a Atmosphere) with(g Gas, pct int, filler Atmosphere) Atmosphere {
+	var rest Atmosphere
+	for _, c := range a {
+		if c.Gas != g {
+			rest = append(rest, c)
+		}
+	}
+	if len(rest) == 0 {
+		rest = append(rest, filler...)
+	}
+	if rest = rest.scaled(100 - pct); len(rest) == 0 {
+		pct = 100
+	}
+	return append(rest, Constituent{Gas: g, Percent: pct})
+}
+
+// scaled returns the atmosphere with the percentages scaled to add up to
+// the total. Gases that drop below 1% are removed, and rounding errors go
+// to the largest gas.
+func (a Atmosphere) scaled(total int) Atmosphere {
+	sum := 0
+	for _, c := range a {
+		sum += c.Percent
+	}
+	if sum == 0 || total <= 0 {
+		return nil
+	}
+	var b Atmosphere
+	remaining, largest := total, 0
+	for _, c := range a {
+		if pct := c.Percent * total / sum; pct > 0 {
+			b = append(b, Constituent{Gas: c.Gas, Percent: pct})
+			remaining -= pct
+			if pct > b[largest].Percent {
+				largest = len(b) - 1
+			}
+		}
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	b[largest].Percent += remaining
+	return b
+}
+
+// sign returns -1, 0 or 1 for negative, zero and positive numbers.
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	} else if n > 0 {
+		return 1
+	}
+	return 0
+}
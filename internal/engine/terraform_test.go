@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestTerraform(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	planet := otherPlanet(g, earth)
+	mars := &engine.Colony{Id: 2, Species: 1, Planet: planet.Id, Name: "Mars", Inventory: map[string]int{"TP": 9}}
+	g.Colonies = append(g.Colonies, mars)
+
+	// a poisonous atmosphere without the required gas, two temperature
+	// classes too hot and one pressure class off
+	planet.Atmosphere = engine.Atmosphere{{Gas: sp.PoisonGases[0], Percent: 100}}
+	planet.TemperatureClass, planet.PressureClass = sp.TemperatureClass+2, sp.PressureClass+1
+	if sp.PressureClass == engine.MaxPressureClass {
+		planet.PressureClass = sp.PressureClass - 1
+	}
+	if lsn := engine.LifeSupportNeeded(sp, planet); lsn != 15 {
+		t.Fatalf("terraform: expected LSN 15: got %d\n", lsn)
+	}
+
+	// terraforming takes biology tech
+	log := runOrders(t, g, "START POST-ARRIVAL\nTERRAFORM PL Mars\nEND\n")
+	if !strings.Contains(log[2], "tech level too low") {
+		t.Errorf("terraform: log: expected tech level too low: got %q\n", log[2])
+	}
+
+	// nine plants remove the poison, add the required gas and cool the
+	// planet by one class
+	sp.Tech[engine.BI] = 40
+	runOrders(t, g, "START POST-ARRIVAL\nTERRAFORM 9 PL Mars\nEND\n")
+	if lsn := engine.LifeSupportNeeded(sp, planet); lsn != 6 {
+		t.Errorf("terraform: partial: expected LSN 6: got %d: %s\n", lsn, planet.Atmosphere)
+	}
+	if planet.TemperatureClass != sp.TemperatureClass+1 || mars.Inventory["TP"] != 0 {
+		t.Errorf("terraform: partial: expected TC %d and no plants: got %d and %d\n", sp.TemperatureClass+1, planet.TemperatureClass, mars.Inventory["TP"])
+	}
+	total := 0
+	for _, c := range planet.Atmosphere {
+		total += c.Percent
+		if sp.IsPoison(c.Gas) {
+			t.Errorf("terraform: partial: unexpected poison %s\n", c.Gas)
+		}
+	}
+	if pct := planet.Atmosphere.Percent(sp.RequiredGas); total != 100 || pct < sp.RequiredMin || pct > sp.RequiredMax {
+		t.Errorf("terraform: partial: expected %s %d-%d%% of 100%%: got %s\n", sp.RequiredGas, sp.RequiredMin, sp.RequiredMax, planet.Atmosphere)
+	}
+
+	// without a count, only the plants needed are used
+	mars.Inventory["TP"] = 10
+	runOrders(t, g, "START POST-ARRIVAL\nTERRAFORM PL Mars\nEND\n")
+	if lsn := engine.LifeSupportNeeded(sp, planet); lsn != 0 || mars.Inventory["TP"] != 4 {
+		t.Errorf("terraform: full: expected LSN 0 and 4 plants left: got %d and %d\n", lsn, mars.Inventory["TP"])
+	}
+
+	// the changes are part of the galaxy, so everyone's scans show them
+	klingon, err := g.AddSpecies(engine.SpeciesSetup{Name: "Klingon", HomePlanet: "Kronos", Government: "High Council", GovernmentType: "Empire", ML: 10, GV: 1, LS: 4, BI: 0})
+	if err != nil {
+		t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+	}
+	for _, p := range g.ScanAt(klingon, g.Galaxy.StarOf(planet.Id).Coords).Planets {
+		if p.Orbit == planet.Orbit && (p.TemperatureClass != sp.TemperatureClass || p.PressureClass != sp.PressureClass || p.Atmosphere.String() != planet.Atmosphere.String()) {
+			t.Errorf("terraform: scan: expected the terraformed planet: got %+v\n", p)
+		}
+	}
+
+	// planets that other species live on are off limits
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 99, Species: klingon.Id, Planet: planet.Id, Name: "Khaarsh Dukh", MiningBase: 10})
+	planet.TemperatureClass++
+	log = runOrders(t, g, "START POST-ARRIVAL\nTERRAFORM PL Mars\nEND\n")
+	if !strings.Contains(log[2], "not allowed here") {
+		t.Errorf("terraform: inhabited: expected not allowed: got %q\n", log[2])
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// AuditEntry is a change to a species' treasury of economic units.
+// The entries for a turn explain how the balance got from where it
+// started to where it ended.
+type AuditEntry struct {
+	Species int    `json:"species"`
+	Phase   string `json:"phase"`
+	Text    string `json:"text"`
+	Amount  int    `json:"amount"`  // positive for credits, negative for debits
+	Balance int    `json:"balance"` // treasury after the change
+}
+
+// audit changes a species' treasury and records why.
+func (t *Turn) audit(sp *Species, amount int, format string, args ...any) {
+	sp.Treasury += amount
+	t.Game.Audit = append(t.Game.Audit, &AuditEntry{
+		Species: sp.Id,
+		Phase:   t.phase,
+		Text:    fmt.Sprintf(format, args...),
+		Amount:  amount,
+		Balance: sp.Treasury,
+	})
+}
+
+// FleetMaintenance returns the cost of maintaining a species' ships and
+// starbases for a turn. The base cost is the tonnage divided by 500 for
+// warships, 1000 for starbases and 2500 for transports, with a 25%
+// discount for sub-light ships. Ships under construction cost the same.
+// The total is discounted by half the military tech level, in percent.
+func (g *Game) FleetMaintenance(sp *Species) int {
+	total := 0
+	for _, ship := range g.Ships {
+		if ship.Species != sp.Id {
+			continue
+		}
+		var cost int
+		switch ship.Kind() {
+		case catalog.Starbase:
+			cost = ship.Tonnage / 1000
+		case catalog.Transport:
+			cost = ship.Tonnage / 2500
+		default:
+			cost = ship.Tonnage / 500
+		}
+		if ship.SubLight {
+			cost -= cost / 4
+		}
+		total += cost
+	}
+	return total * (100 - min(100, sp.Tech[ML]/2)) / 100
+}
+
+// applyMaintenance takes each species' fleet maintenance out of the
+// production of its planets, each paying the same share of what it
+// produces. Whatever the planets can't pay comes from the treasury.
+func applyMaintenance(t *Turn) {
+	for _, id := range t.SpeciesIds() {
+		sp := t.Game.SpeciesById(id)
+		cost := t.Game.FleetMaintenance(sp)
+		if cost == 0 {
+			continue
+		}
+		var ledgers []*Ledger
+		production := 0
+		for _, ledger := range t.Game.Ledgers {
+			if t.Game.ColonyById(ledger.Colony).Species == sp.Id {
+				ledgers = append(ledgers, ledger)
+				production += ledger.Production() - ledger.Siege
+			}
+		}
+		paid := 0
+		for _, ledger := range ledgers {
+			if production > 0 {
+				ledger.Maintenance = min(cost, production) * (ledger.Production() - ledger.Siege) / production
+				paid += ledger.Maintenance
+			}
+		}
+		if production > 0 {
+			t.Logf(sp.Id, 0, "Fleet maintenance cost = %d (%d.%02d%% of total production)", cost, 100*cost/production, 10_000*cost/production%100)
+		} else {
+			t.Logf(sp.Id, 0, "Fleet maintenance cost = %d", cost)
+		}
+		if unpaid := cost - paid; unpaid > 0 {
+			t.audit(sp, -unpaid, "fleet maintenance not covered by production")
+		}
+	}
+}
+
+// drawable returns the economic units that the producing planet can take
+// from the treasury. Colonies can take up to what they produce, and home
+// planets can take everything.
+func (t *Turn) drawable(sp *Species, colony *Colony, ledger *Ledger) int {
+	if sp.Treasury <= 0 {
+		return 0
+	} else if colony.IsHome {
+		return sp.Treasury
+	}
+	return max(0, min(sp.Treasury, ledger.Production()-ledger.Treasury))
+}
+
+// send gives economic units from the treasury to another species.
+// The units are taken right away and delivered during housekeeping.
+// An amount of zero sends the whole treasury.
+func send(t *Turn, sp *Species, cmd *orders.Command) error {
+	n := cmd.Args[0].Number
+	if n == 0 {
+		n = sp.Treasury
+	}
+	other := t.Game.SpeciesNamed(cmd.Args[1].Name)
+	if other == nil || other == sp {
+		return ErrNoSuchSpecies
+	} else if !sp.HasMet(other.Id) {
+		return fmt.Errorf("%s: %w", other, ErrNotMet)
+	} else if t.Game.declaredEnemy(sp.Id, other.Id) {
+		return fmt.Errorf("%s is an enemy: %w", other, ErrNotAllowed)
+	} else if cmd.Args[0].Number < 0 {
+		return fmt.Errorf("%d: %w", n, ErrInvalidAmount)
+	} else if n <= 0 || n > sp.Treasury {
+		return fmt.Errorf("need %d, have %d: %w", n, sp.Treasury, ErrInsufficientFunds)
+	}
+	t.audit(sp, -n, "sent to %s", other)
+	t.Game.Transactions = append(t.Game.Transactions, &Transaction{Kind: "SEND", From: sp.Id, To: other.Id, Amount: n})
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+func TestFleetMaintenance(t *testing.T) {
+	g, sp, _ := newTestGame(t)
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: 1, Class: "CL", Name: "Light", Tonnage: 200_000},
+		{Id: 2, Species: 1, Class: "TR15", Name: "Hauler", Tonnage: 150_000},
+		{Id: 3, Species: 1, Class: "DN", SubLight: true, Name: "Slow", Tonnage: 500_000, Remaining: 1000},
+		{Id: 4, Species: 2, Class: "BAS", Name: "Not Ours", Tonnage: 100_000},
+	}
+	// the examples from the manual: 400, 60 and 750
+	sp.Tech[engine.ML] = 0
+	if got := g.FleetMaintenance(sp); got != 1210 {
+		t.Errorf("FleetMaintenance: expected 1210: got %d\n", got)
+	}
+	sp.Tech[engine.ML] = 27 // 13% discount
+	if got := g.FleetMaintenance(sp); got != 1052 {
+		t.Errorf("FleetMaintenance: ML 27: expected 1052: got %d\n", got)
+	}
+}
+
+func TestTreasury(t *testing.T) {
+	g, sp, earth := newTestGame(t)
+	g.Ships = []*engine.Ship{{Id: 1, Species: 1, Class: "CA", Name: "Cruiser", Tonnage: 300_000}}
+	sp.Treasury = 1000
+	production := map[int]string{1: "START PRODUCTION\nPRODUCTION PL Earth\nRESEARCH 5000 BI\nRESEARCH 1000 BI\nEND\n"}
+	runStep(t, g, engine.DefaultSteps()[3], production)
+
+	ledger := g.LedgerFor(earth.Id)
+	if cost := g.FleetMaintenance(sp); ledger.Maintenance != cost {
+		t.Errorf("maintenance: expected %d: got %d\n", cost, ledger.Maintenance)
+	}
+	// the home planet can draw on the whole treasury, but not more
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "insufficient funds") {
+		t.Errorf("treasury: log: expected insufficient funds for the first order: got %q\n", errs)
+	}
+	if short := 1000 - (ledger.Production() - ledger.Maintenance); short <= 0 || ledger.Treasury != short {
+		t.Errorf("treasury: expected %d from the treasury: got %d\n", short, ledger.Treasury)
+	}
+	// the audit explains the balance
+	balance := 1000
+	for _, e := range g.Audit {
+		if balance += e.Amount; e.Species != 1 || e.Balance != balance {
+			t.Errorf("audit: expected balance %d: got %+v\n", balance, *e)
+		}
+	}
+	if sp.Treasury != balance || sp.Treasury != 1000-ledger.Treasury+ledger.Unspent {
+		t.Errorf("treasury: expected %d: got %d\n", balance, sp.Treasury)
+	}
+}
+
+func TestSend(t *testing.T) {
+	g, _ := newBattleGame(t,
+		&engine.Ship{Species: 1, Class: "TR1", Name: "Hauler", Tonnage: 10_000},
+		&engine.Ship{Species: 2, Class: "TR1", Name: "Freighter", Tonnage: 10_000},
+	)
+	sp, klingon := g.SpeciesById(1), g.SpeciesById(2)
+	runStep(t, g, engine.DefaultSteps()[6], nil) // the species meet
+	sp.Treasury, klingon.Treasury = 500, 0
+
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nSEND 200 SP Klingon\nSEND 900 SP Klingon\nSEND 10 SP Nobody\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 2 || !strings.Contains(errs[0], "insufficient funds") || !strings.Contains(errs[1], "no such species") {
+		t.Errorf("send: log: expected 2 errors: got %q\n", errs)
+	}
+	if sp.Treasury != 300 || klingon.Treasury != 0 || len(g.Transactions) != 1 {
+		t.Errorf("send: expected 300 and 0 with 1 pending transaction: got %d and %d with %d\n", sp.Treasury, klingon.Treasury, len(g.Transactions))
+	}
+
+	// the transfer settles during housekeeping
+	runStep(t, g, engine.DefaultSteps()[6], nil)
+	if klingon.Treasury != 200 {
+		t.Errorf("send: expected 200 delivered: got %d\n", klingon.Treasury)
+	}
+	if len(g.Audit) != 1 || g.Audit[0].Species != 2 || g.Audit[0].Amount != 200 || g.Audit[0].Text != "SEND from SP Humanoid" {
+		t.Errorf("send: audit: expected the delivery: got %v\n", g.Audit)
+	}
+
+	// zero sends the whole treasury, and an empty treasury has nothing to send
+	runStep(t, g, engine.DefaultSteps()[4], map[int]string{1: "START POST-ARRIVAL\nSEND 0 SP Klingon\nSEND 0 SP Klingon\nEND\n"})
+	if errs := orderErrors(g); len(errs) != 1 || !strings.Contains(errs[0], "need 0, have 0: insufficient funds") {
+		t.Errorf("send: zero: expected 1 error: got %q\n", errs)
+	}
+	runStep(t, g, engine.DefaultSteps()[6], nil)
+	if sp.Treasury != 0 || klingon.Treasury != 500 {
+		t.Errorf("send: zero: expected 0 and 500: got %d and %d\n", sp.Treasury, klingon.Treasury)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+	"sort"
+)
+
+// Turn is the context for processing a single turn.
+type Turn struct {
+	Game   *Game
+	Orders map[int]*orders.Orders // keyed by species id
+	RNG    *RNG                   // the only source of random numbers for the turn
+	phase  string                 // name of the step being run
+	moved  map[int]bool           // ships that jumped or moved this turn
+
+	// state for the combat phases
+	battlePlans []*battlePlan       // every species' plans, in the order given
+	battlePlan  map[int]*battlePlan // plan from each species' last BATTLE order
+	betrayals   [][2]int            // attacker and victim for each attack on an ally
+
+	// state for the pre-departure phase
+	installations []*installation // colonial units to add to bases at the end of the turn
+
+	// state for the production phase
+	producing  map[int]*Colony        // planet that each species is producing on
+	produced   []int                  // colonies that have had production orders
+	research   map[int]*TechLevels    // amount spent on research by each species
+	shipyards  map[int]int            // shipyards used on each planet
+	expanded   map[int]bool           // planets that have built a shipyard
+	intercepts map[int]map[Coords]int // amount each species spent on interception in each system
+
+	// state for the post-arrival phase
+	telescopes map[int]bool // starbases that have used their telescopes
+}
+
+// Step is a single step in processing a turn.
+// Steps are run in order, and each one sees the changes made by the
+// steps before it.
+type Step struct {
+	Name string
+	Run  func(t *Turn) error
+}
+
+// DefaultSteps returns the steps for a turn in the order given by the manual:
+// all combat orders, then all pre-departure orders, jumps, production,
+// post-arrival and strikes, followed by housekeeping.
+func DefaultSteps() []Step {
+	return []Step{
+		combatStep("combat", orders.CombatSection, combatOrders),
+		phaseStep("pre-departure", orders.PreDepartureSection, preDepartureOrders),
+		jumpStep(),
+		productionStep(),
+		phaseStep("post-arrival", orders.PostArrivalSection, postArrivalOrders),
+		combatStep("strikes", orders.StrikesSection, strikeOrders),
+		{Name: "housekeeping", Run: housekeeping},
+	}
+}
+
+// RunTurn runs the orders for every species against the game and
+// advances the game to the next turn. The orders are keyed by species id.
+// Species without orders still take part in the turn.
+//
+// Errors in a player's orders are written to the turn log. The error
+// returned is for problems that keep the turn from being run at all.
+//
+// The random numbers for the turn come from the game's seed and the turn
+// number, so running the same turn again with the same orders gives the
+// same results. The seed is saved in the game.
+func RunTurn(g *Game, o map[int]*orders.Orders, opts ...Option) error {
+	options := Options{steps: DefaultSteps()}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	g.TurnSeed, g.Log, g.Battles, g.Scans, g.Observations, g.Estimates, g.Audit = TurnSeed(g.Seed, g.Turn), nil, nil, nil, nil, nil, nil
+	t := &Turn{Game: g, Orders: o, RNG: NewRNG(g.TurnSeed), moved: make(map[int]bool), research: make(map[int]*TechLevels), telescopes: make(map[int]bool)}
+	for _, ship := range g.Ships {
+		ship.InTransit = false
+	}
+	for _, colony := range g.Colonies {
+		colony.Hidden = false
+	}
+	for _, step := range options.steps {
+		t.phase = step.Name
+		if err := step.Run(t); err != nil {
+			return fmt.Errorf("%s: %w", step.Name, err)
+		}
+	}
+	g.Turn++
+
+	return nil
+}
+
+// Logf adds an entry for the species to the turn log.
+func (t *Turn) Logf(species, line int, format string, args ...any) {
+	t.Game.Log = append(t.Game.Log, &LogEntry{
+		Species: species,
+		Phase:   t.phase,
+		Line:    line,
+		Text:    fmt.Sprintf(format, args...),
+	})
+}
+
+// SpeciesIds returns the ids of all species in the game, in order.
+// Species are always processed in this order so that turns are repeatable.
+func (t *Turn) SpeciesIds() []int {
+	var ids []int
+	for _, sp := range t.Game.Species {
+		ids = append(ids, sp.Id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// handler executes a single order for a species.
+// An error from a handler means the order failed. It is logged and
+// processing continues with the next order.
+type handler func(t *Turn, sp *Species, cmd *orders.Command) error
+
+// The handlers for each phase, by verb.
+var (
+	combatOrders = map[orders.Verb]handler{
+		orders.Attack:   attack,
+		orders.Battle:   battle,
+		orders.Engage:   engage,
+		orders.Haven:    haven,
+		orders.Hide:     hide,
+		orders.Hijack:   attack,
+		orders.Summary:  summary,
+		orders.Target:   target,
+		orders.Withdraw: withdraw,
+	}
+	preDepartureOrders = map[orders.Verb]handler{
+		orders.Ally:     ally,
+		orders.Base:     base,
+		orders.Destroy:  destroy,
+		orders.Disband:  disband,
+		orders.Enemy:    enemy,
+		orders.Install:  install,
+		orders.Land:     land,
+		orders.Name:     name,
+		orders.Neutral:  neutral,
+		orders.Orbit:    orbit,
+		orders.Repair:   repair,
+		orders.Scan:     scan,
+		orders.Send:     send,
+		orders.Transfer: transfer,
+		orders.Unload:   unload,
+	}
+	jumpOrders = map[orders.Verb]handler{
+		orders.Jump:     jump,
+		orders.Move:     move,
+		orders.PJump:    portalJump,
+		orders.Visited:  visited,
+		orders.Wormhole: wormhole,
+	}
+	productionOrders = map[orders.Verb]handler{
+		orders.Ally:       ally,
+		orders.Ambush:     ambush,
+		orders.Build:      build,
+		orders.Continue:   continueBuilding,
+		orders.Develop:    develop,
+		orders.Enemy:      enemy,
+		orders.Estimate:   estimate,
+		orders.Hide:       hideColony,
+		orders.IBuild:     ibuild,
+		orders.IContinue:  icontinue,
+		orders.Intercept:  intercept,
+		orders.Neutral:    neutral,
+		orders.Production: produce,
+		orders.Recycle:    recycle,
+		orders.Research:   research,
+		orders.Shipyard:   shipyard,
+		orders.Upgrade:    upgrade,
+	}
+	postArrivalOrders = map[orders.Verb]handler{
+		orders.Ally:      ally,
+		orders.Destroy:   destroy,
+		orders.Enemy:     enemy,
+		orders.Land:      land,
+		orders.Name:      name,
+		orders.Neutral:   neutral,
+		orders.Orbit:     orbit,
+		orders.Repair:    repair,
+		orders.Scan:      scan,
+		orders.Send:      send,
+		orders.Teach:     teach,
+		orders.Telescope: telescope,
+		orders.Terraform: terraform,
+		orders.Transfer:  transfer,
+	}
+	strikeOrders = combatOrders
+)
+
+// phaseStep returns a step that runs the orders from one section of every
+// species' order file. Species are processed in id order and their orders
+// in the order they were given.
+func phaseStep(name string, section orders.Section, handlers map[orders.Verb]handler) Step {
+	return Step{Name: name, Run: func(t *Turn) error {
+		for _, id := range t.SpeciesIds() {
+			sp := t.Game.SpeciesById(id)
+			block := t.Orders[id].Section(section)
+			if block == nil {
+				continue
+			}
+			for _, cmd := range block.Commands {
+				h, ok := handlers[cmd.Verb]
+				if !ok {
+					t.Logf(sp.Id, cmd.Line, "%s: %s: order is not implemented", cmd.Text, cmd.Verb)
+					continue
+				}
+				if err := h(t, sp, cmd); err != nil {
+					t.Logf(sp.Id, cmd.Line, "%s: %v", cmd.Text, err)
+				}
+			}
+		}
+		return nil
+	}}
+}
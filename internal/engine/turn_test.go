@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+	"testing"
+)
+
+// sections are deliberately out of order
+const testOrders = `START STRIKES
+SUMMARY
+END
+START POST-ARRIVAL
+AUTO
+END
+START PRODUCTION
+SHIPYARD
+END
+START JUMPS
+VISITED 1 2 3
+END
+START PRE-DEPARTURE
+SCAN CA Hood
+END
+START COMBAT
+BATTLE 1 2 3
+END
+`
+
+func testGame(t *testing.T) (*engine.Game, map[int]*orders.Orders) {
+	g, err := engine.NewGame("test", 3, 1)
+	if err != nil {
+		t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+	}
+	g.Species = []*engine.Species{{Id: 2, Name: "Klingons"}, {Id: 1, Name: "Humans"}}
+	o, err := orders.Parse(strings.NewReader(testOrders))
+	if err != nil {
+		t.Fatalf("Parse: err: expected nil: got %v\n", err)
+	}
+	return g, map[int]*orders.Orders{1: o, 2: o}
+}
+
+func TestRunTurn(t *testing.T) {
+	g, o := testGame(t)
+	if err := engine.RunTurn(g, o); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	} else if g.Turn != 1 {
+		t.Errorf("RunTurn: turn: expected 1: got %d\n", g.Turn)
+	}
+
+	// phases run in manual order, and species in id order within a phase
+	var got []string
+	for _, e := range g.Log {
+		got = append(got, fmt.Sprintf("%s:%d", e.Phase, e.Species))
+	}
+	expect := "combat:1 combat:2 pre-departure:1 pre-departure:2 jumps:1 jumps:2 production:1 production:2 post-arrival:1 post-arrival:2 strikes:1 strikes:2"
+	if strings.Join(got, " ") != expect {
+		t.Errorf("RunTurn: log: expected %q: got %q\n", expect, strings.Join(got, " "))
+	}
+}
+
+func TestRunTurn_Steps(t *testing.T) {
+	// each phase step runs only the orders from its own section
+	for _, tc := range []struct {
+		step string
+		line int
+	}{
+		{"combat", 17},
+		{"pre-departure", 14},
+		{"jumps", 11},
+		{"production", 8},
+		{"post-arrival", 5},
+		{"strikes", 2},
+	} {
+		g, o := testGame(t)
+		var step engine.Step
+		for _, s := range engine.DefaultSteps() {
+			if s.Name == tc.step {
+				step = s
+			}
+		}
+		if step.Run == nil {
+			t.Fatalf("DefaultSteps: %s: expected step: got none\n", tc.step)
+		}
+		if err := engine.RunTurn(g, o, engine.WithSteps(step)); err != nil {
+			t.Fatalf("RunTurn: %s: err: expected nil: got %v\n", tc.step, err)
+		}
+		for _, e := range g.Log {
+			if e.Phase != tc.step || e.Line != tc.line {
+				t.Errorf("RunTurn: %s: log: expected line %d: got %s line %d\n", tc.step, tc.line, e.Phase, e.Line)
+			}
+		}
+		if len(g.Log) != 2 {
+			t.Errorf("RunTurn: %s: log: expected 2 entries: got %d\n", tc.step, len(g.Log))
+		}
+	}
+}
+
+func TestRunTurn_Housekeeping(t *testing.T) {
+	g, _ := testGame(t)
+	g.Colonies = []*engine.Colony{{Id: 1, Species: 1, Planet: g.Galaxy.Stars[0].Planets[0].Id, Name: "Earth", AvailablePopulation: 17}}
+	g.Transactions = []*engine.Transaction{{Kind: "SEND", From: 1, To: 2, Amount: 100}}
+	if err := engine.RunTurn(g, nil); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+	if g.Colonies[0].AvailablePopulation != 0 {
+		t.Errorf("housekeeping: available population: expected 0: got %d\n", g.Colonies[0].AvailablePopulation)
+	}
+	if len(g.Transactions) != 0 {
+		t.Errorf("housekeeping: transactions: expected 0: got %d\n", len(g.Transactions))
+	}
+	if len(g.Log) != 2 || g.Log[0].Species != 1 || g.Log[1].Species != 2 || g.Log[1].Text != "SEND 100 from SP Humans" {
+		t.Errorf("housekeeping: log: expected entries for both species: got %v\n", g.Log)
+	}
+
+	g.Transactions = []*engine.Transaction{{Kind: "SEND", From: 1, To: 9, Amount: 100}}
+	if err := engine.RunTurn(g, nil); err == nil {
+		t.Errorf("housekeeping: unknown species: expected error: got nil\n")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// The visibility rules decide what a species knows about the ships and
+// colonies of other species. Reports and orders that show anything about
+// aliens must go through them so that nothing leaks between players.
+//
+// A species sees everything that it owns. It sees aliens only in sectors
+// where it is present, which means it has a ship there that isn't in
+// transit, or a populated colony in the system. In those sectors it sees
+// ships in deep space and in orbit, starbases, and populated colonies.
+// It doesn't see ships that are landed on a planet that their owner
+// populates, ships under construction, or colonies that are hidden,
+// unless it has population on the same planet. Field distorted ships are
+// seen, but their owner shows up as a number.
+//
+// Ships that withdrew or were forced out of a battle are in transit until
+// the jump phase and can't be seen or see anything.
+
+// Contact is an alien ship or colony that a species can see.
+type Contact struct {
+	Species string         // owner's name as the observer sees it, e.g. "SP Klingon" or "SP 139"
+	Ship    *Ship          // nil for a colony
+	Colony  *Colony        // nil for a ship
+	Items   map[string]int // items that the observer can see, which is only planetary defenses on a shared planet
+}
+
+// ContactsAt returns the alien ships and colonies that the species can see
+// in a sector, ships first, in the order they are stored in the game.
+func (g *Game) ContactsAt(sp *Species, c Coords) []*Contact {
+	var contacts []*Contact
+	if !g.IsPresent(sp, c) {
+		return nil
+	}
+	for _, ship := range g.Ships {
+		if ship.Species != sp.Id && ship.Coords == c && g.CanSeeShip(sp, ship) {
+			contacts = append(contacts, &Contact{Species: g.Identify(sp, ship), Ship: ship})
+		}
+	}
+	for _, colony := range g.Colonies {
+		if colony.Species != sp.Id && g.Galaxy.StarOf(colony.Planet).Coords == c && g.CanSeeColony(sp, colony) {
+			contact := &Contact{Species: g.SpeciesById(colony.Species).String(), Colony: colony}
+			if n := colony.Inventory["PD"]; n != 0 && g.populates(sp.Id, colony.Planet) {
+				contact.Items = map[string]int{"PD": n}
+			}
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
+
+// IsPresent returns true if the species has a ship that isn't in transit
+// or a populated colony in the sector.
+func (g *Game) IsPresent(sp *Species, c Coords) bool {
+	for _, ship := range g.Ships {
+		if ship.Species == sp.Id && ship.Coords == c && !ship.isLeaving() {
+			return true
+		}
+	}
+	for _, colony := range g.Colonies {
+		if colony.Species == sp.Id && colony.Population() > 0 && g.Galaxy.StarOf(colony.Planet).Coords == c {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSeeShip returns true if the species can see the ship.
+func (g *Game) CanSeeShip(sp *Species, ship *Ship) bool {
+	if ship.Species == sp.Id {
+		return true
+	} else if ship.isLeaving() || !g.IsPresent(sp, ship.Coords) {
+		return false
+	} else if ship.Planet == 0 || (ship.Status != Landed && !ship.IsUnderConstruction()) {
+		return true
+	} else if g.populates(sp.Id, ship.Planet) {
+		return true
+	}
+	return !ship.IsUnderConstruction() && !g.populates(ship.Species, ship.Planet)
+}
+
+// CanSeeColony returns true if the species can see the colony.
+// Colonies without population can't be seen by anyone else.
+func (g *Game) CanSeeColony(sp *Species, colony *Colony) bool {
+	if colony.Species == sp.Id {
+		return true
+	} else if colony.Population() == 0 {
+		return false
+	} else if g.populates(sp.Id, colony.Planet) {
+		return true
+	}
+	return !colony.Hidden && g.IsPresent(sp, g.Galaxy.StarOf(colony.Planet).Coords)
+}
+
+// Identify returns the name of the ship's owner as the species sees it.
+// Field distorted ships are only known by the owner's pseudonym.
+func (g *Game) Identify(sp *Species, ship *Ship) string {
+	owner := g.SpeciesById(ship.Species)
+	if ship.Species != sp.Id && ship.IsDistorted() {
+		return fmt.Sprintf("SP %d", g.Pseudonym(owner))
+	}
+	return owner.String()
+}
+
+// isVisibleFromAfar returns true if a gravitic telescope can see the ship.
+// Landed ships, ships under construction, field distorted ships and ships
+// in transit can't be seen from outside the sector.
+func (s *Ship) isVisibleFromAfar() bool {
+	return s.Status != Landed && !s.IsUnderConstruction() && !s.IsDistorted() && !s.isLeaving()
+}
+
+// isLeaving returns true if the ship withdrew or was forced out of a battle
+// and is in transit until the jump phase.
+func (s *Ship) isLeaving() bool {
+	return s.Withdrawn || s.Forced != ""
+}
+
+// populates returns true if the species has population on the planet.
+func (g *Game) populates(species, planet int) bool {
+	colony := g.colonyOn(species, planet)
+	return colony != nil && colony.Population() > 0
+}
+
+// hideColony hides the producing colony from aliens in its system until
+// the end of the turn. The cost is the colony's mining and manufacturing
+// base, dropping fractions. Home planets, resort colonies and colonies under
+// siege can't be hidden. Mining colonies pay with what they mine, which
+// is the only thing they can spend it on. Ships are hidden with combat
+// orders.
+func hideColony(t *Turn, sp *Species, cmd *orders.Command) error {
+	if len(cmd.Args) != 0 {
+		return fmt.Errorf("ships are hidden in the combat section: %w", ErrNotAllowed)
+	}
+	colony := t.producing[sp.Id]
+	if colony == nil {
+		return ErrNoProduction
+	} else if colony.IsHome || t.Game.IsResortColony(colony) {
+		return fmt.Errorf("PL %s: only normal and mining colonies: %w", colony.Name, ErrNotAllowed)
+	} else if len(t.Game.SiegesOf(colony.Id)) != 0 {
+		return fmt.Errorf("PL %s: under siege: %w", colony.Name, ErrNotAllowed)
+	}
+	cost := (colony.MiningBase + colony.ManufacturingBase) / 10
+	if colony.IsMiningColony() {
+		ledger := t.Game.LedgerFor(colony.Id)
+		if cost > ledger.Converted {
+			return fmt.Errorf("need %d, have %d: %w", cost, ledger.Converted, ErrInsufficientFunds)
+		}
+		ledger.Converted -= cost
+		t.audit(sp, -cost, "spent on PL %s: %s", colony.Name, cmd.Text)
+		ledger.Entries = append(ledger.Entries, &LedgerEntry{Line: cmd.Line, Text: cmd.Text, Amount: cost})
+	} else if err := t.spend(sp, cmd, cost); err != nil {
+		return err
+	}
+	colony.Hidden = true
+	return nil
+}
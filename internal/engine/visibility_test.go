@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package engine_test
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"strings"
+	"testing"
+)
+
+// contactNames returns the names of the contacts, with the owner as seen.
+func contactNames(contacts []*engine.Contact) string {
+	var names []string
+	for _, c := range contacts {
+		if c.Ship != nil {
+			names = append(names, fmt.Sprintf("%s %s", c.Species, c.Ship))
+		} else {
+			names = append(names, fmt.Sprintf("%s PL %s", c.Species, c.Colony.Name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestVisibility(t *testing.T) {
+	g, coords := newBattleGame(t,
+		&engine.Ship{Species: 2, Class: "CA", Name: "Orbiter", Tonnage: 300_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Lander", Tonnage: 300_000},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Keel", Tonnage: 300_000, Remaining: 100},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Ghost", Tonnage: 300_000, Cargo: map[string]int{"FD": 30}},
+		&engine.Ship{Species: 2, Class: "CA", Name: "Runner", Tonnage: 300_000, Withdrawn: true},
+	)
+	human, klingon := g.SpeciesById(1), g.SpeciesById(2)
+	earth := g.ColonyNamed(1, "Earth")
+	planet := otherPlanet(g, earth)
+	dukh := &engine.Colony{Id: 99, Species: 2, Planet: planet.Id, Name: "Khaarsh Dukh", MiningBase: 100, ManufacturingBase: 100, Inventory: map[string]int{"PD": 5}}
+	g.Colonies = append(g.Colonies, dukh)
+	for _, name := range []string{"Orbiter", "Lander", "Keel"} {
+		ship := g.ShipNamed(2, name)
+		ship.Planet, ship.Status = planet.Id, engine.Landed
+	}
+	g.ShipNamed(2, "Orbiter").Status = engine.InOrbit
+	ghost := fmt.Sprintf("SP %d", g.Pseudonym(klingon))
+
+	// landed ships and ships under construction are hidden by the colony,
+	// ships that withdrew are gone, and the distorted ship has no owner
+	expect := "SP Klingon CA Orbiter, " + ghost + " CA Ghost, SP Klingon PL Khaarsh Dukh"
+	if got := contactNames(g.ContactsAt(human, coords)); got != expect {
+		t.Errorf("visibility: expected %q: got %q\n", expect, got)
+	}
+	if g.ContactsAt(human, engine.Coords{X: coords.X + 1, Y: coords.Y, Z: coords.Z}) != nil {
+		t.Errorf("visibility: expected nothing where the Humanoids aren't\n")
+	}
+
+	// a hidden colony can't be seen either
+	dukh.Hidden = true
+	expect = "SP Klingon CA Orbiter, " + ghost + " CA Ghost"
+	if got := contactNames(g.ContactsAt(human, coords)); got != expect {
+		t.Errorf("visibility: hidden: expected %q: got %q\n", expect, got)
+	}
+
+	// but everything on a planet is seen by anyone living there
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 100, Species: 1, Planet: planet.Id, Name: "Mars", Inventory: map[string]int{"CU": 1}})
+	contacts := g.ContactsAt(human, coords)
+	expect = "SP Klingon CA Orbiter, SP Klingon CA Lander, SP Klingon CA Keel, " + ghost + " CA Ghost, SP Klingon PL Khaarsh Dukh"
+	if got := contactNames(contacts); got != expect {
+		t.Errorf("visibility: shared: expected %q: got %q\n", expect, got)
+	}
+	if items := contacts[len(contacts)-1].Items; items["PD"] != 5 {
+		t.Errorf("visibility: shared: expected 5 PD: got %v\n", items)
+	}
+}
+
+func TestHideColony(t *testing.T) {
+	g, coords := newBattleGame(t)
+	earth := g.ColonyNamed(1, "Earth")
+	planet := otherPlanet(g, earth)
+	dukh := &engine.Colony{Id: 99, Species: 2, Planet: planet.Id, Name: "Khaarsh Dukh", MiningBase: 100, ManufacturingBase: 257}
+	g.Colonies = append(g.Colonies, dukh)
+	klingon := g.SpeciesById(2)
+	klingon.Tech[engine.MI], klingon.Tech[engine.MA], klingon.Tech[engine.LS] = 50, 50, 99
+	runStep(t, g, engine.DefaultSteps()[3], map[int]string{
+		1: "START PRODUCTION\nPRODUCTION PL Earth\nHIDE\nEND\n",
+		2: "START PRODUCTION\nPRODUCTION PL Kronos\nHIDE\nPRODUCTION PL Khaarsh Dukh\nHIDE\nHIDE CA Orbiter\nEND\n",
+	})
+	errs := orderErrors(g)
+	if len(errs) != 3 || !strings.Contains(errs[0], "not allowed here") || !strings.Contains(errs[1], "not allowed here") || !strings.Contains(errs[2], "combat section") {
+		t.Errorf("hide: log: expected 3 errors: got %q\n", errs)
+	}
+	if !dukh.Hidden || earth.Hidden {
+		t.Fatalf("hide: expected only the colony to be hidden\n")
+	}
+	if spent := g.LedgerFor(dukh.Id).Spent; spent != 35 {
+		t.Errorf("hide: expected to spend 35: got %d\n", spent)
+	}
+	if got := contactNames(g.ContactsAt(g.SpeciesById(1), coords)); got != "" {
+		t.Errorf("hide: expected no contacts: got %q\n", got)
+	}
+
+	// hiding only lasts for the turn
+	runStep(t, g, engine.DefaultSteps()[3], nil)
+	if got := contactNames(g.ContactsAt(g.SpeciesById(1), coords)); got != "SP Klingon PL Khaarsh Dukh" {
+		t.Errorf("hide: next turn: expected the colony: got %q\n", got)
+	}
+}
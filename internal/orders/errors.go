@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package orders
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is an error in an order file.
+// It quotes the offending line so that players can find and fix it.
+type Error struct {
+	Line int    // line number in the order file
+	Text string // the line as entered
+	Msg  string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %q: %s", e.Line, e.Text, e.Msg)
+}
+
+// ErrorList is the list of errors found while parsing an order file.
+type ErrorList []*Error
+
+// Error implements the error interface.
+func (el ErrorList) Error() string {
+	var lines []string
+	for _, e := range el {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+// Package orders implements a parser for Far Horizons order files.
+package orders
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Orders is the parsed content of a player's order file.
+type Orders struct {
+	Blocks []*Block
+}
+
+// Section returns the block for the section or nil if the file doesn't have one.
+func (o *Orders) Section(s Section) *Block {
+	if o == nil {
+		return nil
+	}
+	for _, b := range o.Blocks {
+		if b.Section == s {
+			return b
+		}
+	}
+	return nil
+}
+
+// Block is a section of the order file, from the START command to the END.
+type Block struct {
+	Section  Section
+	Line     int // line number of the START command
+	Commands []*Command
+}
+
+// Command is a single order.
+type Command struct {
+	Line    int    // line number in the order file
+	Text    string // the order as entered, without any comment
+	Verb    Verb
+	Args    []Arg
+	Message []string // lines of a MESSAGE, not including the ZZZ
+}
+
+// Pattern returns the kinds of the command's arguments as a string
+// (see ArgKind for the letters used).
+func (c *Command) Pattern() string {
+	var sb strings.Builder
+	for _, arg := range c.Args {
+		sb.WriteByte(byte(arg.Kind))
+	}
+	return sb.String()
+}
+
+// String implements the Stringer interface.
+func (c *Command) String() string {
+	return fmt.Sprintf("line %d: %q", c.Line, c.Text)
+}
+
+// ArgKind is the kind of argument.
+// The values are the letters used in argument patterns.
+type ArgKind byte
+
+const (
+	Number  ArgKind = 'n' // a whole number, 0 or more
+	Abbr    ArgKind = 'a' // an item or technology abbreviation, e.g. "PD" or "GV"
+	Ship    ArgKind = 's' // a ship or starbase name, including the class abbreviation
+	Planet  ArgKind = 'p' // a planet name, including "PL"
+	Species ArgKind = 'x' // a species name, including "SP"
+)
+
+// Arg is an argument to a command.
+type Arg struct {
+	Kind   ArgKind
+	Number int    // value of a Number
+	Class  string // upper-case class abbreviation, e.g. "PL", "TR10S", or "PD"
+	Name   string // name of a Ship, Planet, or Species, as entered
+}
+
+// String implements the Stringer interface.
+func (a Arg) String() string {
+	switch a.Kind {
+	case Number:
+		return fmt.Sprintf("%d", a.Number)
+	case Abbr:
+		return a.Class
+	}
+	return a.Class + " " + a.Name
+}
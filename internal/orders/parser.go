@@ -0,0 +1,232 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package orders
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parse reads an order file and returns the commands in it.
+//
+// Commands with errors are left out of the result. If any errors are found,
+// the returned error is an ErrorList, and the orders that were accepted are
+// still returned so that the rest of the player's turn can be processed.
+func Parse(r io.Reader) (*Orders, error) {
+	p := &parser{orders: &Orders{}, seen: make(map[Section]bool)}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		p.parseLine(strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if p.message != nil {
+		p.errorf(p.message.Line, p.message.Text, "MESSAGE is missing ZZZ")
+	}
+	if p.block != nil {
+		p.errorf(p.block.Line, "START "+p.block.Section.String(), "missing END for %s section", p.block.Section)
+	}
+
+	if len(p.errors) != 0 {
+		return p.orders, p.errors
+	}
+	return p.orders, nil
+}
+
+type parser struct {
+	orders  *Orders
+	line    int
+	block   *Block           // the current section, nil between sections
+	skip    bool             // true while skipping a duplicate section
+	seen    map[Section]bool // sections that have been started
+	message *Command         // the MESSAGE being read, nil if none
+	errors  ErrorList
+}
+
+func (p *parser) errorf(line int, text, format string, args ...any) {
+	p.errors = append(p.errors, &Error{Line: line, Text: text, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) parseLine(raw string) {
+	// message text is copied as is, up to the ZZZ
+	if p.message != nil {
+		if word, _ := split(raw); strings.HasPrefix(strings.ToUpper(word), "ZZZ") {
+			p.message = nil
+		} else {
+			p.message.Message = append(p.message.Message, raw)
+		}
+		return
+	}
+
+	text := raw
+	if n := strings.IndexByte(text, ';'); n != -1 {
+		text = text[:n]
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	word, rest := split(text)
+	switch strings.ToUpper(word) {
+	case "START":
+		section, ok := parseSection(strings.TrimSpace(rest))
+		if !ok {
+			p.errorf(p.line, text, "unknown section")
+			return
+		}
+		if p.block != nil {
+			p.errorf(p.line, text, "missing END for %s section", p.block.Section)
+		}
+		p.block, p.skip = &Block{Section: section, Line: p.line}, p.seen[section]
+		if p.skip {
+			p.errorf(p.line, text, "duplicate %s section", section)
+			return
+		}
+		p.seen[section] = true
+		p.orders.Blocks = append(p.orders.Blocks, p.block)
+		return
+	case "END":
+		if p.block == nil {
+			p.errorf(p.line, text, "END outside of a section")
+			return
+		}
+		if name := strings.TrimSpace(rest); name != "" {
+			if section, ok := parseSection(name); !ok || section != p.block.Section {
+				p.errorf(p.line, text, "END does not match START %s", p.block.Section)
+			}
+		}
+		p.block, p.skip = nil, false
+		return
+	}
+
+	if p.block == nil {
+		p.errorf(p.line, text, "command outside of a section")
+		return
+	} else if p.skip {
+		return
+	}
+
+	if strings.HasPrefix(strings.ToUpper(word), "ZZZ") {
+		p.errorf(p.line, text, "ZZZ without MESSAGE")
+		return
+	}
+	verb, ok := parseVerb(word)
+	if !ok {
+		p.errorf(p.line, text, "unknown command")
+		return
+	}
+	cmd := &Command{Line: p.line, Text: text, Verb: verb}
+	if verb == Message {
+		// the text up to the ZZZ is read even if the MESSAGE is
+		// rejected, so that it isn't mistaken for orders
+		p.message = cmd
+	}
+	if !verb.AllowedIn(p.block.Section) {
+		p.errorf(p.line, text, "%s is not allowed in the %s section", verb, p.block.Section)
+		return
+	}
+	args, err := parseArgs(rest)
+	if err != nil {
+		p.errorf(p.line, text, "%v", err)
+		return
+	}
+	cmd.Args = args
+	if !verb.accepts(cmd.Pattern()) {
+		p.errorf(p.line, text, "invalid arguments for %s", verb)
+		return
+	}
+	p.block.Commands = append(p.block.Commands, cmd)
+}
+
+// parseArgs splits the rest of a command into arguments.
+// Names run from the class abbreviation to a comma, a tab, or the end of
+// the line, so they may contain spaces. Numbers may be followed by a period.
+func parseArgs(s string) ([]Arg, error) {
+	var args []Arg
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return args, nil
+		}
+
+		// numbers
+		if n := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }); n != 0 {
+			if n == -1 {
+				n = len(s)
+			}
+			value, err := strconv.Atoi(s[:n])
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", s[:n])
+			}
+			args = append(args, Arg{Kind: Number, Number: value})
+			s = strings.TrimPrefix(s[n:], ".")
+			continue
+		}
+
+		// abbreviations and names
+		n := strings.IndexAny(s, " \t,")
+		if n == -1 {
+			n = len(s)
+		}
+		abbr := strings.ToUpper(s[:n])
+		s = s[n:]
+		kind, ok := classify(abbr)
+		if !ok {
+			return nil, fmt.Errorf("unknown abbreviation %q", abbr)
+		} else if kind == Abbr {
+			args = append(args, Arg{Kind: Abbr, Class: abbr})
+			continue
+		}
+		s = strings.TrimLeft(s, " \t")
+		if n = strings.IndexAny(s, "\t,"); n == -1 {
+			n = len(s)
+		}
+		name := strings.TrimSpace(s[:n])
+		s = s[n:]
+		if name == "" {
+			return nil, fmt.Errorf("missing name after %s", abbr)
+		}
+		args = append(args, Arg{Kind: kind, Class: abbr, Name: name})
+	}
+}
+
+// techs are the abbreviations of technologies.
+var techs = map[string]bool{
+	"MI": true, "MA": true, "ML": true, "GV": true, "LS": true, "BI": true,
+}
+
+// classify returns the kind of argument that an abbreviation starts.
+func classify(abbr string) (ArgKind, bool) {
+	switch {
+	case abbr == "PL":
+		return Planet, true
+	case abbr == "SP":
+		return Species, true
+	case techs[abbr]:
+		return Abbr, true
+	}
+	if _, ok := catalog.LookupItem(abbr); ok {
+		return Abbr, true
+	} else if _, _, err := catalog.LookupShip(abbr); err == nil {
+		return Ship, true
+	}
+	return 0, false
+}
+
+// split returns the first word of the text and the rest of the text.
+func split(text string) (string, string) {
+	text = strings.TrimLeft(text, " \t")
+	if n := strings.IndexAny(text, " \t"); n != -1 {
+		return text[:n], text[n:]
+	}
+	return text, ""
+}
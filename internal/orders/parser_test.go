@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package orders_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/orders"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `; orders for turn 7
+START COMBAT
+	BATTLE	4 12 3
+	ENGAGE	2 4	; protect planet #4
+	ATTACK	SP 171
+END
+
+START PRE-DEPARTURE
+	TRANSFER 100 RM PL Earth, BAS Mars Orbit 1
+	MESSAGE	SP Klingons
+Greetings; we come in peace.
+	ZZZ
+END
+
+start jumps
+	jump	PB  Benjamin Franklin,  12  7   18
+	JUMP	FF Thomas Edison, PL Mars
+END
+
+START PRODUCTION
+	PRODUCTION PL Earth
+	BUILD	TR10S Barrel of Monkeys
+	BUILD	CL	Guardian,	500
+	build	3 gu2
+	RESearch 27 BI
+END PRODUCTION
+`
+	o, err := orders.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: err: expected nil: got %v\n", err)
+	} else if len(o.Blocks) != 4 {
+		t.Fatalf("Parse: blocks: expected 4: got %d\n", len(o.Blocks))
+	}
+
+	combat := o.Section(orders.CombatSection)
+	if combat == nil || combat.Line != 2 || len(combat.Commands) != 3 {
+		t.Fatalf("Parse: combat: expected 3 commands at line 2: got %+v\n", combat)
+	}
+	if cmd := combat.Commands[2]; cmd.Verb != orders.Attack || cmd.Line != 5 || cmd.Args[0].Kind != orders.Species || cmd.Args[0].Name != "171" {
+		t.Errorf("Parse: attack: got %+v\n", cmd)
+	}
+
+	pre := o.Section(orders.PreDepartureSection)
+	if cmd := pre.Commands[0]; cmd.Pattern() != "naps" || cmd.Args[3].Class != "BAS" || cmd.Args[3].Name != "Mars Orbit 1" {
+		t.Errorf("Parse: transfer: got %q %+v\n", cmd.Pattern(), cmd.Args)
+	}
+	if cmd := pre.Commands[1]; cmd.Verb != orders.Message || len(cmd.Message) != 1 || cmd.Message[0] != "Greetings; we come in peace." {
+		t.Errorf("Parse: message: got %+v\n", cmd)
+	}
+
+	jumps := o.Section(orders.JumpsSection)
+	if cmd := jumps.Commands[0]; cmd.Pattern() != "snnn" || cmd.Args[0].Name != "Benjamin Franklin" || cmd.Args[3].Number != 18 {
+		t.Errorf("Parse: jump: got %q %+v\n", cmd.Pattern(), cmd.Args)
+	}
+
+	production := o.Section(orders.ProductionSection)
+	for i, expect := range []struct {
+		verb    orders.Verb
+		pattern string
+		class   string
+	}{
+		{orders.Production, "p", "PL"},
+		{orders.Build, "s", "TR10S"},
+		{orders.Build, "sn", "CL"},
+		{orders.Build, "na", ""},
+		{orders.Research, "na", ""},
+	} {
+		cmd := production.Commands[i]
+		if cmd.Verb != expect.verb || cmd.Pattern() != expect.pattern {
+			t.Errorf("Parse: line %d: expected %s %q: got %s %q\n", cmd.Line, expect.verb, expect.pattern, cmd.Verb, cmd.Pattern())
+		} else if expect.class != "" && cmd.Args[0].Class != expect.class {
+			t.Errorf("Parse: line %d: class: expected %q: got %q\n", cmd.Line, expect.class, cmd.Args[0].Class)
+		}
+	}
+	if cmd := production.Commands[3]; cmd.Args[1].Class != "GU2" {
+		t.Errorf("Parse: build: class: expected %q: got %q\n", "GU2", cmd.Args[1].Class)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		input  string
+		expect string
+	}{
+		{"START JUMPS\nBUILD 10 PD\nEND\n", `line 2: "BUILD 10 PD": BUILD is not allowed in the JUMPS section`},
+		{"START COMBAT\nEND\nSTART COMBAT\nEND\n", `line 3: "START COMBAT": duplicate COMBAT section`},
+		{"BUILD 10 PD\n", `line 1: "BUILD 10 PD": command outside of a section`},
+		{"START PRODUCTION\nFOOBAR\nEND\n", `line 2: "FOOBAR": unknown command`},
+		{"START PRODUCTION\nBUILD 10 XX\nEND\n", `line 2: "BUILD 10 XX": unknown abbreviation "XX"`},
+		{"START PRODUCTION\nBUILD PL\nEND\n", `line 2: "BUILD PL": missing name after PL`},
+		{"START PRODUCTION\nRESEARCH BI 27 ; oops\nEND\n", `line 2: "RESEARCH BI 27": invalid arguments for RESEARCH`},
+		{"START PRODUCTION\n", `line 1: "START PRODUCTION": missing END for PRODUCTION section`},
+		{"START STRIKES\nEND COMBAT\n", `line 2: "END COMBAT": END does not match START STRIKES`},
+		{"START POST-ARRIVAL\nMESSAGE SP Vulcans\nhello\nEND\n", `line 2: "MESSAGE SP Vulcans": MESSAGE is missing ZZZ`},
+		{"START BREAKFAST\nEND\n", `line 1: "START BREAKFAST": unknown section`},
+	} {
+		_, err := orders.Parse(strings.NewReader(tc.input))
+		var el orders.ErrorList
+		if !errors.As(err, &el) {
+			t.Errorf("Parse: %q: err: expected ErrorList: got %v\n", tc.input, err)
+			continue
+		}
+		if el[0].Error() != tc.expect {
+			t.Errorf("Parse: %q: err: expected %q: got %q\n", tc.input, tc.expect, el[0].Error())
+		}
+	}
+}
+
+func TestParse_RejectedMessage(t *testing.T) {
+	// the text of a rejected MESSAGE is still skipped up to the ZZZ
+	for _, input := range []string{
+		"START PRODUCTION\nMESSAGE SP Vulcans\nPRODUCTION PL Earth\nSHIPYARD\nZZZ\nEND\n",
+		"START POST-ARRIVAL\nMESSAGE 12\nAUTO\nZZZ\nEND\n",
+	} {
+		o, err := orders.Parse(strings.NewReader(input))
+		var el orders.ErrorList
+		if !errors.As(err, &el) || len(el) != 1 || el[0].Line != 2 {
+			t.Errorf("Parse: %q: err: expected 1 error on line 2: got %v\n", input, err)
+		}
+		for _, block := range o.Blocks {
+			if len(block.Commands) != 0 {
+				t.Errorf("Parse: %q: commands: expected none: got %v\n", input, block.Commands)
+			}
+		}
+	}
+}
+
+func TestParse_Partial(t *testing.T) {
+	// commands with errors are dropped, the rest are kept
+	o, err := orders.Parse(strings.NewReader("START PRODUCTION\nPRODUCTION PL Earth\nBUILD 10\nSHIPYARD\nEND\n"))
+	if err == nil {
+		t.Fatalf("Parse: err: expected error: got nil\n")
+	} else if o == nil {
+		t.Fatalf("Parse: orders: expected orders: got nil\n")
+	}
+	block := o.Section(orders.ProductionSection)
+	if len(block.Commands) != 2 || block.Commands[1].Verb != orders.Shipyard {
+		t.Errorf("Parse: commands: expected PRODUCTION, SHIPYARD: got %v\n", block.Commands)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package orders
+
+import (
+	"strings"
+)
+
+// Section is one of the sections of an order file.
+// The sections are listed in the order that they are executed.
+type Section int
+
+const (
+	CombatSection Section = iota + 1
+	PreDepartureSection
+	JumpsSection
+	ProductionSection
+	PostArrivalSection
+	StrikesSection
+)
+
+// Sections is the list of all sections, in execution order.
+var Sections = []Section{CombatSection, PreDepartureSection, JumpsSection, ProductionSection, PostArrivalSection, StrikesSection}
+
+var sectionNames = []string{"", "COMBAT", "PRE-DEPARTURE", "JUMPS", "PRODUCTION", "POST-ARRIVAL", "STRIKES"}
+
+// String implements the Stringer interface.
+func (s Section) String() string {
+	if s < CombatSection || s > StrikesSection {
+		return "?"
+	}
+	return sectionNames[s]
+}
+
+// parseSection returns the section for a name from a START or END command.
+// Case is not significant.
+func parseSection(name string) (Section, bool) {
+	for _, s := range Sections {
+		if strings.EqualFold(name, sectionNames[s]) {
+			return s, true
+		}
+	}
+	return 0, false
+}
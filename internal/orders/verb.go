@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package orders
+
+import (
+	"strings"
+)
+
+// Verb is the command word of an order.
+type Verb int
+
+const (
+	Ally Verb = iota + 1
+	Ambush
+	Attack
+	Auto
+	Base
+	Battle
+	Build
+	Continue
+	Deep
+	Destroy
+	Develop
+	Disband
+	Enemy
+	Engage
+	Estimate
+	Haven
+	Hide
+	Hijack
+	IBuild
+	IContinue
+	Install
+	Intercept
+	Jump
+	Land
+	Message
+	Move
+	Name
+	Neutral
+	Orbit
+	PJump
+	Production
+	Recycle
+	Repair
+	Research
+	Scan
+	Send
+	Shipyard
+	Summary
+	Target
+	Teach
+	Telescope
+	Terraform
+	Transfer
+	Unload
+	Upgrade
+	Visited
+	Withdraw
+	Wormhole
+)
+
+// verbInfo is the command name, the sections that the command may be
+// used in, and the argument patterns that it accepts.
+// Patterns use the ArgKind letters, so "sn" is a ship (or starbase)
+// followed by a number. An empty pattern means no arguments.
+type verbInfo struct {
+	name     string
+	sections []Section
+	patterns []string
+}
+
+var (
+	combat       = []Section{CombatSection, StrikesSection}
+	preDeparture = []Section{PreDepartureSection}
+	jumps        = []Section{JumpsSection}
+	production   = []Section{ProductionSection}
+	postArrival  = []Section{PostArrivalSection}
+	prePost      = []Section{PreDepartureSection, PostArrivalSection}
+	diplomacy    = []Section{PreDepartureSection, ProductionSection, PostArrivalSection}
+)
+
+// verbs is indexed by Verb.
+// The table follows the command summary and the section lists in the manual.
+var verbs = []verbInfo{
+	{},
+	Ally:       {"ALLY", diplomacy, []string{"x"}},
+	Ambush:     {"AMBUSH", production, []string{"n"}},
+	Attack:     {"ATTACK", combat, []string{"x", "n"}},
+	Auto:       {"AUTO", postArrival, []string{""}},
+	Base:       {"BASE", preDeparture, []string{"ss", "nss", "ps", "nps"}},
+	Battle:     {"BATTLE", combat, []string{"nnn"}},
+	Build:      {"BUILD", production, []string{"na", "s", "sn"}},
+	Continue:   {"CONTINUE", production, []string{"s", "sn"}},
+	Deep:       {"DEEP", prePost, []string{"s"}},
+	Destroy:    {"DESTROY", prePost, []string{"s"}},
+	Develop:    {"DEVELOP", production, []string{"", "n", "p", "np", "ps", "nps"}},
+	Disband:    {"DISBAND", preDeparture, []string{"p"}},
+	Enemy:      {"ENEMY", diplomacy, []string{"x", "n"}},
+	Engage:     {"ENGAGE", combat, []string{"n", "nn"}},
+	Estimate:   {"ESTIMATE", production, []string{"x"}},
+	Haven:      {"HAVEN", combat, []string{"nnn"}},
+	Hide:       {"HIDE", []Section{CombatSection, ProductionSection, StrikesSection}, []string{"", "s"}},
+	Hijack:     {"HIJACK", combat, []string{"x", "n"}},
+	IBuild:     {"IBUILD", production, []string{"xna", "xs", "xsn"}},
+	IContinue:  {"ICONTINUE", production, []string{"xs", "xsn"}},
+	Install:    {"INSTALL", preDeparture, []string{"nap", "p"}},
+	Intercept:  {"INTERCEPT", production, []string{"n"}},
+	Jump:       {"JUMP", jumps, []string{"sp", "snnn"}},
+	Land:       {"LAND", prePost, []string{"s", "sn", "sp"}},
+	Message:    {"MESSAGE", prePost, []string{"x"}},
+	Move:       {"MOVE", jumps, []string{"snnn"}},
+	Name:       {"NAME", prePost, []string{"nnnnp"}},
+	Neutral:    {"NEUTRAL", diplomacy, []string{"x", "n"}},
+	Orbit:      {"ORBIT", prePost, []string{"s", "sn", "sp"}},
+	PJump:      {"PJUMP", jumps, []string{"sps", "snnns"}},
+	Production: {"PRODUCTION", production, []string{"p"}},
+	Recycle:    {"RECYCLE", production, []string{"na", "s"}},
+	Repair:     {"REPAIR", prePost, []string{"s", "sn", "nnn", "nnnn"}},
+	Research:   {"RESEARCH", production, []string{"na"}},
+	Scan:       {"SCAN", prePost, []string{"s"}},
+	Send:       {"SEND", prePost, []string{"nx"}},
+	Shipyard:   {"SHIPYARD", production, []string{""}},
+	Summary:    {"SUMMARY", combat, []string{""}},
+	Target:     {"TARGET", combat, []string{"n"}},
+	Teach:      {"TEACH", postArrival, []string{"ax", "anx"}},
+	Telescope:  {"TELESCOPE", postArrival, []string{"s"}},
+	Terraform:  {"TERRAFORM", postArrival, []string{"p", "np"}},
+	Transfer:   {"TRANSFER", prePost, []string{"nass", "nasp", "naps", "napp"}},
+	Unload:     {"UNLOAD", preDeparture, []string{"s"}},
+	Upgrade:    {"UPGRADE", production, []string{"s", "sn"}},
+	Visited:    {"VISITED", jumps, []string{"nnn"}},
+	Withdraw:   {"WITHDRAW", combat, []string{"nnn"}},
+	Wormhole:   {"WORMHOLE", jumps, []string{"s", "sp"}},
+}
+
+// String implements the Stringer interface.
+func (v Verb) String() string {
+	if v < Ally || v > Wormhole {
+		return "?"
+	}
+	return verbs[v].name
+}
+
+// AllowedIn returns true if the command may be used in the section.
+func (v Verb) AllowedIn(s Section) bool {
+	if v < Ally || v > Wormhole {
+		return false
+	}
+	for _, section := range verbs[v].sections {
+		if section == s {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts returns true if the command accepts the argument pattern.
+func (v Verb) accepts(pattern string) bool {
+	for _, p := range verbs[v].patterns {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVerb returns the verb for a command word.
+// Like the original game, only the first three letters are significant.
+func parseVerb(word string) (Verb, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	prefix := strings.ToUpper(word[:3])
+	for v := Ally; v <= Wormhole; v++ {
+		if strings.HasPrefix(verbs[v].name, prefix) {
+			return v, true
+		}
+	}
+	return 0, false
+}
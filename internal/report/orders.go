@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package report
+
+import (
+	"github.com/mdhender/fh/internal/orders"
+)
+
+// placeholders are the comments that the manual puts in each section of
+// the order form.
+var placeholders = map[orders.Section]string{
+	orders.CombatSection:       ";Combat orders belong here.",
+	orders.PreDepartureSection: ";Pre-departure orders belong here.",
+	orders.JumpsSection:        ";Jump orders belong here.",
+	orders.ProductionSection:   ";Production orders belong here.",
+	orders.PostArrivalSection:  ";Post-arrival orders belong here.",
+	orders.StrikesSection:      ";Strike orders belong here.",
+}
+
+// orderForm writes the order form at the end of the report, which is the
+// skeleton from the manual:
+//
+//	START COMBAT
+//	;Combat orders belong here.
+//
+//	END
+//
+// for each of the six sections, in the order they are run. The production
+// section is started with a PRODUCTION order for each of the species'
+// populated planets, and the jump section lists where each ship is so
+// that the player can write JUMP orders without looking it up. Ships
+// under construction can't jump and aren't listed.
+func (r *report) orderForm() {
+	r.section()
+	for i, section := range orders.Sections {
+		if i > 0 {
+			r.printf("\n")
+		}
+		r.printf("START %s\n", section)
+		switch section {
+		case orders.JumpsSection:
+			r.printf("%s\n", placeholders[section])
+			for _, c := range r.sectors() {
+				for _, ship := range r.g.Ships {
+					if ship.Species == r.sp.Id && ship.Coords == c && !ship.IsUnderConstruction() {
+						r.printf(";   %s %s is at %s\n", ship, r.designation(ship), c)
+					}
+				}
+			}
+			r.printf("\n")
+		case orders.ProductionSection:
+			started := false
+			for _, colony := range r.colonies() {
+				if colony.Population() > 0 {
+					r.printf("    PRODUCTION PL %s\n", colony.Name)
+					r.printf("    ; Enter your production orders for planet %s here.\n\n", colony.Name)
+					started = true
+				}
+			}
+			if !started {
+				r.printf("%s\n\n", placeholders[section])
+			}
+		default:
+			r.printf("%s\n\n", placeholders[section])
+		}
+		r.printf("END\n")
+	}
+}
@@ -0,0 +1,487 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+// Package report implements the status reports that players get after
+// each turn.
+//
+// A report shows one species what it knows at the start of the next
+// turn, in the layout used by the classic game: the species' status,
+// what happened during the last turn, its planets and ships, the aliens
+// it can see, and an order form to fill out. Everything about other
+// species goes through the engine's visibility rules so that nothing
+// leaks between players.
+//
+// Reports are plain text, and the same game state always produces the
+// same report.
+package report
+
+import (
+	"fmt"
+	"github.com/mdhender/fh/internal/catalog"
+	"github.com/mdhender/fh/internal/engine"
+	"io"
+	"sort"
+	"strings"
+)
+
+// separator is the line between the major sections of a report.
+const separator = "* * * * * * * * * * * * * * * * * * * * * * * * *"
+
+// Write writes the species' status report for the last turn that was run.
+func Write(w io.Writer, g *engine.Game, sp *engine.Species) error {
+	r := &report{g: g, sp: sp}
+	r.status()
+	r.events()
+	r.combat()
+	r.treasury()
+	r.estimates()
+	r.scans()
+	r.observations()
+	r.planets()
+	r.ships()
+	r.aliens()
+	r.orderForm()
+	_, err := io.WriteString(w, r.b.String())
+	return err
+}
+
+// report is the state for writing a single report.
+type report struct {
+	g  *engine.Game
+	sp *engine.Species
+	b  strings.Builder
+}
+
+// printf adds formatted text to the report.
+func (r *report) printf(format string, args ...any) {
+	fmt.Fprintf(&r.b, format, args...)
+}
+
+// section starts a major section of the report.
+func (r *report) section() {
+	r.printf("\n%s\n\n", separator)
+}
+
+// status writes the species' name, government, environment, tech levels,
+// treasury and diplomatic status.
+func (r *report) status() {
+	sp := r.sp
+	r.printf("\t\t\tSTART OF TURN %d\n\n", r.g.Turn+1)
+	r.printf("Species name: %s\n", sp.Name)
+	r.printf("Government name: %s\n", sp.Government)
+	r.printf("Government type: %s\n", sp.GovernmentType)
+
+	var poisonous, harmless []string
+	for _, gas := range engine.Gases {
+		if sp.IsPoison(gas) {
+			poisonous = append(poisonous, gas.String())
+		} else if gas != sp.RequiredGas {
+			harmless = append(harmless, gas.String())
+		}
+	}
+	r.printf("\nAtmospheric Requirement: %d%%-%d%% %s\n", sp.RequiredMin, sp.RequiredMax, sp.RequiredGas)
+	r.printf("Gases Poisonous to Species: %s\n", list(poisonous, ","))
+	r.printf("Gases Harmless to Species: %s\n", list(harmless, ","))
+
+	r.printf("\nTech Levels:\n")
+	for _, tech := range engine.Techs {
+		if sp.Knowledge[tech] > sp.Tech[tech] {
+			r.printf("   %s = %d/%d\n", tech.Name(), sp.Tech[tech], sp.Knowledge[tech])
+		} else {
+			r.printf("   %s = %d\n", tech.Name(), sp.Tech[tech])
+		}
+	}
+
+	r.printf("\nEconomic units = %d\n", sp.Treasury)
+	maintenance, production := r.g.FleetMaintenance(sp), 0
+	for _, colony := range r.g.Colonies {
+		if ledger := r.g.LedgerFor(colony.Id); colony.Species == sp.Id && ledger != nil {
+			production += ledger.Production() - ledger.Siege
+		}
+	}
+	if production > 0 {
+		r.printf("Fleet maintenance cost = %d (%d.%02d%% of total production)\n", maintenance, 100*maintenance/production, 10_000*maintenance/production%100)
+	} else {
+		r.printf("Fleet maintenance cost = %d\n", maintenance)
+	}
+
+	var met, allies, enemies []string
+	for _, stance := range r.g.Stances(sp) {
+		name := r.g.SpeciesById(stance.Species).String()
+		met = append(met, name)
+		switch stance.Status {
+		case engine.Ally:
+			allies = append(allies, name)
+		case engine.Enemy:
+			enemies = append(enemies, name)
+		}
+	}
+	r.printf("\nSpecies met: %s\n", list(met, ", "))
+	r.printf("Allies: %s\n", list(allies, ", "))
+	r.printf("Enemies: %s\n", list(enemies, ", "))
+}
+
+// events writes the species' log for the last turn, grouped by phase.
+func (r *report) events() {
+	phase := ""
+	for _, e := range r.g.Log {
+		if e.Species != r.sp.Id {
+			continue
+		}
+		if phase == "" {
+			r.section()
+			r.printf("EVENTS OF TURN %d:\n", r.g.Turn)
+		}
+		if e.Phase != phase {
+			phase = e.Phase
+			r.printf("\n  %s phase:\n", strings.ToUpper(phase[:1])+phase[1:])
+		}
+		if e.Line != 0 {
+			r.printf("    Line %d: %s\n", e.Line, e.Text)
+		} else {
+			r.printf("    %s\n", e.Text)
+		}
+	}
+}
+
+// combat writes the logs of the battles that the species took part in.
+func (r *report) combat() {
+	first := true
+	for _, battle := range r.g.Battles {
+		if !contains(battle.Species, r.sp.Id) {
+			continue
+		}
+		if first {
+			r.section()
+			r.printf("COMBAT LOGS:\n")
+			first = false
+		}
+		c := battle.Coords
+		r.printf("\n  Battle at x = %d, y = %d, z = %d (%s phase):\n", c.X, c.Y, c.Z, battle.Phase)
+		for _, line := range battle.LogFor(r.sp.Id) {
+			r.printf("    %s\n", line)
+		}
+	}
+}
+
+// treasury writes the changes to the species' treasury during the last turn.
+func (r *report) treasury() {
+	first := true
+	for _, e := range r.g.Audit {
+		if e.Species != r.sp.Id {
+			continue
+		}
+		if first {
+			r.section()
+			r.printf("TREASURY:\n\n")
+			r.printf("  Economic units at start of turn = %d\n\n", e.Balance-e.Amount)
+			r.printf("  %-14s %8s %8s  %s\n", "Phase", "Amount", "Balance", "Reason")
+			r.printf(" %s\n", strings.Repeat("-", 76))
+			first = false
+		}
+		r.printf("  %-14s %+8d %8d  %s\n", e.Phase, e.Amount, e.Balance, e.Text)
+	}
+}
+
+// estimates writes the species' estimates of other species' tech levels.
+func (r *report) estimates() {
+	first := true
+	for _, e := range r.g.Estimates {
+		if e.Species != r.sp.Id {
+			continue
+		}
+		if first {
+			r.section()
+			r.printf("TECH ESTIMATES:\n\n")
+			first = false
+		}
+		var levels []string
+		for _, tech := range engine.Techs {
+			levels = append(levels, fmt.Sprintf("%s = %d", tech, e.Tech[tech]))
+		}
+		r.printf("  Estimate of %s: %s\n", r.g.SpeciesById(e.Of), strings.Join(levels, ", "))
+	}
+}
+
+// scans writes the star system data from the species' scans.
+func (r *report) scans() {
+	first := true
+	for _, scan := range r.g.Scans {
+		if scan.Species != r.sp.Id {
+			continue
+		}
+		if first {
+			r.section()
+			r.printf("STAR SYSTEM DATA:\n")
+			first = false
+		}
+		r.printf("\n")
+		for _, line := range scan.Lines() {
+			r.printf("%s\n", line)
+		}
+	}
+}
+
+// observations writes what the species' gravitic telescopes saw.
+func (r *report) observations() {
+	first := true
+	for _, obs := range r.g.Observations {
+		if obs.Species != r.sp.Id {
+			continue
+		}
+		if first {
+			r.section()
+			r.printf("GRAVITIC TELESCOPE OBSERVATIONS:\n")
+			first = false
+		}
+		c := obs.Coords
+		r.printf("\n  %s at x = %d, y = %d, z = %d (range %d):\n", obs.Telescope, c.X, c.Y, c.Z, obs.Range)
+		if len(obs.Sightings) == 0 {
+			r.printf("    Nothing was detected.\n")
+		}
+		for _, s := range obs.Sightings {
+			text := fmt.Sprintf("    %-10s %s %s", s.Coords, r.g.SpeciesById(s.Species), s.Name)
+			if s.Base != 0 {
+				text += fmt.Sprintf(" (economic base is approximately %d)", s.Base)
+			}
+			if s.Telescope != 0 {
+				text += fmt.Sprintf(" (%d gravitic telescope units)", s.Telescope)
+			}
+			r.printf("%s\n", text)
+		}
+	}
+}
+
+// planets writes the species' colonies, home planet first, with what
+// each produced last turn and its inventory.
+func (r *report) planets() {
+	for _, colony := range r.colonies() {
+		planet := r.g.Galaxy.Planet(colony.Planet)
+		c := r.g.Galaxy.StarOf(planet.Id).Coords
+		r.section()
+		r.printf("%s: PL %s\n", r.kind(colony), colony.Name)
+		r.printf("   Coordinates: x = %d, y = %d, z = %d, planet number %d\n", c.X, c.Y, c.Z, planet.Orbit)
+		if colony.Population() > 0 {
+			r.printf("   Available population units = %d\n", colony.AvailablePopulation)
+			r.printf("   Economic efficiency = %d%%\n", r.g.EconomicEfficiency(colony))
+			r.printf("   Production penalty = %d%% (LSN = %d)\n", engine.ProductionPenalty(r.sp, planet), engine.LifeSupportNeeded(r.sp, planet))
+			r.printf("   Mining base = %s (MI = %d, MD = %d.%02d)\n", tenths(colony.MiningBase), r.sp.Tech[engine.MI], planet.MiningDifficulty/100, planet.MiningDifficulty%100)
+			r.printf("   Manufacturing base = %s (MA = %d)\n", tenths(colony.ManufacturingBase), r.sp.Tech[engine.MA])
+			r.printf("   Shipyard capacity = %d\n", colony.Shipyards)
+		}
+		for _, siege := range r.g.Sieges {
+			if siege.Colony == colony.Id {
+				r.printf("   Under siege by %s (%d%% effective)\n", r.g.SpeciesById(siege.Besieger), siege.Effectiveness)
+			}
+		}
+		r.ledger(r.g.LedgerFor(colony.Id))
+		if len(colony.Inventory) != 0 {
+			r.printf("\n   Planetary inventory:\n")
+			for _, item := range catalog.Items {
+				if n := colony.Inventory[item.Code]; n != 0 {
+					r.printf("      %s (%s,C%d) = %d\n", item.Name, item.Code, item.Carry, n)
+				}
+			}
+		}
+	}
+}
+
+// ledger writes a colony's production for the last turn.
+func (r *report) ledger(l *engine.Ledger) {
+	if l == nil {
+		return
+	}
+	r.printf("\n   Production last turn:\n")
+	r.printf("      Raw material units produced = %d (%d in stock)\n", l.RawMaterial, l.Stockpile)
+	r.printf("      Production capacity = %d\n", l.Capacity)
+	if l.Converted != 0 {
+		r.printf("      Converted to economic units = %d\n", l.Converted)
+	}
+	if l.Siege != 0 {
+		r.printf("      Lost to sieges = %d\n", l.Siege)
+	}
+	if l.Maintenance != 0 {
+		r.printf("      Fleet maintenance = %d\n", l.Maintenance)
+	}
+	if l.Recycled != 0 {
+		r.printf("      Recycled = %d\n", l.Recycled)
+	}
+	if l.Treasury != 0 {
+		r.printf("      From the treasury = %d\n", l.Treasury)
+	}
+	r.printf("      Available for spending = %d\n", l.Available())
+	for _, e := range l.Entries {
+		r.printf("      %8d  %s\n", e.Amount, strings.TrimSpace(e.Text))
+	}
+	r.printf("      Spent = %d\n", l.Spent)
+	r.printf("      Unspent = %d\n", l.Unspent)
+	r.printf("      Raw material units carried over = %d\n", l.CarryOver)
+}
+
+// ships writes the species' ships, grouped by location.
+func (r *report) ships() {
+	for _, c := range r.sectors() {
+		var ships []*engine.Ship
+		for _, ship := range r.g.Ships {
+			if ship.Species == r.sp.Id && ship.Coords == c {
+				ships = append(ships, ship)
+			}
+		}
+		if len(ships) == 0 {
+			continue
+		}
+		r.section()
+		r.printf("Ships at x = %d, y = %d, z = %d:\n", c.X, c.Y, c.Z)
+		r.printf("  %-45s %4s  %s\n", "Name", "Cap.", "Cargo")
+		r.printf(" %s\n", strings.Repeat("-", 76))
+		for _, ship := range ships {
+			name := fmt.Sprintf("%s %s", ship, r.designation(ship))
+			r.printf("%s\n", strings.TrimRight(fmt.Sprintf("  %-45s %4d  %s", name, ship.CarryingCapacity(), cargo(ship.Cargo)), " "))
+		}
+	}
+}
+
+// designation returns the age and location of one of the species' own
+// ships, like "(A5,O6)", or "(C)" if it is under construction.
+func (r *report) designation(ship *engine.Ship) string {
+	if ship.IsUnderConstruction() {
+		return "(C)"
+	}
+	return fmt.Sprintf("(A%d,%s)", ship.Age, r.where(ship))
+}
+
+// where returns a ship's location in its star system, like "O6" or "D".
+// Ships leaving a battle are shown as "WD" or "FJ" instead.
+func (r *report) where(ship *engine.Ship) string {
+	if ship.Withdrawn {
+		return "WD"
+	} else if ship.Forced != "" {
+		return ship.Forced
+	} else if ship.Planet == 0 || ship.Status == engine.DeepSpace {
+		return string(engine.DeepSpace)
+	}
+	return fmt.Sprintf("%s%d", ship.Status, r.g.Galaxy.Planet(ship.Planet).Orbit)
+}
+
+// aliens writes the alien ships and colonies that the species can see.
+func (r *report) aliens() {
+	for _, c := range r.sectors() {
+		contacts := r.g.ContactsAt(r.sp, c)
+		if len(contacts) == 0 {
+			continue
+		}
+		r.section()
+		r.printf("Aliens at x = %d, y = %d, z = %d:\n", c.X, c.Y, c.Z)
+		r.printf("  %-50s %s\n", "Name", "Species")
+		r.printf(" %s\n", strings.Repeat("-", 76))
+		for _, contact := range contacts {
+			if ship := contact.Ship; ship != nil {
+				name := ship.String()
+				if ship.IsDistorted() {
+					name = ship.Class + " ???"
+				}
+				r.printf("  %-50s %s\n", fmt.Sprintf("%s (%s)", name, r.where(ship)), contact.Species)
+				continue
+			}
+			colony := contact.Colony
+			r.printf("  %-50s %s\n", fmt.Sprintf("Colony planet PL %s (planet #%d)", colony.Name, r.g.Galaxy.Planet(colony.Planet).Orbit), contact.Species)
+			r.printf("      (Economic base is approximately %d.)\n", (colony.MiningBase+colony.ManufacturingBase+50)/100*10)
+			if n := contact.Items["PD"]; n != 0 {
+				r.printf("      (Planetary defense units = %d.)\n", n)
+			}
+		}
+	}
+}
+
+// colonies returns the species' colonies, home planet first and the rest
+// in the order they were founded.
+func (r *report) colonies() []*engine.Colony {
+	var colonies []*engine.Colony
+	for _, colony := range r.g.Colonies {
+		if colony.Species == r.sp.Id {
+			colonies = append(colonies, colony)
+		}
+	}
+	sort.SliceStable(colonies, func(i, j int) bool { return colonies[i].IsHome && !colonies[j].IsHome })
+	return colonies
+}
+
+// kind returns the label for a colony's section of the report.
+func (r *report) kind(colony *engine.Colony) string {
+	switch {
+	case colony.IsHome:
+		return "HOME PLANET"
+	case colony.Population() == 0:
+		return "NAMED PLANET"
+	case colony.IsMiningColony():
+		return "MINING COLONY"
+	case r.g.IsResortColony(colony):
+		return "RESORT COLONY"
+	}
+	return "COLONY PLANET"
+}
+
+// sectors returns the sectors where the species has ships or populated
+// colonies, sorted by X, then Y, then Z.
+func (r *report) sectors() []engine.Coords {
+	seen := make(map[engine.Coords]bool)
+	for _, ship := range r.g.Ships {
+		if ship.Species == r.sp.Id {
+			seen[ship.Coords] = true
+		}
+	}
+	for _, colony := range r.g.Colonies {
+		if colony.Species == r.sp.Id && colony.Population() > 0 {
+			seen[r.g.Galaxy.StarOf(colony.Planet).Coords] = true
+		}
+	}
+	var sectors []engine.Coords
+	for c := range seen {
+		sectors = append(sectors, c)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		a, b := sectors[i], sectors[j]
+		if a.X != b.X {
+			return a.X < b.X
+		} else if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+	return sectors
+}
+
+// cargo returns the items in a ship's hold, like "2 FD, 10 CU", in the
+// order that items are listed on reports.
+func cargo(items map[string]int) string {
+	var list []string
+	for _, item := range catalog.Items {
+		if n := items[item.Code]; n != 0 {
+			list = append(list, fmt.Sprintf("%d %s", n, item.Code))
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// contains returns true if the id is in the list.
+func contains(ids []int, id int) bool {
+	for _, n := range ids {
+		if n == id {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the names joined by the separator, or "none" if there
+// aren't any.
+func list(names []string, sep string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, sep)
+}
+
+// tenths formats a number stored in tenths, like a mining base.
+func tenths(n int) string {
+	return fmt.Sprintf("%d.%d", n/10, n%10)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package report_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/orders"
+	"github.com/mdhender/fh/internal/report"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// newGame returns a game after a turn with a scan, an estimate, some
+// production and a battle between the Humanoids and the Klingons, who
+// have a colony in the Humanoids' system and a field distorted transport
+// in the next sector.
+func newGame(t *testing.T) *engine.Game {
+	t.Helper()
+	g, err := engine.NewGame("test", 3, 1)
+	if err != nil {
+		t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+	}
+	for _, setup := range []engine.SpeciesSetup{
+		{Name: "Humanoid", HomePlanet: "Earth", Government: "United Nations", GovernmentType: "Democracy", ML: 4, GV: 4, LS: 4, BI: 3},
+		{Name: "Klingon", HomePlanet: "Kronos", Government: "High Council", GovernmentType: "Empire", ML: 10, GV: 1, LS: 4, BI: 0},
+	} {
+		if _, err := g.AddSpecies(setup); err != nil {
+			t.Fatalf("AddSpecies: err: expected nil: got %v\n", err)
+		}
+	}
+	earth := g.ColonyNamed(1, "Earth")
+	coords := g.Galaxy.StarOf(earth.Planet).Coords
+	next := coords
+	next.X++
+	for _, planet := range g.Galaxy.StarOf(earth.Planet).Planets {
+		if planet.Id != earth.Planet {
+			g.Colonies = append(g.Colonies, &engine.Colony{Id: 99, Species: 2, Planet: planet.Id, Name: "Outpost", MiningBase: 213, Inventory: map[string]int{"PD": 40}})
+			break
+		}
+	}
+	g.SpeciesById(1).Meet(2)
+	g.SpeciesById(1).Knowledge[engine.LS] = 6
+	g.Ships = []*engine.Ship{
+		{Id: 1, Species: 1, Class: "DD", Name: "Dagger", Tonnage: 150_000, Age: 3, Coords: coords, Status: engine.DeepSpace},
+		{Id: 2, Species: 1, Class: "TR1", Name: "Lander", Tonnage: 10_000, Coords: coords, Planet: earth.Planet, Status: engine.Landed, Cargo: map[string]int{"CU": 5, "IU": 3}},
+		{Id: 3, Species: 1, Class: "CT", Name: "Scout", Tonnage: 20_000, Age: 1, Coords: coords, Planet: earth.Planet, Status: engine.InOrbit},
+		{Id: 4, Species: 2, Class: "CT", Name: "Raider", Tonnage: 20_000, Coords: coords, Status: engine.DeepSpace},
+		{Id: 5, Species: 1, Class: "CT", Name: "Picket", Tonnage: 20_000, Coords: next, Status: engine.DeepSpace},
+		{Id: 6, Species: 2, Class: "TR1", Name: "Ghost", Tonnage: 10_000, Coords: next, Status: engine.DeepSpace, Cargo: map[string]int{"FD": 1}},
+	}
+	text := map[int]string{
+		1: fmt.Sprintf(`START PRE-DEPARTURE
+ENEMY SP Klingon
+SCAN CT Scout
+END
+START PRODUCTION
+PRODUCTION PL Earth
+ESTIMATE SP Klingon
+BUILD 10 CU
+END
+START STRIKES
+BATTLE %d %d %d
+ATTACK SP Klingon
+ENGAGE 3
+END
+`, coords.X, coords.Y, coords.Z),
+	}
+	o := make(map[int]*orders.Orders)
+	for id, s := range text {
+		if o[id], err = orders.Parse(strings.NewReader(s)); err != nil {
+			t.Fatalf("Parse: err: expected nil: got %v\n", err)
+		}
+	}
+	if err := engine.RunTurn(g, o); err != nil {
+		t.Fatalf("RunTurn: err: expected nil: got %v\n", err)
+	}
+	return g
+}
+
+func TestWrite(t *testing.T) {
+	g := newGame(t)
+	for _, sp := range g.Species {
+		b := &bytes.Buffer{}
+		if err := report.Write(b, g, sp); err != nil {
+			t.Fatalf("Write: %s: err: expected nil: got %v\n", sp.Name, err)
+		}
+		golden := filepath.Join("testdata", strings.ToLower(sp.Name)+".golden")
+		if *update {
+			if err := os.WriteFile(golden, b.Bytes(), 0644); err != nil {
+				t.Fatalf("Write: %s: err: expected nil: got %v\n", golden, err)
+			}
+		}
+		expect, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Write: %s: err: expected nil: got %v\n", golden, err)
+		}
+		if got := b.String(); got != string(expect) {
+			t.Errorf("Write: %s: expected %s: got\n%s\n", sp.Name, golden, got)
+		}
+
+		// the order form can be sent back as it is
+		form := b.String()[strings.Index(b.String(), "START COMBAT"):]
+		if _, err := orders.Parse(strings.NewReader(form)); err != nil {
+			t.Errorf("Write: %s: order form: expected nil: got %v\n", sp.Name, err)
+		}
+	}
+}
+
+func TestOrderForm(t *testing.T) {
+	g := newGame(t)
+	earth := g.ColonyNamed(1, "Earth")
+	outpost := g.ColonyNamed(2, "Outpost")
+	g.Colonies = append(g.Colonies, &engine.Colony{Id: 100, Species: 1, Planet: outpost.Planet, Name: "Mars", ManufacturingBase: 50})
+	g.Ships = append(g.Ships, &engine.Ship{Id: 7, Species: 1, Class: "CT", Name: "Unfinished", Tonnage: 20_000, Remaining: 20, Coords: g.Galaxy.StarOf(earth.Planet).Coords})
+	b := &bytes.Buffer{}
+	if err := report.Write(b, g, g.SpeciesById(1)); err != nil {
+		t.Fatalf("Write: err: expected nil: got %v\n", err)
+	}
+
+	// the manual's skeleton, with hints for jumps and production started
+	expect := `START COMBAT
+;Combat orders belong here.
+
+END
+
+START PRE-DEPARTURE
+;Pre-departure orders belong here.
+
+END
+
+START JUMPS
+;Jump orders belong here.
+;   DD Dagger (A4,D) is at 15 19 5
+;   TR1 Lander (A1,L1) is at 15 19 5
+;   CT Scout (A2,O1) is at 15 19 5
+;   CT Picket (A1,D) is at 16 19 5
+
+END
+
+START PRODUCTION
+    PRODUCTION PL Earth
+    ; Enter your production orders for planet Earth here.
+
+    PRODUCTION PL Mars
+    ; Enter your production orders for planet Mars here.
+
+END
+
+START POST-ARRIVAL
+;Post-arrival orders belong here.
+
+END
+
+START STRIKES
+;Strike orders belong here.
+
+END
+`
+	if got := b.String()[strings.Index(b.String(), "START COMBAT"):]; got != expect {
+		t.Errorf("Write: order form: expected\n%s\ngot\n%s\n", expect, got)
+	}
+}
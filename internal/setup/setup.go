@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+// Package setup implements a parser for the set-up form that players
+// send to the gamemaster to enter a game.
+//
+// The form is the one in the manual. Each answer follows the label on
+// the same line:
+//
+//	Military: 4
+//	Gravitics: 4
+//	Life Support: 4
+//	Biology: 3
+//	Species name (MUST contain 7 or more characters): Humanoid
+//	Home planet name: Earth
+//	Government name: United Nations
+//	Government type: Democracy
+//
+// Lines without a colon, like the instructions on the form, are ignored.
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Error is an error on the set-up form.
+// It quotes the offending line so that players can find and fix it.
+type Error struct {
+	Line int    // line number on the form, 0 if the error isn't for a single line
+	Text string // the line as entered
+	Msg  string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d: %q: %s", e.Line, e.Text, e.Msg)
+}
+
+// ErrorList is the list of errors found on the form.
+type ErrorList []*Error
+
+// Error implements the error interface.
+func (el ErrorList) Error() string {
+	var lines []string
+	for _, e := range el {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parse reads a set-up form and returns the validated set-up.
+// If there are errors on the form, the error is an ErrorList.
+func Parse(r io.Reader) (engine.SpeciesSetup, error) {
+	var s engine.SpeciesSetup
+	var errs ErrorList
+	errorf := func(line int, text, format string, args ...any) {
+		errs = append(errs, &Error{Line: line, Text: text, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	// the fields on the form, by label
+	names := map[string]*string{
+		"species name":     &s.Name,
+		"home planet name": &s.HomePlanet,
+		"government name":  &s.Government,
+		"government type":  &s.GovernmentType,
+	}
+	levels := map[string]*int{
+		"military":     &s.ML,
+		"gravitics":    &s.GV,
+		"life support": &s.LS,
+		"biology":      &s.BI,
+	}
+	seen := make(map[string]bool)
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimRight(scanner.Text(), "\r"))
+		label, value, ok := strings.Cut(text, ":")
+		if !ok || strings.HasPrefix(text, "[") {
+			continue
+		}
+		// drop any comment in the label, like "(MUST contain 7 or more characters)"
+		if n := strings.IndexByte(label, '('); n != -1 {
+			label = label[:n]
+		}
+		label, value = strings.ToLower(strings.Join(strings.Fields(label), " ")), strings.TrimSpace(value)
+
+		if seen[label] {
+			errorf(line, text, "duplicate entry for %s", label)
+			continue
+		}
+		seen[label] = true
+
+		if field, ok := names[label]; ok {
+			if err := engine.ValidateName(value); err != nil {
+				errorf(line, text, "%s: %v", label, err)
+			}
+			*field = value
+		} else if field, ok := levels[label]; ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				errorf(line, text, "%s: tech level must be a whole number, 0 or more", label)
+			}
+			*field = n
+		} else if label == "mining" || label == "manufacturing" {
+			if n, err := strconv.Atoi(value); err != nil || n != engine.StartingTechLevel {
+				errorf(line, text, "%s: all species start at %d", label, engine.StartingTechLevel)
+			}
+		} else {
+			errorf(line, text, "unknown entry %q", label)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return s, err
+	}
+
+	for _, label := range []string{"military", "gravitics", "life support", "biology", "species name", "home planet name", "government name", "government type"} {
+		if !seen[label] {
+			errorf(0, "", "missing entry for %s", label)
+		}
+	}
+	if len(errs) == 0 {
+		if err := s.Validate(); err != nil {
+			errorf(0, "", "%v", err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return s, errs
+	}
+	return s, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package setup_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/setup"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	form := `SET-UP FORM FOR ENTERING A GAME
+Allocate points to Military, Gravitics, Life Support, and Biology tech levels.
+You have a total of 15 points to allocate.
+     Military: 4
+     Gravitics: 5
+     Life Support: 3
+     Biology: 3
+[REMINDER: If a tech level is zero, you will not be able to raise it unless
+another species transfers the knowledge or technology to you.]
+Next, enter the names for your species, home planet, and government.
+You may use up to 31 characters each.
+     Species name (MUST contain 7 or more characters): Jubjub Denboy
+     Home planet name: Giver of Life
+     Government name: The Jubjub Denboy Empire
+     Government type: Benevolent Plutocracy
+`
+	s, err := setup.Parse(strings.NewReader(form))
+	if err != nil {
+		t.Fatalf("Parse: err: expected nil: got %v\n", err)
+	}
+	expect := engine.SpeciesSetup{
+		Name:           "Jubjub Denboy",
+		HomePlanet:     "Giver of Life",
+		Government:     "The Jubjub Denboy Empire",
+		GovernmentType: "Benevolent Plutocracy",
+		ML:             4, GV: 5, LS: 3, BI: 3,
+	}
+	if s != expect {
+		t.Errorf("Parse: expected %+v: got %+v\n", expect, s)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := map[string]string{
+		"Military":         "Military: 4",
+		"Gravitics":        "Gravitics: 4",
+		"Life Support":     "Life Support: 4",
+		"Biology":          "Biology: 3",
+		"Species name":     "Species name: Klingon",
+		"Home planet name": "Home planet name: Qo'noS",
+		"Government name":  "Government name: Klingon Empire",
+		"Government type":  "Government type: Feudal Monarchy",
+	}
+	form := func(changes map[string]string) string {
+		var lines []string
+		for _, label := range []string{"Military", "Gravitics", "Life Support", "Biology", "Species name", "Home planet name", "Government name", "Government type"} {
+			line, ok := changes[label]
+			if !ok {
+				line = valid[label]
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if _, err := setup.Parse(strings.NewReader(form(nil))); err != nil {
+		t.Fatalf("Parse: valid: err: expected nil: got %v\n", err)
+	}
+
+	for _, tc := range []struct {
+		changes map[string]string
+		expect  string
+	}{
+		{map[string]string{"Biology": "Biology: 4"}, "tech levels must add up to 15, not 16"},
+		{map[string]string{"Biology": "Biology: three"}, `line 4: "Biology: three": biology: tech level must be a whole number, 0 or more`},
+		{map[string]string{"Species name": "Species name: Orcs"}, "species name: must have at least 7 characters"},
+		{map[string]string{"Home planet name": "Home planet name: Earth, Moon"}, `line 6: "Home planet name: Earth, Moon": home planet name: "Earth, Moon": commas and semi-colons are not allowed`},
+		{map[string]string{"Government name": "Government name: The Extremely Long Name Of Our Government"}, "more than 31 characters"},
+		{map[string]string{"Government type": ""}, "missing entry for government type"},
+		{map[string]string{"Military": "Mining: 12"}, `line 1: "Mining: 12": mining: all species start at 10`},
+		{map[string]string{"Military": "Militia: 4"}, `line 1: "Militia: 4": unknown entry "militia"`},
+	} {
+		_, err := setup.Parse(strings.NewReader(form(tc.changes)))
+		var el setup.ErrorList
+		if !errors.As(err, &el) {
+			t.Errorf("Parse: %v: err: expected ErrorList: got %v\n", tc.changes, err)
+		} else if !strings.Contains(el[0].Error(), tc.expect) {
+			t.Errorf("Parse: %v: err: expected %q: got %q\n", tc.changes, tc.expect, el[0].Error())
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package store
+
+// Errors used by the package.
+const (
+	ErrInvalidGameId      = constError("invalid game id")
+	ErrNotDirectory       = constError("not a directory")
+	ErrNotFound           = constError("not found")
+	ErrUnsupportedVersion = constError("unsupported version")
+)
+
+// declarations to support constant errors
+type constError string
+
+func (ce constError) Error() string {
+	return string(ce)
+}
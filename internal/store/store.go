@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+// Package store implements a persistent store for game state.
+//
+// Each turn of a game is saved as a separate JSON document, so any past
+// turn can be loaded to roll a game back or to replay its history.
+// The documents are kept under the working directory:
+//
+//	games/<game id>/turn-0000.json
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mdhender/fh/internal/engine"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the snapshot document.
+// It must be changed when the document changes in a way that older
+// code can't read.
+const Version = 1
+
+// Store saves and loads turn snapshots.
+type Store struct {
+	root string // path to the games directory
+}
+
+// document is the snapshot of a single turn.
+type document struct {
+	Version int          `json:"version"`
+	Game    *engine.Game `json:"game"`
+}
+
+var validGameId = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// New returns a store that keeps games in the working directory.
+// The working directory must exist.
+func New(workingDir string) (*Store, error) {
+	if sb, err := os.Stat(workingDir); err != nil {
+		return nil, err
+	} else if !sb.IsDir() {
+		return nil, fmt.Errorf("%s: %w", workingDir, ErrNotDirectory)
+	}
+	return &Store{root: filepath.Join(workingDir, "games")}, nil
+}
+
+// Save writes a snapshot of the game for its current turn.
+// An existing snapshot for the same turn is replaced.
+// The write is atomic: readers see either the old snapshot or the new one.
+func (s *Store) Save(g *engine.Game) error {
+	if !validGameId.MatchString(g.Id) {
+		return fmt.Errorf("%q: %w", g.Id, ErrInvalidGameId)
+	}
+	data, err := json.MarshalIndent(document{Version: Version, Game: g}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(s.root, g.Id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, turnFile(g.Turn))
+	log.Printf("[store] save: %q\n", path)
+
+	// write to a temporary file in the same directory and then rename it
+	// over the snapshot, so that a crash never leaves a partial file.
+	fp, err := os.CreateTemp(dir, ".turn-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := fp.Name()
+	if _, err = fp.Write(data); err == nil {
+		err = fp.Sync()
+	}
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Load returns the game as it was saved for the turn.
+func (s *Store) Load(id string, turn int) (*engine.Game, error) {
+	if !validGameId.MatchString(id) {
+		return nil, fmt.Errorf("%q: %w", id, ErrInvalidGameId)
+	}
+	data, err := os.ReadFile(filepath.Join(s.root, id, turnFile(turn)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: turn %d: %w", id, turn, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: turn %d: %w", id, turn, err)
+	} else if doc.Version != Version {
+		return nil, fmt.Errorf("%s: turn %d: version %d: %w", id, turn, doc.Version, ErrUnsupportedVersion)
+	} else if doc.Game == nil {
+		return nil, fmt.Errorf("%s: turn %d: %w", id, turn, ErrNotFound)
+	}
+	return doc.Game, nil
+}
+
+// Latest returns the most recent turn saved for the game.
+func (s *Store) Latest(id string) (*engine.Game, error) {
+	turns, err := s.Turns(id)
+	if err != nil {
+		return nil, err
+	} else if len(turns) == 0 {
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	return s.Load(id, turns[len(turns)-1])
+}
+
+// Turns returns the turns that have been saved for the game, in order.
+func (s *Store) Turns(id string) ([]int, error) {
+	if !validGameId.MatchString(id) {
+		return nil, fmt.Errorf("%q: %w", id, ErrInvalidGameId)
+	}
+	entries, err := os.ReadDir(filepath.Join(s.root, id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	var turns []int
+	for _, entry := range entries {
+		if turn, ok := parseTurnFile(entry.Name()); ok && !entry.IsDir() {
+			turns = append(turns, turn)
+		}
+	}
+	sort.Ints(turns)
+	return turns, nil
+}
+
+// Rollback deletes all the snapshots after the turn, so that the turn
+// becomes the latest one and can be run again.
+func (s *Store) Rollback(id string, turn int) error {
+	turns, err := s.Turns(id)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, t := range turns {
+		found = found || t == turn
+	}
+	if !found {
+		return fmt.Errorf("%s: turn %d: %w", id, turn, ErrNotFound)
+	}
+	for _, t := range turns {
+		if t > turn {
+			path := filepath.Join(s.root, id, turnFile(t))
+			log.Printf("[store] rollback: remove %q\n", path)
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func turnFile(turn int) string {
+	return fmt.Sprintf("turn-%04d.json", turn)
+}
+
+func parseTurnFile(name string) (int, bool) {
+	if !strings.HasPrefix(name, "turn-") || !strings.HasSuffix(name, ".json") {
+		return 0, false
+	}
+	turn, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "turn-"), ".json"))
+	if err != nil || turn < 0 {
+		return 0, false
+	}
+	return turn, true
+}
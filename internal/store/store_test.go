@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Michael D Henderson. All rights reserved.
+
+package store_test
+
+import (
+	"errors"
+	"github.com/mdhender/fh/internal/engine"
+	"github.com/mdhender/fh/internal/store"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("New: err: expected nil: got %v\n", err)
+	}
+
+	g, err := engine.NewGame("alpha", 3, 1)
+	if err != nil {
+		t.Fatalf("NewGame: err: expected nil: got %v\n", err)
+	}
+	g.Species = []*engine.Species{{Id: 1, Name: "Humans"}}
+	g.Transactions = []*engine.Transaction{{Kind: "SEND", From: 1, To: 1, Amount: 5}}
+	for turn := 0; turn < 3; turn++ {
+		g.Turn = turn
+		if err := s.Save(g); err != nil {
+			t.Fatalf("Save: turn %d: err: expected nil: got %v\n", turn, err)
+		}
+	}
+
+	if turns, err := s.Turns("alpha"); err != nil {
+		t.Fatalf("Turns: err: expected nil: got %v\n", err)
+	} else if !reflect.DeepEqual(turns, []int{0, 1, 2}) {
+		t.Errorf("Turns: expected [0 1 2]: got %v\n", turns)
+	}
+
+	// no temporary files are left behind
+	if entries, _ := os.ReadDir(filepath.Join(dir, "games", "alpha")); len(entries) != 3 {
+		t.Errorf("Save: files: expected 3: got %d\n", len(entries))
+	}
+
+	got, err := s.Load("alpha", 2)
+	if err != nil {
+		t.Fatalf("Load: err: expected nil: got %v\n", err)
+	} else if !reflect.DeepEqual(got, g) {
+		t.Errorf("Load: expected saved game: got %+v\n", got)
+	}
+
+	if err := s.Rollback("alpha", 1); err != nil {
+		t.Fatalf("Rollback: err: expected nil: got %v\n", err)
+	}
+	if got, err := s.Latest("alpha"); err != nil {
+		t.Fatalf("Latest: err: expected nil: got %v\n", err)
+	} else if got.Turn != 1 {
+		t.Errorf("Latest: turn: expected 1: got %d\n", got.Turn)
+	}
+	if _, err := s.Load("alpha", 2); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Load: rolled back: err: expected ErrNotFound: got %v\n", err)
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("New: err: expected nil: got %v\n", err)
+	}
+
+	if err := s.Save(&engine.Game{Id: "../escape"}); !errors.Is(err, store.ErrInvalidGameId) {
+		t.Errorf("Save: err: expected ErrInvalidGameId: got %v\n", err)
+	}
+	if _, err := s.Load("missing", 0); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Load: err: expected ErrNotFound: got %v\n", err)
+	}
+
+	path := filepath.Join(dir, "games", "beta", "turn-0000.json")
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	_ = os.WriteFile(path, []byte(`{"version": 99, "game": {"id": "beta"}}`), 0644)
+	if _, err := s.Load("beta", 0); !errors.Is(err, store.ErrUnsupportedVersion) {
+		t.Errorf("Load: err: expected ErrUnsupportedVersion: got %v\n", err)
+	}
+}